@@ -1,11 +1,37 @@
 package main
 
 import (
-	"fmt"
+	"TodoApp/src/main/config"
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	fmt.Println("Rest API v1.0 - Mux Routers")
-	todoController := InitializeTodoController()
-	todoController.HandleRequests()
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		// The config could not be loaded, so its failure is logged as configured by default
+		provideLogger(config.Default()).Error("Failed to load config", "error", err)
+		os.Exit(1)
+	}
+	logger := provideLogger(cfg)
+	logger.Info("Rest API v1.0 - Mux Routers")
+	server, cleanup, err := InitializeServer(cfg)
+	if err != nil {
+		logger.Error("Failed to initialize server", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = server.Run(ctx)
+	// The store is only flushed once every in-flight request has been drained, so no writes are lost
+	cleanup()
+	if err != nil {
+		logger.Error("Server stopped unexpectedly", "error", err)
+		stop()
+		os.Exit(1)
+	}
+	logger.Info("Server stopped")
 }
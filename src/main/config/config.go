@@ -0,0 +1,398 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// StoreMemory selects the in-memory TodoService backend
+	StoreMemory = "memory"
+	// StoreSqlite selects the SQLite backed TodoService backend
+	StoreSqlite = "sqlite"
+	// StoreFile selects the TodoService backend persisted within an append-only log file
+	StoreFile = "file"
+	// StoreEvents selects the event-sourced TodoService backend
+	StoreEvents = "events"
+)
+
+const (
+	// CompletionIndependent lets todo items be completed regardless of their subtasks
+	CompletionIndependent = "independent"
+	// CompletionCascade completes every subtask of a todo item when it is completed
+	CompletionCascade = "cascade"
+	// CompletionRequire only lets todo items be completed once every one of their subtasks has been completed
+	CompletionRequire = "require"
+)
+
+// Config holds the settings used when starting the API. Composed of the following fields:
+//
+// Store: The TodoService backend to use, one of "memory", "sqlite", "file" or "events"
+//
+// SqlitePath: The path of the SQLite database file, only used when Store is "sqlite"
+//
+// FilePath: The path of the log file todo items are persisted within, only used when Store is "file". Its snapshot is
+// kept alongside it, with ".snapshot" appended to its name
+//
+// FileCompactAfter: How many records the log file may hold before it is compacted into its snapshot, only used when
+// Store is "file"
+//
+// EventsPath: The path of the log file the event stream is persisted within, only used when Store is "events". Empty
+// holds the stream in-memory so it is lost when the API restarts
+//
+// AllowClientIds: Whether clients may supply the id of new todo items, otherwise ids are always generated by the API
+//
+// CompletionRule: What completing a todo item with subtasks means, one of "independent", "cascade" or "require"
+//
+// TrashRetention: How long deleted todo items are kept in the trash before being permanently removed, zero keeps them
+// until they are removed by hand
+//
+// TrashPurgeInterval: How often the trash is checked for todo items which have been kept for longer than TrashRetention
+//
+// FeedBufferSize: How many of the most recent changes are kept for clients resuming a stream of changes
+//
+// FeedHeartbeatInterval: How long a stream of changes may be idle before a heartbeat is sent down it
+//
+// SocketPingInterval: How often a ping is sent to each WebSocket client, which is disconnected if it does not answer
+// before the next one is due
+//
+// SocketSendBuffer: How many messages can wait to be sent to a WebSocket client before it is disconnected for falling
+// behind
+//
+// WebhookPath: The path of the file webhooks are persisted within, their queued deliveries are logged alongside it.
+// Empty holds them in-memory so they are lost when the API restarts
+//
+// WebhookTimeout: How long a webhook has to respond to a delivery before the attempt is counted as failed
+//
+// WebhookMaxAttempts: How many times a delivery is attempted before it is marked as failed
+//
+// WebhookBackoff: How long a delivery waits after its first failed attempt, doubling after each further failed attempt
+//
+// LogLevel: The minimum level of the log records written, one of "debug", "info", "warn" or "error"
+//
+// Server: The settings of the HTTP server the API is served from
+type Config struct {
+	Store                 string        `yaml:"store" toml:"store"`
+	SqlitePath            string        `yaml:"sqlite_path" toml:"sqlite_path"`
+	FilePath              string        `yaml:"file_path" toml:"file_path"`
+	FileCompactAfter      int           `yaml:"file_compact_after" toml:"file_compact_after"`
+	EventsPath            string        `yaml:"events_path" toml:"events_path"`
+	AllowClientIds        bool          `yaml:"allow_client_ids" toml:"allow_client_ids"`
+	CompletionRule        string        `yaml:"completion_rule" toml:"completion_rule"`
+	TrashRetention        time.Duration `yaml:"trash_retention" toml:"trash_retention"`
+	TrashPurgeInterval    time.Duration `yaml:"trash_purge_interval" toml:"trash_purge_interval"`
+	FeedBufferSize        int           `yaml:"feed_buffer_size" toml:"feed_buffer_size"`
+	FeedHeartbeatInterval time.Duration `yaml:"feed_heartbeat_interval" toml:"feed_heartbeat_interval"`
+	SocketPingInterval    time.Duration `yaml:"socket_ping_interval" toml:"socket_ping_interval"`
+	SocketSendBuffer      int           `yaml:"socket_send_buffer" toml:"socket_send_buffer"`
+	WebhookPath           string        `yaml:"webhook_path" toml:"webhook_path"`
+	WebhookTimeout        time.Duration `yaml:"webhook_timeout" toml:"webhook_timeout"`
+	WebhookMaxAttempts    int           `yaml:"webhook_max_attempts" toml:"webhook_max_attempts"`
+	WebhookBackoff        time.Duration `yaml:"webhook_backoff" toml:"webhook_backoff"`
+	LogLevel              slog.Level    `yaml:"log_level" toml:"log_level"`
+	Server                ServerConfig  `yaml:"server" toml:"server"`
+}
+
+// ServerConfig holds the settings of the HTTP server. Composed of the following fields:
+//
+// Addr: The TCP address the server listens on, e.g. ":10000"
+//
+// ReadTimeout: The maximum duration for reading an entire request, including its body
+//
+// ReadHeaderTimeout: The maximum duration for reading the headers of a request
+//
+// WriteTimeout: The maximum duration before timing out writes of a response
+//
+// IdleTimeout: The maximum duration to wait for the next request on a keep-alive connection
+//
+// ShutdownTimeout: The maximum duration to wait for in-flight requests to complete when shutting down
+//
+// MaxHeaderBytes: The maximum size of the headers of a request
+//
+// MaxBodyBytes: The maximum size of the body of a request, larger requests are rejected with a 413
+type ServerConfig struct {
+	Addr              string        `yaml:"addr" toml:"addr"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" toml:"max_header_bytes"`
+	MaxBodyBytes      int64         `yaml:"max_body_bytes" toml:"max_body_bytes"`
+}
+
+// Default returns the Config used when no other settings are supplied: an in-memory store with server generated ids,
+// keeping deleted todo items for 30 days, served on port 10000
+func Default() Config {
+	return Config{
+		Store:                 StoreMemory,
+		SqlitePath:            "todos.db",
+		FilePath:              "todos.jsonl",
+		FileCompactAfter:      1000,
+		EventsPath:            "events.jsonl",
+		CompletionRule:        CompletionIndependent,
+		TrashRetention:        30 * 24 * time.Hour,
+		TrashPurgeInterval:    time.Hour,
+		FeedBufferSize:        1024,
+		FeedHeartbeatInterval: 15 * time.Second,
+		SocketPingInterval:    30 * time.Second,
+		SocketSendBuffer:      64,
+		WebhookPath:           "webhooks.json",
+		WebhookTimeout:        10 * time.Second,
+		WebhookMaxAttempts:    8,
+		WebhookBackoff:        10 * time.Second,
+		LogLevel:              slog.LevelInfo,
+		Server: ServerConfig{
+			Addr:              ":10000",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      15 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			MaxBodyBytes:      1 << 20,
+		},
+	}
+}
+
+// Load builds the Config used when starting the API from the command line arguments in args, which should not include
+// the program name. Settings are read from the following sources, with later sources taking precedence over earlier
+// ones:
+//
+// 1. The values returned by Default
+//
+// 2. A YAML or TOML file, chosen by its extension, named by the -config flag or the TODO_CONFIG environment variable
+//
+// 3. Environment variables, e.g. TODO_STORE or TODO_ADDR
+//
+// 4. Command line flags, e.g. -store or -addr
+func Load(args []string) (Config, error) {
+	// The flags are parsed once up front to find the config file, then again once the file and environment variables
+	// have been applied, so that only flags which were explicitly set override them
+	scratch := Default()
+	path, err := parseFlags(args, &scratch)
+	if err != nil {
+		return Config{}, err
+	}
+	if path == "" {
+		path = os.Getenv("TODO_CONFIG")
+	}
+
+	cfg := Default()
+	if path != "" {
+		err = loadFile(path, &cfg)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+	loadEnv(&cfg)
+	_, err = parseFlags(args, &cfg)
+	if err != nil {
+		return Config{}, err
+	}
+	return cfg, cfg.validate()
+}
+
+// parseFlags parses args into cfg, only changing the fields whose flag was set, and returns the value of the -config flag
+func parseFlags(args []string, cfg *Config) (string, error) {
+	flags := flag.NewFlagSet("todo", flag.ContinueOnError)
+	path := flags.String("config", "", "path of a YAML or TOML config file")
+	flags.StringVar(&cfg.Store, "store", cfg.Store, "todo store to use, one of memory, sqlite, file or events")
+	flags.StringVar(&cfg.SqlitePath, "sqlite-path", cfg.SqlitePath, "path of the SQLite database file")
+	flags.StringVar(&cfg.FilePath, "file-path", cfg.FilePath, "path of the todo log file")
+	flags.IntVar(&cfg.FileCompactAfter, "file-compact-after", cfg.FileCompactAfter, "number of records the todo log file holds before it is compacted")
+	flags.StringVar(&cfg.EventsPath, "events-path", cfg.EventsPath, "path of the event log file, empty keeps the event stream in-memory")
+	flags.BoolVar(&cfg.AllowClientIds, "allow-client-ids", cfg.AllowClientIds, "allow clients to supply todo ids")
+	flags.StringVar(&cfg.CompletionRule, "completion-rule", cfg.CompletionRule,
+		"what completing a todo with subtasks means, one of independent, cascade or require")
+	flags.DurationVar(&cfg.TrashRetention, "trash-retention", cfg.TrashRetention, "how long deleted todos are kept, 0 keeps them forever")
+	flags.DurationVar(&cfg.TrashPurgeInterval, "trash-purge-interval", cfg.TrashPurgeInterval, "how often expired todos are removed from the trash")
+	flags.IntVar(&cfg.FeedBufferSize, "feed-buffer-size", cfg.FeedBufferSize, "number of recent changes kept for resuming streams")
+	flags.DurationVar(&cfg.FeedHeartbeatInterval, "feed-heartbeat-interval", cfg.FeedHeartbeatInterval, "how long a change stream may be idle before a heartbeat is sent")
+	flags.DurationVar(&cfg.SocketPingInterval, "socket-ping-interval", cfg.SocketPingInterval, "how often WebSocket clients are pinged")
+	flags.IntVar(&cfg.SocketSendBuffer, "socket-send-buffer", cfg.SocketSendBuffer, "number of messages which can wait to be sent to a WebSocket client")
+	flags.StringVar(&cfg.WebhookPath, "webhook-path", cfg.WebhookPath, "path of the webhooks file, empty keeps webhooks in-memory")
+	flags.DurationVar(&cfg.WebhookTimeout, "webhook-timeout", cfg.WebhookTimeout, "how long a webhook has to respond to a delivery")
+	flags.IntVar(&cfg.WebhookMaxAttempts, "webhook-max-attempts", cfg.WebhookMaxAttempts, "number of times a webhook delivery is attempted")
+	flags.DurationVar(&cfg.WebhookBackoff, "webhook-backoff", cfg.WebhookBackoff, "how long a webhook delivery waits after its first failed attempt")
+	flags.TextVar(&cfg.LogLevel, "log-level", cfg.LogLevel, "minimum level of logs to write, one of debug, info, warn or error")
+	flags.StringVar(&cfg.Server.Addr, "addr", cfg.Server.Addr, "address to listen on")
+	flags.DurationVar(&cfg.Server.ReadTimeout, "read-timeout", cfg.Server.ReadTimeout, "maximum duration for reading a request")
+	flags.DurationVar(&cfg.Server.ReadHeaderTimeout, "read-header-timeout", cfg.Server.ReadHeaderTimeout, "maximum duration for reading request headers")
+	flags.DurationVar(&cfg.Server.WriteTimeout, "write-timeout", cfg.Server.WriteTimeout, "maximum duration for writing a response")
+	flags.DurationVar(&cfg.Server.IdleTimeout, "idle-timeout", cfg.Server.IdleTimeout, "maximum duration a keep-alive connection may be idle")
+	flags.DurationVar(&cfg.Server.ShutdownTimeout, "shutdown-timeout", cfg.Server.ShutdownTimeout, "maximum duration to drain requests when shutting down")
+	flags.IntVar(&cfg.Server.MaxHeaderBytes, "max-header-bytes", cfg.Server.MaxHeaderBytes, "maximum size of request headers")
+	flags.Int64Var(&cfg.Server.MaxBodyBytes, "max-body-bytes", cfg.Server.MaxBodyBytes, "maximum size of request bodies")
+	err := flags.Parse(args)
+	return *path, err
+}
+
+// loadFile decodes the YAML or TOML file found at path into cfg, fields missing from the file are left unchanged
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("config file [%s] must have a .yaml, .yml or .toml extension", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file [%s]: %w", path, err)
+	}
+	return nil
+}
+
+// loadEnv overrides the fields of cfg whose environment variable is set
+func loadEnv(cfg *Config) {
+	cfg.Store = getEnv("TODO_STORE", cfg.Store)
+	cfg.SqlitePath = getEnv("TODO_SQLITE_PATH", cfg.SqlitePath)
+	cfg.FilePath = getEnv("TODO_FILE_PATH", cfg.FilePath)
+	cfg.FileCompactAfter = int(getIntEnv("TODO_FILE_COMPACT_AFTER", int64(cfg.FileCompactAfter)))
+	cfg.EventsPath = getEnv("TODO_EVENTS_PATH", cfg.EventsPath)
+	cfg.AllowClientIds = getBoolEnv("TODO_ALLOW_CLIENT_IDS", cfg.AllowClientIds)
+	cfg.CompletionRule = getEnv("TODO_COMPLETION_RULE", cfg.CompletionRule)
+	cfg.TrashRetention = getDurationEnv("TODO_TRASH_RETENTION", cfg.TrashRetention)
+	cfg.TrashPurgeInterval = getDurationEnv("TODO_TRASH_PURGE_INTERVAL", cfg.TrashPurgeInterval)
+	cfg.FeedBufferSize = int(getIntEnv("TODO_FEED_BUFFER_SIZE", int64(cfg.FeedBufferSize)))
+	cfg.FeedHeartbeatInterval = getDurationEnv("TODO_FEED_HEARTBEAT_INTERVAL", cfg.FeedHeartbeatInterval)
+	cfg.SocketPingInterval = getDurationEnv("TODO_SOCKET_PING_INTERVAL", cfg.SocketPingInterval)
+	cfg.SocketSendBuffer = int(getIntEnv("TODO_SOCKET_SEND_BUFFER", int64(cfg.SocketSendBuffer)))
+	cfg.WebhookPath = getEnv("TODO_WEBHOOK_PATH", cfg.WebhookPath)
+	cfg.WebhookTimeout = getDurationEnv("TODO_WEBHOOK_TIMEOUT", cfg.WebhookTimeout)
+	cfg.WebhookMaxAttempts = int(getIntEnv("TODO_WEBHOOK_MAX_ATTEMPTS", int64(cfg.WebhookMaxAttempts)))
+	cfg.WebhookBackoff = getDurationEnv("TODO_WEBHOOK_BACKOFF", cfg.WebhookBackoff)
+	cfg.LogLevel = getLevelEnv("TODO_LOG_LEVEL", cfg.LogLevel)
+	cfg.Server.Addr = getEnv("TODO_ADDR", cfg.Server.Addr)
+	cfg.Server.ReadTimeout = getDurationEnv("TODO_READ_TIMEOUT", cfg.Server.ReadTimeout)
+	cfg.Server.ReadHeaderTimeout = getDurationEnv("TODO_READ_HEADER_TIMEOUT", cfg.Server.ReadHeaderTimeout)
+	cfg.Server.WriteTimeout = getDurationEnv("TODO_WRITE_TIMEOUT", cfg.Server.WriteTimeout)
+	cfg.Server.IdleTimeout = getDurationEnv("TODO_IDLE_TIMEOUT", cfg.Server.IdleTimeout)
+	cfg.Server.ShutdownTimeout = getDurationEnv("TODO_SHUTDOWN_TIMEOUT", cfg.Server.ShutdownTimeout)
+	cfg.Server.MaxHeaderBytes = int(getIntEnv("TODO_MAX_HEADER_BYTES", int64(cfg.Server.MaxHeaderBytes)))
+	cfg.Server.MaxBodyBytes = getIntEnv("TODO_MAX_BODY_BYTES", cfg.Server.MaxBodyBytes)
+}
+
+// validate reports the first setting of cfg which the API cannot be started with
+func (cfg Config) validate() error {
+	switch {
+	case cfg.Store != StoreMemory && cfg.Store != StoreSqlite && cfg.Store != StoreFile && cfg.Store != StoreEvents:
+		return fmt.Errorf("unknown todo store [%s]", cfg.Store)
+	case cfg.FileCompactAfter <= 0:
+		return fmt.Errorf("file compact after must be positive")
+	case cfg.CompletionRule != CompletionIndependent && cfg.CompletionRule != CompletionCascade &&
+		cfg.CompletionRule != CompletionRequire:
+		return fmt.Errorf("unknown completion rule [%s]", cfg.CompletionRule)
+	case cfg.TrashRetention < 0:
+		return fmt.Errorf("trash retention cannot be negative")
+	case cfg.TrashPurgeInterval <= 0:
+		return fmt.Errorf("trash purge interval must be positive")
+	case cfg.FeedBufferSize <= 0:
+		return fmt.Errorf("feed buffer size must be positive")
+	case cfg.FeedHeartbeatInterval <= 0:
+		return fmt.Errorf("feed heartbeat interval must be positive")
+	case cfg.SocketPingInterval <= 0:
+		return fmt.Errorf("socket ping interval must be positive")
+	case cfg.SocketSendBuffer <= 0:
+		return fmt.Errorf("socket send buffer must be positive")
+	case cfg.WebhookTimeout <= 0:
+		return fmt.Errorf("webhook timeout must be positive")
+	case cfg.WebhookMaxAttempts <= 0:
+		return fmt.Errorf("webhook max attempts must be positive")
+	case cfg.WebhookBackoff <= 0:
+		return fmt.Errorf("webhook backoff must be positive")
+	case cfg.Server.Addr == "":
+		return fmt.Errorf("server address cannot be empty")
+	case cfg.Server.ReadTimeout < 0, cfg.Server.ReadHeaderTimeout < 0, cfg.Server.WriteTimeout < 0,
+		cfg.Server.IdleTimeout < 0, cfg.Server.ShutdownTimeout < 0:
+		return fmt.Errorf("server timeouts cannot be negative")
+	case cfg.Server.MaxHeaderBytes <= 0, cfg.Server.MaxBodyBytes <= 0:
+		return fmt.Errorf("server size limits must be positive")
+	}
+	return nil
+}
+
+// getEnv returns the value of the environment variable named by key, or fallback if it is unset or empty
+func getEnv(key string, fallback string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	return value
+}
+
+// getBoolEnv returns the value of the environment variable named by key parsed as a boolean, or fallback if it is unset
+// or cannot be parsed
+func getBoolEnv(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Ignoring invalid value [%s] for %s\n", value, key)
+		return fallback
+	}
+	return parsed
+}
+
+// getDurationEnv returns the value of the environment variable named by key parsed as a duration, e.g. "15s", or
+// fallback if it is unset or cannot be parsed
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Ignoring invalid value [%s] for %s\n", value, key)
+		return fallback
+	}
+	return parsed
+}
+
+// getIntEnv returns the value of the environment variable named by key parsed as an integer, or fallback if it is unset
+// or cannot be parsed
+func getIntEnv(key string, fallback int64) int64 {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Printf("Ignoring invalid value [%s] for %s\n", value, key)
+		return fallback
+	}
+	return parsed
+}
+
+// getLevelEnv returns the value of the environment variable named by key parsed as a log level, e.g. "debug", or
+// fallback if it is unset or cannot be parsed
+func getLevelEnv(key string, fallback slog.Level) slog.Level {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	var parsed slog.Level
+	err := parsed.UnmarshalText([]byte(value))
+	if err != nil {
+		log.Printf("Ignoring invalid value [%s] for %s\n", value, key)
+		return fallback
+	}
+	return parsed
+}
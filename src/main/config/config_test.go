@@ -0,0 +1,205 @@
+package config
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name string, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(contents), 0o600)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := writeConfigFile(t, "config.yaml", `
+store: sqlite
+sqlite_path: file.db
+completion_rule: cascade
+trash_retention: 168h
+log_level: debug
+server:
+  addr: ":8080"
+  read_timeout: 3s
+  max_body_bytes: 2048
+`)
+	tomlFile := writeConfigFile(t, "config.toml", `
+store = "sqlite"
+sqlite_path = "file.db"
+completion_rule = "cascade"
+trash_retention = "168h"
+log_level = "debug"
+
+[server]
+addr = ":8080"
+read_timeout = "3s"
+max_body_bytes = 2048
+`)
+	fromFile := Default()
+	fromFile.Store = StoreSqlite
+	fromFile.SqlitePath = "file.db"
+	fromFile.CompletionRule = CompletionCascade
+	fromFile.TrashRetention = 7 * 24 * time.Hour
+	fromFile.LogLevel = slog.LevelDebug
+	fromFile.Server.Addr = ":8080"
+	fromFile.Server.ReadTimeout = 3 * time.Second
+	fromFile.Server.MaxBodyBytes = 2048
+
+	tests := map[string]struct {
+		args     []string
+		env      map[string]string
+		expected func() Config
+	}{
+		"Defaults": {
+			expected: Default,
+		},
+		"Yaml File": {
+			args:     []string{"-config", yamlFile},
+			expected: func() Config { return fromFile },
+		},
+		"Toml File From Environment": {
+			env:      map[string]string{"TODO_CONFIG": tomlFile},
+			expected: func() Config { return fromFile },
+		},
+		"Environment Overrides File": {
+			args: []string{"-config", yamlFile},
+			env:  map[string]string{"TODO_ADDR": ":9090", "TODO_READ_TIMEOUT": "4s", "TODO_COMPLETION_RULE": "require"},
+			expected: func() Config {
+				cfg := fromFile
+				cfg.CompletionRule = CompletionRequire
+				cfg.Server.Addr = ":9090"
+				cfg.Server.ReadTimeout = 4 * time.Second
+				return cfg
+			},
+		},
+		"Flags Override Environment": {
+			args: []string{"-config", yamlFile, "-addr", ":7070", "-allow-client-ids", "-log-level", "warn", "-trash-retention", "0"},
+			env:  map[string]string{"TODO_ADDR": ":9090", "TODO_READ_TIMEOUT": "4s", "TODO_TRASH_RETENTION": "24h"},
+			expected: func() Config {
+				cfg := fromFile
+				cfg.TrashRetention = 0
+				cfg.Server.Addr = ":7070"
+				cfg.Server.ReadTimeout = 4 * time.Second
+				cfg.AllowClientIds = true
+				cfg.LogLevel = slog.LevelWarn
+				return cfg
+			},
+		},
+		"File Store": {
+			args: []string{"-store", "file", "-file-path", "flag.jsonl"},
+			env:  map[string]string{"TODO_FILE_PATH": "env.jsonl", "TODO_FILE_COMPACT_AFTER": "50"},
+			expected: func() Config {
+				cfg := Default()
+				cfg.Store = StoreFile
+				cfg.FilePath = "flag.jsonl"
+				cfg.FileCompactAfter = 50
+				return cfg
+			},
+		},
+		"Events Store": {
+			args: []string{"-store", "events"},
+			env:  map[string]string{"TODO_EVENTS_PATH": "env-events.jsonl"},
+			expected: func() Config {
+				cfg := Default()
+				cfg.Store = StoreEvents
+				cfg.EventsPath = "env-events.jsonl"
+				return cfg
+			},
+		},
+		"Feed": {
+			args: []string{"-feed-heartbeat-interval", "5s"},
+			env:  map[string]string{"TODO_FEED_BUFFER_SIZE": "10", "TODO_FEED_HEARTBEAT_INTERVAL": "1m"},
+			expected: func() Config {
+				cfg := Default()
+				cfg.FeedBufferSize = 10
+				cfg.FeedHeartbeatInterval = 5 * time.Second
+				return cfg
+			},
+		},
+		"Socket": {
+			args: []string{"-socket-send-buffer", "8"},
+			env:  map[string]string{"TODO_SOCKET_PING_INTERVAL": "10s", "TODO_SOCKET_SEND_BUFFER": "16"},
+			expected: func() Config {
+				cfg := Default()
+				cfg.SocketPingInterval = 10 * time.Second
+				cfg.SocketSendBuffer = 8
+				return cfg
+			},
+		},
+		"Webhook": {
+			args: []string{"-webhook-path", "", "-webhook-max-attempts", "3"},
+			env: map[string]string{"TODO_WEBHOOK_PATH": "env.json", "TODO_WEBHOOK_TIMEOUT": "2s",
+				"TODO_WEBHOOK_BACKOFF": "1m"},
+			expected: func() Config {
+				cfg := Default()
+				cfg.WebhookPath = ""
+				cfg.WebhookTimeout = 2 * time.Second
+				cfg.WebhookMaxAttempts = 3
+				cfg.WebhookBackoff = time.Minute
+				return cfg
+			},
+		},
+		"Invalid Environment Value Ignored": {
+			env:      map[string]string{"TODO_WRITE_TIMEOUT": "soon"},
+			expected: Default,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			actual, err := Load(tt.args)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff(tt.expected(), actual)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+	}{
+		"Unknown Flag":              {args: []string{"-port", "80"}},
+		"Missing Config File":       {args: []string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}},
+		"Unsupported Extension":     {args: []string{"-config", writeConfigFile(t, "config.json", `{}`)}},
+		"Malformed Config File":     {args: []string{"-config", writeConfigFile(t, "config.yaml", `server: [`)}},
+		"Unknown Log Level":         {args: []string{"-log-level", "verbose"}},
+		"Unknown Store":             {args: []string{"-store", "postgres"}},
+		"Unknown Completion Rule":   {args: []string{"-completion-rule", "sometimes"}},
+		"Negative Timeout":          {args: []string{"-idle-timeout", "-1s"}},
+		"Negative Trash Retention":  {args: []string{"-trash-retention", "-1h"}},
+		"Zero Trash Purge Interval": {args: []string{"-trash-purge-interval", "0s"}},
+		"Non Positive Body Size":    {args: []string{"-max-body-bytes", "0"}},
+		"Non Positive Compaction":   {args: []string{"-file-compact-after", "0"}},
+		"Non Positive Feed Buffer":  {args: []string{"-feed-buffer-size", "0"}},
+		"Zero Feed Heartbeat":       {args: []string{"-feed-heartbeat-interval", "0s"}},
+		"Zero Socket Ping":          {args: []string{"-socket-ping-interval", "0s"}},
+		"Non Positive Send Buffer":  {args: []string{"-socket-send-buffer", "-1"}},
+		"Zero Webhook Timeout":      {args: []string{"-webhook-timeout", "0s"}},
+		"Non Positive Attempts":     {args: []string{"-webhook-max-attempts", "0"}},
+		"Zero Webhook Backoff":      {args: []string{"-webhook-backoff", "0s"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(tt.args)
+			if err == nil {
+				t.Fatal("Error expected but none occured")
+			}
+		})
+	}
+}
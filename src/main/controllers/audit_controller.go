@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/utils"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// An AuditController represents a REST controller for handling HTTP requests to the API under the "audit/" URI, used to
+// review the changes made to every todo item
+type AuditController struct {
+	responder
+	todoService services.TodoService
+}
+
+// NewAuditController creates a new AuditController object. This is used by Wire when starting the API to perform the
+// necessary dependency injection
+func NewAuditController(todoService services.TodoService, logger *slog.Logger) AuditController {
+	return AuditController{responder{logger}, todoService}
+}
+
+// An AuditPageResponse represents the body of a response containing a single page of revisions, in the order they were
+// recorded, alongside how to fetch the next page
+type AuditPageResponse struct {
+	Revisions  []models.Revision `json:"Revisions"`
+	Pagination Pagination        `json:"Pagination"`
+}
+
+// QueryAudit returns a page of the revisions of every todo item, in the order they were recorded. The revisions can be
+// narrowed to a time range with the "since" and "until" query parameters, to a single actor with "actor" and to a
+// single kind of change with "action". Further pages are fetched by passing the Next value of the previous page as
+// "cursor", and links to the first and next pages are returned within the Link header
+func (controller *AuditController) QueryAudit(writer http.ResponseWriter, request *http.Request) {
+	query, problems := parseAuditQuery(request.URL.Query())
+	if len(problems) > 0 {
+		controller.returnProblem(writer, request, http.StatusBadRequest, "Invalid query parameters", problems...)
+		return
+	}
+	page, err := controller.todoService.QueryAudit(request.Context(), query)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	links := []string{pageLink(request, "", "first")}
+	if page.Next != "" {
+		links = append(links, pageLink(request, page.Next, "next"))
+	}
+	writer.Header().Set("Link", strings.Join(links, ", "))
+	controller.returnJson(writer, request, http.StatusOK, AuditPageResponse{
+		Revisions:  page.Revisions,
+		Pagination: Pagination{Limit: page.Limit, Count: len(page.Revisions), Next: page.Next},
+	})
+}
+
+// parseAuditQuery builds an AuditQuery from the query parameters of a request, returning a problem for each parameter
+// which could not be parsed
+func parseAuditQuery(values url.Values) (services.AuditQuery, []utils.ProblemError) {
+	var problems []utils.ProblemError
+	query := services.AuditQuery{
+		Actor:  values.Get("actor"),
+		Action: models.RevisionAction(values.Get("action")),
+		Cursor: values.Get("cursor"),
+	}
+	query.Since, problems = parseTimestamp(values, "since", problems)
+	query.Until, problems = parseTimestamp(values, "until", problems)
+	if limit := values.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 {
+			problems = append(problems, utils.ProblemError{Field: "limit", Message: "must be a positive whole number"})
+		} else {
+			query.Limit = parsed
+		}
+	}
+	return query, problems
+}
+
+// RegisterRoutes registers the routes under the "audit/" URI with router, handling requests to them by calling methods
+// within AuditController
+func (controller AuditController) RegisterRoutes(myRouter *mux.Router) {
+	myRouter.HandleFunc("/audit", controller.QueryAudit).Methods("GET")
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/utils"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuditController(t *testing.T) {
+	since := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC)
+	revisions := []models.Revision{
+		{Id: 7, TodoId: "1", Version: 2, Action: models.ActionDelete, Actor: "alice", At: since.Add(time.Hour),
+			Todo: models.Todo{Id: "1", Title: "Bake cake", Version: 2}},
+	}
+	tests := map[string]struct {
+		target           string
+		expectedCode     int
+		expectedLink     string
+		expectedResponse interface{}
+		mockSetup        func(mockedComponent *MockTodoServiceImpl)
+	}{
+		"Query Audit": {
+			target:       "/audit?since=2024-03-01T00:00:00Z&until=2024-03-02T00:00:00Z&actor=alice&action=delete&limit=1",
+			expectedCode: http.StatusOK,
+			expectedLink: `</audit?action=delete&actor=alice&limit=1&since=2024-03-01T00%3A00%3A00Z&until=2024-03-02T00%3A00%3A00Z>; rel="first", ` +
+				`</audit?action=delete&actor=alice&cursor=7&limit=1&since=2024-03-01T00%3A00%3A00Z&until=2024-03-02T00%3A00%3A00Z>; rel="next"`,
+			expectedResponse: AuditPageResponse{Revisions: revisions, Pagination: Pagination{Limit: 1, Count: 1, Next: "7"}},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("QueryAudit", services.AuditQuery{Since: &since, Until: &until, Actor: "alice",
+					Action: models.ActionDelete, Limit: 1}).Return(services.AuditPage{Revisions: revisions, Limit: 1, Next: "7"}, nil)
+			},
+		},
+		"Query Audit Invalid Parameters": {
+			target:       "/audit?since=yesterday&limit=0",
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: problem(http.StatusBadRequest, "/audit", "Invalid query parameters",
+				utils.ProblemError{Field: "since", Message: "must be an RFC 3339 timestamp"},
+				utils.ProblemError{Field: "limit", Message: "must be a positive whole number"}),
+		},
+		"Query Audit Fails Validation": {
+			target:       "/audit?action=archive",
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedResponse: problem(http.StatusUnprocessableEntity, "/audit",
+				"Query action must be one of create, update, delete, restore or purge",
+				utils.ProblemError{Field: "action", Message: "must be one of create, update, delete, restore or purge"}),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("QueryAudit", services.AuditQuery{Action: "archive"}).Return(services.AuditPage{},
+					&services.ValidationError{Resource: "query", Fields: []services.FieldError{
+						{Field: "action", Message: "must be one of create, update, delete, restore or purge"}}})
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockTodoService := new(MockTodoServiceImpl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockTodoService)
+			}
+			router := mux.NewRouter()
+			NewAuditController(mockTodoService, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+
+			httpWriter := httptest.NewRecorder()
+			router.ServeHTTP(httpWriter, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if httpWriter.Code != tt.expectedCode {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
+			}
+			if link := httpWriter.Header().Get("Link"); link != tt.expectedLink {
+				t.Errorf("unexpected Link header, expected [%v] but recieved [%v]", tt.expectedLink, link)
+			}
+			expectedResponse, _ := json.Marshal(tt.expectedResponse)
+			require.JSONEq(t, string(expectedResponse), httpWriter.Body.String())
+		})
+	}
+}
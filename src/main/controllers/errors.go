@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A responder sends error responses back to clients, logging those which are unexpected. It is embedded within each
+// controller which calls the services
+type responder struct {
+	logger *slog.Logger
+}
+
+// returnJson responds with body serialized as JSON, logging a failure to serialize it alongside the id of the request as
+// the status has already been sent, see utils.ReturnJsonResponse
+func (controller responder) returnJson(writer http.ResponseWriter, request *http.Request, code int, body any) {
+	err := utils.ReturnJsonResponse(writer, code, body)
+	if err != nil {
+		controller.logger.ErrorContext(request.Context(), "Error serializing the response", "error", err)
+	}
+}
+
+// returnProblem responds with a problem details response, logging a failure to serialize it in the same way as
+// returnJson
+func (controller responder) returnProblem(writer http.ResponseWriter, request *http.Request, code int, detail string,
+	problems ...utils.ProblemError) {
+	err := utils.ReturnProblemResponse(writer, request, code, detail, problems...)
+	if err != nil {
+		controller.logger.ErrorContext(request.Context(), "Error serializing the problem response", "error", err)
+	}
+}
+
+// returnServiceError maps an error returned by one of the services onto the matching HTTP status code and sends it
+// back to the client as a problem details response, see serviceProblem
+func (controller responder) returnServiceError(writer http.ResponseWriter, request *http.Request, err error) {
+	problem := controller.serviceProblem(request, err)
+	controller.returnProblem(writer, request, problem.Status, problem.Detail, problem.Errors...)
+}
+
+// serviceProblem maps an error returned by one of the services onto the problem reported to the client. Errors which
+// are not one of the known service errors are logged and reported as a 500 without exposing their details, known errors
+// are not logged as the request log records their status
+func (controller responder) serviceProblem(request *http.Request, err error) utils.Problem {
+	var validationErr *services.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		problemErrors := make([]utils.ProblemError, 0, len(validationErr.Fields))
+		for _, field := range validationErr.Fields {
+			problemErrors = append(problemErrors, utils.ProblemError{Field: field.Field, Message: field.Message})
+		}
+		return utils.NewProblem(request, http.StatusUnprocessableEntity, capitalise(err.Error()), problemErrors...)
+	case errors.Is(err, services.ErrNotFound):
+		return utils.NewProblem(request, http.StatusNotFound, capitalise(err.Error()))
+	case errors.Is(err, services.ErrConflict):
+		return utils.NewProblem(request, http.StatusConflict, capitalise(err.Error()))
+	case errors.Is(err, services.ErrPreconditionFailed):
+		return utils.NewProblem(request, http.StatusPreconditionFailed, capitalise(err.Error()))
+	case errors.Is(err, services.ErrInvalidPatch):
+		return utils.NewProblem(request, http.StatusBadRequest, capitalise(err.Error()))
+	default:
+		controller.logger.ErrorContext(request.Context(), "Unexpected error", "error", err)
+		return utils.NewProblem(request, http.StatusInternalServerError, "An unexpected error occurred")
+	}
+}
+
+// returnBadRequest reports a request which could not be understood, such as one containing malformed JSON. Requests
+// whose body could not be read as it exceeds the maximum size allowed by the server are reported with a 413 instead
+func (controller responder) returnBadRequest(writer http.ResponseWriter, request *http.Request, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		controller.returnProblem(writer, request, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Request body exceeds the maximum size of %d bytes", maxBytesErr.Limit))
+		return
+	}
+	controller.logger.InfoContext(request.Context(), "Error deserializing the request", "error", err)
+	controller.returnProblem(writer, request, http.StatusBadRequest, "Malformed request body")
+}
+
+// decodeBody reads the JSON body of request into body, responding with a 400 and returning false if it is malformed
+func (controller responder) decodeBody(writer http.ResponseWriter, request *http.Request, body any) bool {
+	reqBody, err := io.ReadAll(request.Body)
+	if err == nil {
+		err = json.Unmarshal(reqBody, body)
+	}
+	if err != nil {
+		controller.returnBadRequest(writer, request, err)
+		return false
+	}
+	return true
+}
+
+// returnPreconditionFailed reports a request whose If-Match or If-None-Match precondition could not be satisfied
+func (controller responder) returnPreconditionFailed(writer http.ResponseWriter, request *http.Request, detail string) {
+	controller.returnProblem(writer, request, http.StatusPreconditionFailed, detail)
+}
+
+// capitalise upper cases the first letter of message, service errors follow the Go convention of starting in lower case
+// but responses sent to clients start with a capital letter
+func capitalise(message string) string {
+	first, size := utf8.DecodeRuneInString(message)
+	if first == utf8.RuneError {
+		return message
+	}
+	var builder strings.Builder
+	builder.WriteRune(unicode.ToUpper(first))
+	builder.WriteString(message[size:])
+	return builder.String()
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"TodoApp/src/main/logging"
+	"bytes"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReturnJsonLogsSerializationError(t *testing.T) {
+	var logs bytes.Buffer
+	controller := responder{logging.New(&logs, 0)}
+	request := httptest.NewRequest(http.MethodGet, "/todo", nil)
+	request = request.WithContext(logging.WithRequestId(request.Context(), "abc"))
+	httpWriter := httptest.NewRecorder()
+
+	controller.returnJson(httpWriter, request, http.StatusOK, math.Inf(1))
+
+	var record map[string]any
+	err := json.Unmarshal(logs.Bytes(), &record)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if record["msg"] != "Error serializing the response" || record["request_id"] != "abc" {
+		t.Errorf("unexpected log record [%v]", record)
+	}
+}
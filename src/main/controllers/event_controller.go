@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/utils"
+	"fmt"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// An EventController represents a REST controller for handling HTTP requests to the API under the "events/" URI, used by
+// consumers to follow every change as a stream of events. Events are only recorded by the "events" store, when another
+// store is in use eventStream is nil and every request is answered with a 501
+type EventController struct {
+	responder
+	eventStream services.EventStream
+}
+
+// NewEventController creates a new EventController object. This is used by Wire when starting the API to perform the
+// necessary dependency injection
+func NewEventController(eventStream services.EventStream, logger *slog.Logger) EventController {
+	return EventController{responder{logger}, eventStream}
+}
+
+// An EventPageResponse represents the body of a response containing a single page of events, in the order they were
+// recorded, alongside how to fetch the next page
+type EventPageResponse struct {
+	Events     []models.Event `json:"Events"`
+	Pagination Pagination     `json:"Pagination"`
+}
+
+// A RebuildResponse represents the body of a response to rebuilding the projection, reporting how many events were
+// replayed
+type RebuildResponse struct {
+	Events int `json:"Events"`
+}
+
+// ReturnEvents returns a page of the events within the stream, in the order they were recorded. Consumers pass the Seq
+// of the last event they have seen as the "after" query parameter to fetch only the events which followed it, and the
+// page size with "limit". A link to the next page is returned within the Link header while further events remain
+func (controller *EventController) ReturnEvents(writer http.ResponseWriter, request *http.Request) {
+	if !controller.recordsEvents(writer, request) {
+		return
+	}
+	query, problems := parseEventQuery(request.URL.Query())
+	if len(problems) > 0 {
+		controller.returnProblem(writer, request, http.StatusBadRequest, "Invalid query parameters", problems...)
+		return
+	}
+	page, err := controller.eventStream.ReturnEvents(request.Context(), query)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	if page.Next != "" {
+		values := request.URL.Query()
+		values.Set("after", page.Next)
+		target := url.URL{Path: request.URL.Path, RawQuery: values.Encode()}
+		writer.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", target.String()))
+	}
+	controller.returnJson(writer, request, http.StatusOK, EventPageResponse{
+		Events:     page.Events,
+		Pagination: Pagination{Limit: page.Limit, Count: len(page.Events), Next: page.Next},
+	})
+}
+
+// RebuildProjection discards the current state of every todo item and list and derives it again by replaying the
+// stream from its start, returning the number of events replayed
+func (controller *EventController) RebuildProjection(writer http.ResponseWriter, request *http.Request) {
+	if !controller.recordsEvents(writer, request) {
+		return
+	}
+	replayed, err := controller.eventStream.RebuildProjection(request.Context())
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, RebuildResponse{Events: replayed})
+}
+
+// recordsEvents reports whether the store in use records events, sending a 501 back to the client when it does not
+func (controller *EventController) recordsEvents(writer http.ResponseWriter, request *http.Request) bool {
+	if controller.eventStream == nil {
+		controller.returnProblem(writer, request, http.StatusNotImplemented, "Events are only recorded by the events store")
+		return false
+	}
+	return true
+}
+
+// parseEventQuery builds an EventQuery from the query parameters of a request, returning a problem for each parameter
+// which could not be parsed
+func parseEventQuery(values url.Values) (services.EventQuery, []utils.ProblemError) {
+	var problems []utils.ProblemError
+	var query services.EventQuery
+	if after := values.Get("after"); after != "" {
+		parsed, err := strconv.ParseInt(after, 10, 64)
+		if err != nil || parsed < 0 {
+			problems = append(problems, utils.ProblemError{Field: "after", Message: "must be a whole number"})
+		} else {
+			query.After = parsed
+		}
+	}
+	if limit := values.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 {
+			problems = append(problems, utils.ProblemError{Field: "limit", Message: "must be a positive whole number"})
+		} else {
+			query.Limit = parsed
+		}
+	}
+	return query, problems
+}
+
+// RegisterRoutes registers the routes under the "events/" URI with router, handling requests to them by calling
+// methods within EventController
+func (controller EventController) RegisterRoutes(myRouter *mux.Router) {
+	myRouter.HandleFunc("/events", controller.ReturnEvents).Methods("GET")
+	myRouter.HandleFunc("/events/rebuild", controller.RebuildProjection).Methods("POST")
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type MockEventStream struct {
+	mock.Mock
+}
+
+func (stream *MockEventStream) ReturnEvents(_ context.Context, query services.EventQuery) (services.EventPage, error) {
+	args := stream.Called(query)
+	return args.Get(0).(services.EventPage), args.Error(1)
+}
+
+func (stream *MockEventStream) RebuildProjection(_ context.Context) (int, error) {
+	args := stream.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func TestEventController(t *testing.T) {
+	at := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	events := []models.Event{
+		{Seq: 4, Type: models.EventTodoRenamed, TodoId: "1", Revision: 3, Version: 2, Actor: "alice", At: at,
+			Changes: []models.FieldChange{{Field: "Title", Old: json.RawMessage(`"Bake cake"`), New: json.RawMessage(`"Bake bread"`)}}},
+	}
+	tests := map[string]struct {
+		method           string
+		target           string
+		withoutStream    bool
+		expectedCode     int
+		expectedLink     string
+		expectedResponse interface{}
+		mockSetup        func(mockedComponent *MockEventStream)
+	}{
+		"Return Events": {
+			method:           http.MethodGet,
+			target:           "/events?after=3&limit=1",
+			expectedCode:     http.StatusOK,
+			expectedLink:     `</events?after=4&limit=1>; rel="next"`,
+			expectedResponse: EventPageResponse{Events: events, Pagination: Pagination{Limit: 1, Count: 1, Next: "4"}},
+			mockSetup: func(mockedComponent *MockEventStream) {
+				mockedComponent.On("ReturnEvents", services.EventQuery{After: 3, Limit: 1}).Return(
+					services.EventPage{Events: events, Limit: 1, Next: "4"}, nil)
+			},
+		},
+		"Return Last Page Of Events": {
+			method:           http.MethodGet,
+			target:           "/events?after=4",
+			expectedCode:     http.StatusOK,
+			expectedResponse: EventPageResponse{Events: []models.Event{}, Pagination: Pagination{Limit: 50, Count: 0}},
+			mockSetup: func(mockedComponent *MockEventStream) {
+				mockedComponent.On("ReturnEvents", services.EventQuery{After: 4}).Return(
+					services.EventPage{Events: []models.Event{}, Limit: 50}, nil)
+			},
+		},
+		"Return Events Invalid Parameters": {
+			method:       http.MethodGet,
+			target:       "/events?after=-1&limit=none",
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: problem(http.StatusBadRequest, "/events", "Invalid query parameters",
+				utils.ProblemError{Field: "after", Message: "must be a whole number"},
+				utils.ProblemError{Field: "limit", Message: "must be a positive whole number"}),
+		},
+		"Return Events Without Event Store": {
+			method:        http.MethodGet,
+			target:        "/events",
+			withoutStream: true,
+			expectedCode:  http.StatusNotImplemented,
+			expectedResponse: problem(http.StatusNotImplemented, "/events",
+				"Events are only recorded by the events store"),
+		},
+		"Rebuild Projection": {
+			method:           http.MethodPost,
+			target:           "/events/rebuild",
+			expectedCode:     http.StatusOK,
+			expectedResponse: RebuildResponse{Events: 12},
+			mockSetup: func(mockedComponent *MockEventStream) {
+				mockedComponent.On("RebuildProjection").Return(12, nil)
+			},
+		},
+		"Rebuild Projection Fails": {
+			method:           http.MethodPost,
+			target:           "/events/rebuild",
+			expectedCode:     http.StatusInternalServerError,
+			expectedResponse: problem(http.StatusInternalServerError, "/events/rebuild", "An unexpected error occurred"),
+			mockSetup: func(mockedComponent *MockEventStream) {
+				mockedComponent.On("RebuildProjection").Return(0, errors.New("failed to rebuild projection"))
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockEventStream := new(MockEventStream)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockEventStream)
+			}
+			var eventStream services.EventStream = mockEventStream
+			if tt.withoutStream {
+				eventStream = nil
+			}
+			router := mux.NewRouter()
+			NewEventController(eventStream, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+
+			httpWriter := httptest.NewRecorder()
+			router.ServeHTTP(httpWriter, httptest.NewRequest(tt.method, tt.target, nil))
+			if httpWriter.Code != tt.expectedCode {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
+			}
+			if link := httpWriter.Header().Get("Link"); link != tt.expectedLink {
+				t.Errorf("unexpected Link header, expected [%v] but recieved [%v]", tt.expectedLink, link)
+			}
+			expectedResponse, _ := json.Marshal(tt.expectedResponse)
+			require.JSONEq(t, string(expectedResponse), httpWriter.Body.String())
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"TodoApp/src/main/feed"
+	"TodoApp/src/main/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// streamWriteTimeout bounds how long writing a single message to a stream may take, so that a client which has stopped
+// reading is disconnected rather than holding its stream open
+const streamWriteTimeout = 10 * time.Second
+
+// A FeedController represents a REST controller streaming the changes made to todo items as Server-Sent Events, used
+// by clients to follow changes instead of polling "todo/"
+type FeedController struct {
+	responder
+	feed      *feed.Feed
+	heartbeat time.Duration
+}
+
+// NewFeedController creates a new FeedController object, sending a heartbeat comment down each stream after every
+// heartbeat interval. This is used by Wire when starting the API to perform the necessary dependency injection
+func NewFeedController(changeFeed *feed.Feed, heartbeat time.Duration, logger *slog.Logger) FeedController {
+	return FeedController{responder{logger}, changeFeed, heartbeat}
+}
+
+// StreamTodos streams a notification for every change made to a todo item as a Server-Sent Event, whose event type is
+// "create", "update" or "delete" and whose data is the notification as JSON. The stream can be narrowed to a single
+// list with the "list" query parameter, a single tag with "tag" and to completed or open todo items with "completed",
+// a change being sent when the todo item matched before or after it. A client which reconnects with the id of the last
+// event it received in the Last-Event-ID header is first sent the events it missed, while they are still buffered.
+// When they are not an event of type "reset" is sent first, telling the client to fetch the todo items again. A comment
+// is sent whenever the stream has been idle for the heartbeat interval, and a client which falls too far behind is
+// disconnected so that it can resume from where it was
+func (controller *FeedController) StreamTodos(writer http.ResponseWriter, request *http.Request) {
+	values := request.URL.Query()
+	filter := feed.Filter{ListId: values.Get("list"), Tag: values.Get("tag")}
+	var problems []utils.ProblemError
+	filter.Completed, problems = parseBool(values, "completed", problems)
+	if len(problems) > 0 {
+		controller.returnProblem(writer, request, http.StatusBadRequest, "Invalid query parameters", problems...)
+		return
+	}
+	var lastId *int64
+	if header := request.Header.Get("Last-Event-ID"); header != "" {
+		parsed, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || parsed < 0 {
+			controller.returnProblem(writer, request, http.StatusBadRequest, "Invalid Last-Event-ID header")
+			return
+		}
+		lastId = &parsed
+	}
+
+	subscription, backlog, reset := controller.feed.Subscribe(lastId, filter)
+	defer controller.feed.Unsubscribe(subscription)
+	stream := http.NewResponseController(writer)
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.WriteHeader(http.StatusOK)
+	if reset && writeEvent(stream, writer, "event: reset\ndata: {}\n\n") != nil {
+		return
+	}
+	for _, notification := range backlog {
+		if writeNotification(stream, writer, notification) != nil {
+			return
+		}
+	}
+	if writeEvent(stream, writer, "") != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(controller.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case notification, ok := <-subscription.Notifications():
+			if !ok || writeNotification(stream, writer, notification) != nil {
+				return
+			}
+			heartbeat.Reset(controller.heartbeat)
+		case <-heartbeat.C:
+			if writeEvent(stream, writer, ": heartbeat\n\n") != nil {
+				return
+			}
+		case <-request.Context().Done():
+			return
+		}
+	}
+}
+
+// writeNotification sends notification down stream as a Server-Sent Event
+func writeNotification(stream *http.ResponseController, writer http.ResponseWriter, notification feed.Notification) error {
+	// Marshalling a notification cannot fail
+	data, _ := json.Marshal(notification)
+	return writeEvent(stream, writer, fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", notification.Id, notification.Type, data))
+}
+
+// writeEvent sends message down stream and flushes it to the client, allowing streamWriteTimeout for it to be written.
+// This replaces the write timeout of the server, which would otherwise end every stream once it had passed
+func writeEvent(stream *http.ResponseController, writer http.ResponseWriter, message string) error {
+	err := stream.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	_, err = writer.Write([]byte(message))
+	if err != nil {
+		return err
+	}
+	return stream.Flush()
+}
+
+// RegisterRoutes registers the "todo/stream" route with router. It must be registered before the routes of
+// TodoController, as otherwise "stream" would be taken as the id of a todo item
+func (controller FeedController) RegisterRoutes(myRouter *mux.Router) {
+	myRouter.HandleFunc("/todo/stream", controller.StreamTodos).Methods("GET")
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"TodoApp/src/main/feed"
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/utils"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupFeedControllerTest(t *testing.T) (*httptest.Server, services.Store) {
+	memory := services.NewTodoServiceImpl([]models.Todo{}, services.Options{AllowClientIds: true})
+	changeFeed, err := feed.New(context.Background(), memory, 10, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	memory.Observe(changeFeed.Sync)
+	router := mux.NewRouter()
+	NewFeedController(changeFeed, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, memory
+}
+
+// readEvent reads the next event from stream, returned as its id, type and todo item id, or only its type when it has
+// no data
+func readEvent(t *testing.T, stream *bufio.Reader) string {
+	fields := map[string]string{}
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Error occured when none expected: [%v]", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			break
+		}
+		name, value, _ := strings.Cut(line, ": ")
+		fields[name] = value
+	}
+	var notification feed.Notification
+	_ = json.Unmarshal([]byte(fields["data"]), &notification)
+	if notification.Id == 0 {
+		return fields["event"]
+	}
+	return fmt.Sprintf("%s %s %s", fields["id"], fields["event"], notification.Todo.Id)
+}
+
+func TestFeedControllerStream(t *testing.T) {
+	tests := map[string]struct {
+		target         string
+		lastEventId    string
+		expectedEvents []string
+	}{
+		"New Stream": {
+			target:         "/todo/stream",
+			expectedEvents: []string{"4 create 2"},
+		},
+		"Resume From Last Event": {
+			target:         "/todo/stream",
+			lastEventId:    "2",
+			expectedEvents: []string{"3 update 1", "4 create 2"},
+		},
+		"Resume From Unknown Event": {
+			target:         "/todo/stream",
+			lastEventId:    "99",
+			expectedEvents: []string{"reset", "4 create 2"},
+		},
+		"Resume Filtered Stream": {
+			target:         "/todo/stream?completed=true",
+			lastEventId:    "0",
+			expectedEvents: []string{"3 update 1", "4 create 2"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			server, store := setupFeedControllerTest(t)
+			_, err := store.CreateNewTodo(ctx, models.Todo{Id: "1", Title: "Bake cake"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = store.UpdateTodo(ctx, models.Todo{Id: "1", Title: "Bake bread"}, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = store.UpdateTodo(ctx, models.Todo{Id: "1", Title: "Bake bread", Completed: true}, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+
+			request, _ := http.NewRequest(http.MethodGet, server.URL+tt.target, nil)
+			if tt.lastEventId != "" {
+				request.Header.Set("Last-Event-ID", tt.lastEventId)
+			}
+			response, err := server.Client().Do(request)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			defer response.Body.Close()
+			if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+				t.Errorf("unexpected Content-Type header, expected [text/event-stream] but recieved [%v]", contentType)
+			}
+			// The stream has subscribed once its headers are received, so this change is sent down it
+			_, err = store.CreateNewTodo(ctx, models.Todo{Id: "2", Title: "Buy flour", Completed: true})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+
+			stream := bufio.NewReader(response.Body)
+			var events []string
+			for range tt.expectedEvents {
+				events = append(events, readEvent(t, stream))
+			}
+			diff := cmp.Diff(tt.expectedEvents, events)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestFeedControllerInvalidRequests(t *testing.T) {
+	tests := map[string]struct {
+		target           string
+		lastEventId      string
+		expectedResponse interface{}
+	}{
+		"Invalid Filter": {
+			target: "/todo/stream?completed=sometimes",
+			expectedResponse: problem(http.StatusBadRequest, "/todo/stream", "Invalid query parameters",
+				utils.ProblemError{Field: "completed", Message: "must be either true or false"}),
+		},
+		"Invalid Last Event Id": {
+			target:           "/todo/stream",
+			lastEventId:      "latest",
+			expectedResponse: problem(http.StatusBadRequest, "/todo/stream", "Invalid Last-Event-ID header"),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			changeFeed, err := feed.New(context.Background(), services.NewTodoServiceImpl([]models.Todo{}, services.Options{}),
+				10, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			router := mux.NewRouter()
+			NewFeedController(changeFeed, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+
+			request := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			request.Header.Set("Last-Event-ID", tt.lastEventId)
+			httpWriter := httptest.NewRecorder()
+			router.ServeHTTP(httpWriter, request)
+			if httpWriter.Code != http.StatusBadRequest {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", http.StatusBadRequest, httpWriter.Code)
+			}
+			expectedResponse, _ := json.Marshal(tt.expectedResponse)
+			require.JSONEq(t, string(expectedResponse), httpWriter.Body.String())
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"TodoApp/src/main/services"
+	"context"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// readinessTimeout bounds how long the backend checks made by a readiness probe may take
+const readinessTimeout = 5 * time.Second
+
+// A HealthController represents a REST controller for handling the liveness and readiness probes made by the
+// orchestrator running the API
+type HealthController struct {
+	responder
+	todoService services.TodoService
+}
+
+// NewHealthController creates a new HealthController object. This is used by Wire when starting the API to perform the
+// necessary dependency injection
+func NewHealthController(todoService services.TodoService, logger *slog.Logger) HealthController {
+	return HealthController{responder{logger}, todoService}
+}
+
+// A HealthResponse represents the body of a response to a health probe. Status is services.HealthUp only when every
+// component is healthy, Components is omitted from liveness probes as they do not check any components
+type HealthResponse struct {
+	Status     string                     `json:"Status"`
+	Components []services.ComponentHealth `json:"Components,omitempty"`
+}
+
+// Liveness reports that the API is running and able to handle requests. It does not check any of the components the
+// API depends upon, so that a failing dependency does not cause the API to be restarted
+func (controller *HealthController) Liveness(writer http.ResponseWriter, request *http.Request) {
+	controller.returnJson(writer, request, http.StatusOK, HealthResponse{Status: services.HealthUp})
+}
+
+// Readiness reports whether the API is ready to handle requests, asking the TodoService backend to check each of the
+// components it depends upon. A 503 is returned when any component is unhealthy
+func (controller *HealthController) Readiness(writer http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithTimeout(request.Context(), readinessTimeout)
+	defer cancel()
+	response := HealthResponse{Status: services.HealthUp, Components: controller.todoService.CheckHealth(ctx)}
+	code := http.StatusOK
+	for _, component := range response.Components {
+		if component.Status != services.HealthUp {
+			response.Status = services.HealthDown
+			code = http.StatusServiceUnavailable
+		}
+	}
+	controller.returnJson(writer, request, code, response)
+}
+
+// RegisterRoutes registers the "/healthz" liveness and "/readyz" readiness routes with router
+func (controller HealthController) RegisterRoutes(myRouter *mux.Router) {
+	myRouter.HandleFunc("/healthz", controller.Liveness).Methods("GET")
+	myRouter.HandleFunc("/readyz", controller.Readiness).Methods("GET")
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"TodoApp/src/main/services"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLiveness(t *testing.T) {
+	healthController := NewHealthController(new(MockTodoServiceImpl), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	httpWriter := httptest.NewRecorder()
+	healthController.Liveness(httpWriter, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if httpWriter.Code != http.StatusOK {
+		t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", http.StatusOK, httpWriter.Code)
+	}
+	require.JSONEq(t, `{"Status": "up"}`, httpWriter.Body.String())
+}
+
+func TestReadiness(t *testing.T) {
+	tests := map[string]struct {
+		components       []services.ComponentHealth
+		expectedCode     int
+		expectedResponse HealthResponse
+	}{
+		"All Components Healthy": {
+			components: []services.ComponentHealth{
+				{Name: "sqlite.connection", Status: services.HealthUp},
+				{Name: "sqlite.migrations", Status: services.HealthUp},
+			},
+			expectedCode: http.StatusOK,
+			expectedResponse: HealthResponse{Status: services.HealthUp, Components: []services.ComponentHealth{
+				{Name: "sqlite.connection", Status: services.HealthUp},
+				{Name: "sqlite.migrations", Status: services.HealthUp},
+			}},
+		},
+		"Component Unhealthy": {
+			components: []services.ComponentHealth{
+				{Name: "sqlite.connection", Status: services.HealthUp},
+				{Name: "sqlite.writable", Status: services.HealthDown, Detail: "attempt to write a readonly database"},
+			},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedResponse: HealthResponse{Status: services.HealthDown, Components: []services.ComponentHealth{
+				{Name: "sqlite.connection", Status: services.HealthUp},
+				{Name: "sqlite.writable", Status: services.HealthDown, Detail: "attempt to write a readonly database"},
+			}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockTodoService := new(MockTodoServiceImpl)
+			mockTodoService.On("CheckHealth").Return(tt.components)
+			healthController := NewHealthController(mockTodoService, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			httpWriter := httptest.NewRecorder()
+			healthController.Readiness(httpWriter, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if httpWriter.Code != tt.expectedCode {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
+			}
+			expectedResponse, _ := json.Marshal(tt.expectedResponse)
+			require.JSONEq(t, string(expectedResponse), httpWriter.Body.String())
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/utils"
+	"errors"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+// A ListController represents a REST controller for handling HTTP requests to the API under the "lists/" URI, including
+// the todo items nested beneath each list
+type ListController struct {
+	responder
+	listService services.ListService
+	todoService services.TodoService
+}
+
+// NewListController creates a new ListController object. This is used by Wire when starting the API to perform the
+// necessary dependency injection
+func NewListController(listService services.ListService, todoService services.TodoService, logger *slog.Logger) ListController {
+	return ListController{responder{logger}, listService, todoService}
+}
+
+// A ListCollectionResponse represents the body of a response containing every list
+type ListCollectionResponse struct {
+	Lists []models.List `json:"Lists"`
+}
+
+// ReturnAllLists returns every list persisted within the DB, in the order they were created
+func (controller *ListController) ReturnAllLists(writer http.ResponseWriter, request *http.Request) {
+	lists, err := controller.listService.ReturnAllLists(request.Context())
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, ListCollectionResponse{Lists: lists})
+}
+
+// ReturnSingleList returns the list with an id matching the listId path parameter. The version of the list is returned
+// in the ETag header, and if it matches the If-None-Match header a 304 is returned without a body
+func (controller *ListController) ReturnSingleList(writer http.ResponseWriter, request *http.Request) {
+	todoList, err := controller.listService.ReturnSingleList(request.Context(), mux.Vars(request)["listId"])
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+	} else if noneMatch(request, todoList.Version) {
+		writer.Header().Set("ETag", etag(todoList.Version))
+		writer.WriteHeader(http.StatusNotModified)
+	} else {
+		controller.returnList(writer, request, http.StatusOK, todoList)
+	}
+}
+
+// CreateNewList creates a new list, generating its id if none was supplied. The location of the new list is returned in
+// the Location header
+func (controller *ListController) CreateNewList(writer http.ResponseWriter, request *http.Request) {
+	var todoList models.List
+	if !controller.decodeBody(writer, request, &todoList) {
+		return
+	}
+	response, err := controller.listService.CreateNewList(request.Context(), todoList)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	writer.Header().Set("Location", "/lists/"+url.PathEscape(response.Id))
+	controller.returnList(writer, request, http.StatusCreated, response)
+}
+
+// UpdateList replaces the list with an id matching the listId path parameter with the list passed in the request. When
+// an If-Match header is sent the list is only changed if it is still at that version, otherwise a 412 is returned
+func (controller *ListController) UpdateList(writer http.ResponseWriter, request *http.Request) {
+	var todoList models.List
+	if !controller.decodeBody(writer, request, &todoList) {
+		return
+	}
+	conditions, ok := parsePreconditions(request)
+	if !ok {
+		controller.returnPreconditionFailed(writer, request, "If-Match must be either * or a single strong ETag")
+		return
+	}
+	todoList.Id = mux.Vars(request)["listId"]
+	response, err := controller.listService.UpdateList(request.Context(), todoList, conditions.version)
+	if conditions.ifMatch && errors.Is(err, services.ErrNotFound) {
+		controller.returnPreconditionFailed(writer, request, capitalise(err.Error()))
+		return
+	}
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnList(writer, request, http.StatusOK, response)
+}
+
+// DeleteList removes the list with an id matching the listId path parameter. The "mode" query parameter decides what
+// happens to the todo items within the list, either "deny" (the default) which returns a 409 if the list is not empty,
+// or "cascade" which deletes them alongside the list. When an If-Match header is sent the list is only removed if it is
+// still at that version, otherwise a 412 is returned
+func (controller *ListController) DeleteList(writer http.ResponseWriter, request *http.Request) {
+	mode := services.ListDeleteMode(request.URL.Query().Get("mode"))
+	switch mode {
+	case "":
+		mode = services.ListDeleteDeny
+	case services.ListDeleteDeny, services.ListDeleteCascade:
+	default:
+		controller.returnProblem(writer, request, http.StatusBadRequest, "Invalid query parameters",
+			utils.ProblemError{Field: "mode", Message: "must be either deny or cascade"})
+		return
+	}
+	conditions, ok := parsePreconditions(request)
+	if !ok {
+		controller.returnPreconditionFailed(writer, request, "If-Match must be either * or a single strong ETag")
+		return
+	}
+	err := controller.listService.DeleteList(request.Context(), mux.Vars(request)["listId"], mode, conditions.version)
+	if conditions.ifMatch && errors.Is(err, services.ErrNotFound) {
+		controller.returnPreconditionFailed(writer, request, capitalise(err.Error()))
+		return
+	}
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, "List Deleted Successfully")
+}
+
+// QueryListTodos returns a page of the todo items within the list with an id matching the listId path parameter,
+// accepting the same query parameters as TodoController.QueryTodos. A 404 is returned if the list does not exist
+func (controller *ListController) QueryListTodos(writer http.ResponseWriter, request *http.Request) {
+	listId := mux.Vars(request)["listId"]
+	query, problems := parseTodoQuery(request.URL.Query())
+	if len(problems) > 0 {
+		controller.returnProblem(writer, request, http.StatusBadRequest, "Invalid query parameters", problems...)
+		return
+	}
+	query.ListId = &listId
+	_, err := controller.listService.ReturnSingleList(request.Context(), listId)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	page, err := controller.todoService.QueryTodos(request.Context(), query)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnTodoPage(writer, request, page)
+}
+
+// CreateListTodo creates a new todo item within the list with an id matching the listId path parameter, overriding any
+// ListId sent in the request. A 404 is returned if the list does not exist
+func (controller *ListController) CreateListTodo(writer http.ResponseWriter, request *http.Request) {
+	var todo models.Todo
+	if !controller.decodeBody(writer, request, &todo) {
+		return
+	}
+	todo.ListId = mux.Vars(request)["listId"]
+	_, err := controller.listService.ReturnSingleList(request.Context(), todo.ListId)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	response, err := controller.todoService.CreateNewTodo(request.Context(), todo)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnCreated(writer, request, response)
+}
+
+// MoveTodo moves the todo item with an id matching the id path parameter into the list with an id matching the listId
+// path parameter, from whichever list it belonged to before. A 404 is returned if either does not exist. When an
+// If-Match header is sent the todo item is only moved if it is still at that version, otherwise a 412 is returned
+func (controller *ListController) MoveTodo(writer http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	conditions, ok := parsePreconditions(request)
+	if !ok {
+		controller.returnPreconditionFailed(writer, request, "If-Match must be either * or a single strong ETag")
+		return
+	}
+	response, err := controller.listService.MoveTodo(request.Context(), vars["id"], vars["listId"], conditions.version)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnTodo(writer, request, http.StatusOK, response)
+}
+
+// returnList responds with a single list, including its version within the ETag header
+func (controller responder) returnList(writer http.ResponseWriter, request *http.Request, code int, todoList models.List) {
+	writer.Header().Set("ETag", etag(todoList.Version))
+	controller.returnJson(writer, request, code, todoList)
+}
+
+// RegisterRoutes registers the routes under the "lists/" URI with router, handling requests to them by calling methods
+// within ListController
+func (controller ListController) RegisterRoutes(myRouter *mux.Router) {
+	myRouter.HandleFunc("/lists", controller.CreateNewList).Methods("POST")
+	myRouter.HandleFunc("/lists", controller.ReturnAllLists).Methods("GET")
+	myRouter.HandleFunc("/lists/{listId}", controller.UpdateList).Methods("PUT")
+	myRouter.HandleFunc("/lists/{listId}", controller.DeleteList).Methods("DELETE")
+	myRouter.HandleFunc("/lists/{listId}", controller.ReturnSingleList).Methods("GET")
+	myRouter.HandleFunc("/lists/{listId}/todos", controller.CreateListTodo).Methods("POST")
+	myRouter.HandleFunc("/lists/{listId}/todos", controller.QueryListTodos).Methods("GET")
+	myRouter.HandleFunc("/lists/{listId}/todos/{id}", controller.MoveTodo).Methods("PUT")
+}
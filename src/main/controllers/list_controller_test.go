@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/utils"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestListController(t *testing.T) {
+	version := int64(2)
+	listId := "sprint"
+	tests := map[string]struct {
+		method           string
+		target           string
+		body             string
+		headers          map[string]string
+		expectedCode     int
+		expectedHeaders  map[string]string
+		expectedResponse interface{}
+		mockSetup        func(mockedComponent *MockTodoServiceImpl)
+	}{
+		"Return All Lists": {
+			method:           http.MethodGet,
+			target:           "/lists",
+			expectedCode:     http.StatusOK,
+			expectedResponse: ListCollectionResponse{Lists: []models.List{{Id: "sprint", Name: "Sprint 1", Version: 1}}},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnAllLists").Return([]models.List{{Id: "sprint", Name: "Sprint 1", Version: 1}}, nil)
+			},
+		},
+		"Create List": {
+			method:           http.MethodPost,
+			target:           "/lists",
+			body:             `{"Name": "Sprint 1"}`,
+			expectedCode:     http.StatusCreated,
+			expectedHeaders:  map[string]string{"Location": "/lists/sprint", "ETag": `"1"`},
+			expectedResponse: models.List{Id: "sprint", Name: "Sprint 1", Version: 1},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("CreateNewList", models.List{Name: "Sprint 1"}).Return(models.List{Id: "sprint", Name: "Sprint 1", Version: 1}, nil)
+			},
+		},
+		"Return Missing List": {
+			method:           http.MethodGet,
+			target:           "/lists/sprint",
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/lists/sprint", "Could not find list with id [sprint]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnSingleList", "sprint").Return(models.List{}, &services.NotFoundError{Resource: "list", Id: "sprint"})
+			},
+		},
+		"Update List If Match": {
+			method:           http.MethodPut,
+			target:           "/lists/sprint",
+			body:             `{"Id": "ignored", "Name": "Sprint 2"}`,
+			headers:          map[string]string{"If-Match": `"2"`},
+			expectedCode:     http.StatusOK,
+			expectedHeaders:  map[string]string{"ETag": `"3"`},
+			expectedResponse: models.List{Id: "sprint", Name: "Sprint 2", Version: 3},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("UpdateList", models.List{Id: "sprint", Name: "Sprint 2"}, &version).
+					Return(models.List{Id: "sprint", Name: "Sprint 2", Version: 3}, nil)
+			},
+		},
+		"Delete Non Empty List": {
+			method:           http.MethodDelete,
+			target:           "/lists/sprint",
+			expectedCode:     http.StatusConflict,
+			expectedResponse: problem(http.StatusConflict, "/lists/sprint", "List with id [sprint] still contains [3] todo items"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("DeleteList", "sprint", services.ListDeleteDeny, (*int64)(nil)).Return(&services.ListNotEmptyError{Id: "sprint", Todos: 3})
+			},
+		},
+		"Delete List Cascading": {
+			method:           http.MethodDelete,
+			target:           "/lists/sprint?mode=cascade",
+			expectedCode:     http.StatusOK,
+			expectedResponse: "List Deleted Successfully",
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("DeleteList", "sprint", services.ListDeleteCascade, (*int64)(nil)).Return(nil)
+			},
+		},
+		"Delete List With Invalid Mode": {
+			method:       http.MethodDelete,
+			target:       "/lists/sprint?mode=orphan",
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: problem(http.StatusBadRequest, "/lists/sprint", "Invalid query parameters",
+				utils.ProblemError{Field: "mode", Message: "must be either deny or cascade"}),
+		},
+		"Query List Todos": {
+			method:       http.MethodGet,
+			target:       "/lists/sprint/todos?completed=true",
+			expectedCode: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Link": `</lists/sprint/todos?completed=true>; rel="first"`,
+			},
+			expectedResponse: TodoPageResponse{
+				Todos:      []models.Todo{{Id: "1", Title: "Bake cake", Completed: true, ListId: "sprint"}},
+				Pagination: Pagination{Limit: 50, Count: 1},
+			},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				completed := true
+				mockedComponent.On("ReturnSingleList", "sprint").Return(models.List{Id: "sprint"}, nil)
+				mockedComponent.On("QueryTodos", services.TodoQuery{Completed: &completed, ListId: &listId}).Return(services.TodoPage{
+					Todos: []models.Todo{{Id: "1", Title: "Bake cake", Completed: true, ListId: "sprint"}},
+					Limit: 50,
+				}, nil)
+			},
+		},
+		"Create Todo In Missing List": {
+			method:           http.MethodPost,
+			target:           "/lists/sprint/todos",
+			body:             `{"Title": "Bake cake"}`,
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/lists/sprint/todos", "Could not find list with id [sprint]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnSingleList", "sprint").Return(models.List{}, &services.NotFoundError{Resource: "list", Id: "sprint"})
+			},
+		},
+		"Create Todo In List": {
+			method:           http.MethodPost,
+			target:           "/lists/sprint/todos",
+			body:             `{"Title": "Bake cake", "ListId": "other"}`,
+			expectedCode:     http.StatusCreated,
+			expectedHeaders:  map[string]string{"Location": "/todo/1"},
+			expectedResponse: models.Todo{Id: "1", Title: "Bake cake", ListId: "sprint", Version: 1},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnSingleList", "sprint").Return(models.List{Id: "sprint"}, nil)
+				mockedComponent.On("CreateNewTodo", models.Todo{Title: "Bake cake", ListId: "sprint"}).
+					Return(models.Todo{Id: "1", Title: "Bake cake", ListId: "sprint", Version: 1}, nil)
+			},
+		},
+		"Move Todo": {
+			method:           http.MethodPut,
+			target:           "/lists/sprint/todos/1",
+			expectedCode:     http.StatusOK,
+			expectedHeaders:  map[string]string{"ETag": `"2"`},
+			expectedResponse: models.Todo{Id: "1", Title: "Bake cake", ListId: "sprint", Version: 2},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("MoveTodo", "1", "sprint", mock.Anything).
+					Return(models.Todo{Id: "1", Title: "Bake cake", ListId: "sprint", Version: 2}, nil)
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockTodoService := new(MockTodoServiceImpl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockTodoService)
+			}
+			router := mux.NewRouter()
+			NewListController(mockTodoService, mockTodoService, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			for header, value := range tt.headers {
+				req.Header.Set(header, value)
+			}
+			httpWriter := httptest.NewRecorder()
+			router.ServeHTTP(httpWriter, req)
+			if httpWriter.Code != tt.expectedCode {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
+			}
+			for header, value := range tt.expectedHeaders {
+				if httpWriter.Header().Get(header) != value {
+					t.Errorf("unexpected %s header, expected [%v] but recieved [%v]", header, value, httpWriter.Header().Get(header))
+				}
+			}
+			expectedResponse, _ := json.Marshal(tt.expectedResponse)
+			require.JSONEq(t, string(expectedResponse), httpWriter.Body.String())
+		})
+	}
+}
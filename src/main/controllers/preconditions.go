@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// preconditions holds the conditional request headers sent by the client. Composed of the following fields:
+//
+// ifMatch: Whether an If-Match header was sent, in which case the todo item or list must already exist
+//
+// version: The version the todo item or list must be at for the request to succeed, nil when any version is acceptable
+//
+// createOnly: Whether "If-None-Match: *" was sent, in which case the todo item must not already exist
+type preconditions struct {
+	ifMatch    bool
+	version    *int64
+	createOnly bool
+}
+
+// etag formats the version of a todo item or list as a strong entity tag
+func etag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// parsePreconditions reads the If-Match and If-None-Match headers of a request. If-Match may either be "*" or a single
+// strong entity tag previously returned by the API, if it is anything else the precondition can never be satisfied and
+// false is returned
+func parsePreconditions(request *http.Request) (preconditions, bool) {
+	result := preconditions{createOnly: strings.TrimSpace(request.Header.Get("If-None-Match")) == "*"}
+	ifMatch := strings.TrimSpace(request.Header.Get("If-Match"))
+	if ifMatch == "" {
+		return result, true
+	}
+	result.ifMatch = true
+	if ifMatch == "*" {
+		return result, true
+	}
+	if len(ifMatch) < 2 || !strings.HasPrefix(ifMatch, `"`) || !strings.HasSuffix(ifMatch, `"`) {
+		return result, false
+	}
+	version, err := strconv.ParseInt(ifMatch[1:len(ifMatch)-1], 10, 64)
+	if err != nil {
+		return result, false
+	}
+	result.version = &version
+	return result, true
+}
+
+// noneMatch reports whether the If-None-Match header of a request matches the entity tag of the current version of a
+// todo item or list, using the weak comparison required for If-None-Match, meaning the client's cached copy is still
+// current
+func noneMatch(request *http.Request, version int64) bool {
+	header := request.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	current := etag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
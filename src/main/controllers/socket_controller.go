@@ -0,0 +1,304 @@
+package controllers
+
+import (
+	"TodoApp/src/main/feed"
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/utils"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// The types of SocketCommand a client can send
+const (
+	SocketSubscribe   = "subscribe"
+	SocketUnsubscribe = "unsubscribe"
+	SocketCreate      = "create"
+	SocketUpdate      = "update"
+	SocketDelete      = "delete"
+)
+
+// The types of SocketMessage sent to a client
+const (
+	SocketResponse = "response"
+	SocketEvent    = "event"
+)
+
+// A SocketCommand represents a single command sent by a client over a WebSocket. Composed of the following fields:
+//
+// Id: Chosen by the client and returned within the response to the command, correlating the two
+//
+// Type: What the command does, one of "subscribe", "unsubscribe", "create", "update" or "delete"
+//
+// TodoIds: The todo items to subscribe to or unsubscribe from
+//
+// ListIds: The lists whose todo items to subscribe to or unsubscribe from
+//
+// Todo: The todo item to create or update
+//
+// TodoId: The id of the todo item to delete
+//
+// Version: When sent, the version the todo item must be at for an update or delete to succeed, like If-Match
+type SocketCommand struct {
+	Id      string       `json:"Id"`
+	Type    string       `json:"Type"`
+	TodoIds []string     `json:"TodoIds,omitempty"`
+	ListIds []string     `json:"ListIds,omitempty"`
+	Todo    *models.Todo `json:"Todo,omitempty"`
+	TodoId  string       `json:"TodoId,omitempty"`
+	Version *int64       `json:"Version,omitempty"`
+}
+
+// A SocketMessage represents a single message sent to a client over a WebSocket, either the response to a command or an
+// event describing a change made to a todo item the client subscribed to. Composed of the following fields:
+//
+// Type: Either "response" or "event"
+//
+// Id: The Id of the command responded to
+//
+// Status: The HTTP status code matching the outcome of the command
+//
+// Todo: The todo item created or updated by the command
+//
+// Problem: Why the command failed, in the same format as the error responses of the REST routes
+//
+// Event: The change made to a todo item, in the same format as the events of "todo/stream"
+type SocketMessage struct {
+	Type    string             `json:"Type"`
+	Id      string             `json:"Id,omitempty"`
+	Status  int                `json:"Status,omitempty"`
+	Todo    *models.Todo       `json:"Todo,omitempty"`
+	Problem *utils.Problem     `json:"Problem,omitempty"`
+	Event   *feed.Notification `json:"Event,omitempty"`
+}
+
+// A SocketController represents a controller serving the "todo/socket" WebSocket, over which clients subscribe to the
+// todo items and lists they are showing and make changes to todo items. Changes are made through the TodoService, so
+// they are published by the feed and sent to every client subscribed to the todo items they change
+type SocketController struct {
+	responder
+	todoService     services.TodoService
+	feed            *feed.Feed
+	upgrader        websocket.Upgrader
+	pingInterval    time.Duration
+	sendBuffer      int
+	maxMessageBytes int64
+}
+
+// NewSocketController creates a new SocketController object. Each client is pinged after every ping interval, and up to
+// sendBuffer messages can wait to be sent to it. Messages from clients may be no larger than maxMessageBytes. This is
+// used by Wire when starting the API to perform the necessary dependency injection
+func NewSocketController(todoService services.TodoService, changeFeed *feed.Feed, pingInterval time.Duration,
+	sendBuffer int, maxMessageBytes int64, logger *slog.Logger) SocketController {
+	return SocketController{
+		responder:       responder{logger},
+		todoService:     todoService,
+		feed:            changeFeed,
+		pingInterval:    pingInterval,
+		sendBuffer:      sendBuffer,
+		maxMessageBytes: maxMessageBytes,
+	}
+}
+
+// Connect upgrades the request to a WebSocket and serves the client until either side closes it. Commands are executed
+// in the order they are received, each answered by a "response" message carrying the Id of the command. A client which
+// does not answer a ping before the next is due, or which falls so far behind that its send buffer fills, is
+// disconnected. Requests from another origin are refused, as browsers allow any page to open a WebSocket
+func (controller *SocketController) Connect(writer http.ResponseWriter, request *http.Request) {
+	conn, err := controller.upgrader.Upgrade(writer, request, nil)
+	if err != nil {
+		// The upgrader has already responded to the client
+		return
+	}
+	client := &socketClient{
+		conn:    conn,
+		send:    make(chan SocketMessage, controller.sendBuffer),
+		done:    make(chan struct{}),
+		todoIds: map[string]bool{},
+		listIds: map[string]bool{},
+	}
+	subscription, _, _ := controller.feed.Subscribe(nil, feed.Filter{})
+	go client.forward(subscription)
+	go client.write(controller.pingInterval)
+	controller.read(request, client)
+	client.close(websocket.CloseNormalClosure)
+	controller.feed.Unsubscribe(subscription)
+}
+
+// read executes each command sent by client, queuing its response, until the WebSocket is closed or client stops
+// answering pings
+func (controller *SocketController) read(request *http.Request, client *socketClient) {
+	pongWait := 2 * controller.pingInterval
+	client.conn.SetReadLimit(controller.maxMessageBytes)
+	_ = client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	client.conn.SetPongHandler(func(string) error {
+		return client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		_, data, err := client.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if !client.enqueue(controller.execute(request, client, data)) {
+			return
+		}
+	}
+}
+
+// execute executes the command within data on behalf of client, returning the response to send back
+func (controller *SocketController) execute(request *http.Request, client *socketClient, data []byte) SocketMessage {
+	var command SocketCommand
+	err := json.Unmarshal(data, &command)
+	if err != nil {
+		return socketProblem(command.Id, utils.NewProblem(request, http.StatusBadRequest, "Malformed message"))
+	}
+	response := SocketMessage{Type: SocketResponse, Id: command.Id, Status: http.StatusOK}
+	ctx := request.Context()
+	var todo models.Todo
+	switch command.Type {
+	case SocketSubscribe, SocketUnsubscribe:
+		if len(command.TodoIds) == 0 && len(command.ListIds) == 0 {
+			return socketProblem(command.Id, utils.NewProblem(request, http.StatusBadRequest,
+				"At least one of TodoIds or ListIds must be sent"))
+		}
+		client.subscribe(command.Type == SocketSubscribe, command.TodoIds, command.ListIds)
+		return response
+	case SocketCreate, SocketUpdate:
+		if command.Todo == nil {
+			return socketProblem(command.Id, utils.NewProblem(request, http.StatusBadRequest, "Todo must be sent"))
+		}
+		if command.Type == SocketCreate {
+			todo, err = controller.todoService.CreateNewTodo(ctx, *command.Todo)
+			response.Status = http.StatusCreated
+		} else {
+			todo, err = controller.todoService.UpdateTodo(ctx, *command.Todo, command.Version)
+		}
+		response.Todo = &todo
+	case SocketDelete:
+		err = controller.todoService.DeleteTodo(ctx, command.TodoId, command.Version)
+	default:
+		return socketProblem(command.Id, utils.NewProblem(request, http.StatusBadRequest, "Unknown command type"))
+	}
+	if err != nil {
+		return socketProblem(command.Id, controller.serviceProblem(request, err))
+	}
+	return response
+}
+
+// socketProblem returns the response to the command with id id, reporting problem
+func socketProblem(id string, problem utils.Problem) SocketMessage {
+	return SocketMessage{Type: SocketResponse, Id: id, Status: problem.Status, Problem: &problem}
+}
+
+// A socketClient represents a single client connected to the WebSocket, alongside the todo items and lists it has
+// subscribed to
+type socketClient struct {
+	conn *websocket.Conn
+	// send holds the messages waiting to be written to conn, when it is full the client has fallen behind
+	send      chan SocketMessage
+	done      chan struct{}
+	closeOnce sync.Once
+	mu        sync.Mutex
+	todoIds   map[string]bool
+	listIds   map[string]bool
+}
+
+// subscribe subscribes the client to, or unsubscribes it from, the todo items and lists with the ids passed
+func (client *socketClient) subscribe(subscribed bool, todoIds []string, listIds []string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	for _, id := range todoIds {
+		client.todoIds[id] = subscribed
+	}
+	for _, id := range listIds {
+		client.listIds[id] = subscribed
+	}
+}
+
+// subscribed reports whether the client has subscribed to the todo item changed by notification, either directly or
+// through the list it was in before or after the change
+func (client *socketClient) subscribed(notification feed.Notification) bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.todoIds[notification.Todo.Id] {
+		return true
+	}
+	for id, subscribed := range client.listIds {
+		if subscribed && (feed.Filter{ListId: id}).Matches(notification) {
+			return true
+		}
+	}
+	return false
+}
+
+// forward queues an event for every notification the client has subscribed to. Once the feed closes subscription, as
+// the server is shutting down, the client is disconnected
+func (client *socketClient) forward(subscription *feed.Subscription) {
+	for notification := range subscription.Notifications() {
+		if client.subscribed(notification) && !client.enqueue(SocketMessage{Type: SocketEvent, Event: &notification}) {
+			return
+		}
+	}
+	client.close(websocket.CloseGoingAway)
+}
+
+// enqueue queues message to be sent to the client without waiting, disconnecting the client if its send buffer is full.
+// It returns false if the message will not be sent
+func (client *socketClient) enqueue(message SocketMessage) bool {
+	select {
+	case <-client.done:
+		return false
+	default:
+	}
+	select {
+	case client.send <- message:
+		return true
+	default:
+		client.close(websocket.CloseTryAgainLater)
+		return false
+	}
+}
+
+// write sends each queued message to the client, alongside a ping after every ping interval, until the client is closed
+func (client *socketClient) write(pingInterval time.Duration) {
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case message := <-client.send:
+			_ = client.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			err = client.conn.WriteJSON(message)
+		case <-ping.C:
+			err = client.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout))
+		case <-client.done:
+			return
+		}
+		if err != nil {
+			client.close(websocket.CloseGoingAway)
+			return
+		}
+	}
+}
+
+// close sends a close message with code to the client, if it is still reachable, then closes its connection. Only the
+// first call has any effect
+func (client *socketClient) close(code int) {
+	client.closeOnce.Do(func() {
+		_ = client.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, ""),
+			time.Now().Add(streamWriteTimeout))
+		_ = client.conn.Close()
+		close(client.done)
+	})
+}
+
+// RegisterRoutes registers the "todo/socket" route with router. It must be registered before the routes of
+// TodoController, as otherwise "socket" would be taken as the id of a todo item
+func (controller SocketController) RegisterRoutes(myRouter *mux.Router) {
+	myRouter.HandleFunc("/todo/socket", controller.Connect).Methods("GET")
+}
@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"TodoApp/src/main/feed"
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/utils"
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupSocketTest(t *testing.T) (*httptest.Server, *feed.Feed) {
+	ctx := context.Background()
+	memory := services.NewTodoServiceImpl([]models.Todo{}, services.Options{AllowClientIds: true})
+	_, err := memory.CreateNewList(ctx, models.List{Id: "chores", Name: "Chores"})
+	if err != nil {
+		t.Fatalf("Failed to persist prerequisite list: [%v]", err)
+	}
+	_, err = memory.CreateNewTodo(ctx, models.Todo{Id: "1", Title: "Bake cake"})
+	if err != nil {
+		t.Fatalf("Failed to persist prerequisite todo: [%v]", err)
+	}
+	changeFeed, err := feed.New(ctx, memory, 10, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	memory.Observe(changeFeed.Sync)
+	router := mux.NewRouter()
+	NewSocketController(memory, changeFeed, time.Hour, 8, 1024,
+		slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, changeFeed
+}
+
+func dialSocket(t *testing.T, server *httptest.Server) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/todo/socket", nil)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// sendCommand sends command over conn and returns the next message received
+func sendCommand(t *testing.T, conn *websocket.Conn, command string) SocketMessage {
+	err := conn.WriteMessage(websocket.TextMessage, []byte(command))
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	return receiveMessage(t, conn)
+}
+
+func receiveMessage(t *testing.T, conn *websocket.Conn) SocketMessage {
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message SocketMessage
+	err := conn.ReadJSON(&message)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	return message
+}
+
+func TestSocketControllerCommands(t *testing.T) {
+	tests := map[string]struct {
+		command         string
+		expectedStatus  int
+		expectedTitle   string
+		expectedProblem *utils.Problem
+	}{
+		"Create": {
+			command:        `{"Id":"a","Type":"create","Todo":{"Id":"2","Title":"Buy flour"}}`,
+			expectedStatus: http.StatusCreated,
+			expectedTitle:  "Buy flour",
+		},
+		"Update": {
+			command:        `{"Id":"a","Type":"update","Todo":{"Id":"1","Title":"Bake bread"},"Version":1}`,
+			expectedStatus: http.StatusOK,
+			expectedTitle:  "Bake bread",
+		},
+		"Update Stale Version": {
+			command:        `{"Id":"a","Type":"update","Todo":{"Id":"1","Title":"Bake bread"},"Version":5}`,
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedProblem: pointer(problem(http.StatusPreconditionFailed, "/todo/socket",
+				"Todo with id [1] is at version [1] not the expected version [5]")),
+		},
+		"Delete": {
+			command:        `{"Id":"a","Type":"delete","TodoId":"1"}`,
+			expectedStatus: http.StatusOK,
+		},
+		"Delete Missing Todo": {
+			command:         `{"Id":"a","Type":"delete","TodoId":"9"}`,
+			expectedStatus:  http.StatusNotFound,
+			expectedProblem: pointer(problem(http.StatusNotFound, "/todo/socket", "Could not find todo with id [9]")),
+		},
+		"Create Without Todo": {
+			command:         `{"Id":"a","Type":"create"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedProblem: pointer(problem(http.StatusBadRequest, "/todo/socket", "Todo must be sent")),
+		},
+		"Subscribe Without Ids": {
+			command:        `{"Id":"a","Type":"subscribe"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedProblem: pointer(problem(http.StatusBadRequest, "/todo/socket",
+				"At least one of TodoIds or ListIds must be sent")),
+		},
+		"Unknown Type": {
+			command:         `{"Id":"a","Type":"rename"}`,
+			expectedStatus:  http.StatusBadRequest,
+			expectedProblem: pointer(problem(http.StatusBadRequest, "/todo/socket", "Unknown command type")),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server, _ := setupSocketTest(t)
+			response := sendCommand(t, dialSocket(t, server), tt.command)
+			if response.Type != SocketResponse || response.Id != "a" {
+				t.Errorf("unexpected response, expected type [%v] with id [a] but recieved [%v] with id [%v]",
+					SocketResponse, response.Type, response.Id)
+			}
+			if response.Status != tt.expectedStatus {
+				t.Errorf("unexpected status, expected [%v] but recieved [%v]", tt.expectedStatus, response.Status)
+			}
+			if response.Todo != nil && response.Todo.Title != tt.expectedTitle {
+				t.Errorf("unexpected title, expected [%v] but recieved [%v]", tt.expectedTitle, response.Todo.Title)
+			}
+			diff := cmp.Diff(tt.expectedProblem, response.Problem)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestSocketControllerMalformedMessage(t *testing.T) {
+	server, _ := setupSocketTest(t)
+	response := sendCommand(t, dialSocket(t, server), `{"Id":`)
+	expected := SocketMessage{Type: SocketResponse, Status: http.StatusBadRequest,
+		Problem: pointer(problem(http.StatusBadRequest, "/todo/socket", "Malformed message"))}
+	diff := cmp.Diff(expected, response)
+	if diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestSocketControllerBroadcast(t *testing.T) {
+	server, _ := setupSocketTest(t)
+	todoSubscriber := dialSocket(t, server)
+	listSubscriber := dialSocket(t, server)
+	writer := dialSocket(t, server)
+	sendCommand(t, todoSubscriber, `{"Id":"a","Type":"subscribe","TodoIds":["1"]}`)
+	sendCommand(t, listSubscriber, `{"Id":"b","Type":"subscribe","ListIds":["chores"]}`)
+
+	sendCommand(t, writer, `{"Id":"c","Type":"create","Todo":{"Id":"2","Title":"Buy flour"}}`)
+	moved := sendCommand(t, writer, `{"Id":"d","Type":"update","Todo":{"Id":"1","Title":"Bake cake","ListId":"chores"}}`)
+	if moved.Status != http.StatusOK {
+		t.Fatalf("unexpected status, expected [%v] but recieved [%v]", http.StatusOK, moved.Status)
+	}
+	for name, conn := range map[string]*websocket.Conn{"todo": todoSubscriber, "list": listSubscriber} {
+		// The todo item created is not subscribed to, so the first event is the todo item moving into the list
+		event := receiveMessage(t, conn)
+		if event.Type != SocketEvent || event.Event.Type != feed.TypeUpdate || event.Event.Todo.Id != "1" {
+			t.Errorf("unexpected message for the %s subscriber [%+v]", name, event)
+		}
+	}
+
+	sendCommand(t, listSubscriber, `{"Id":"e","Type":"unsubscribe","ListIds":["chores"]}`)
+	sendCommand(t, writer, `{"Id":"f","Type":"delete","TodoId":"1"}`)
+	event := receiveMessage(t, todoSubscriber)
+	if event.Event == nil || event.Event.Type != feed.TypeDelete {
+		t.Errorf("unexpected message for the todo subscriber [%+v]", event)
+	}
+	response := sendCommand(t, listSubscriber, `{"Id":"g","Type":"unsubscribe","TodoIds":["1"]}`)
+	if response.Type != SocketResponse || response.Id != "g" {
+		t.Errorf("unexpected message for the unsubscribed list subscriber [%+v]", response)
+	}
+}
+
+func TestSocketControllerShutdown(t *testing.T) {
+	server, changeFeed := setupSocketTest(t)
+	conn := dialSocket(t, server)
+	sendCommand(t, conn, `{"Id":"a","Type":"subscribe","TodoIds":["1"]}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	changeFeed.Run(ctx)
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+		t.Errorf("unexpected error, expected a close with code [%v] but recieved [%v]", websocket.CloseGoingAway, err)
+	}
+}
+
+func pointer[T any](value T) *T {
+	return &value
+}
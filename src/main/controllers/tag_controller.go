@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"TodoApp/src/main/services"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+)
+
+// A TagController represents a REST controller for handling HTTP requests to the API under the "tags/" URI, used to
+// manage the tags attached to todo items
+type TagController struct {
+	responder
+	todoService services.TodoService
+}
+
+// NewTagController creates a new TagController object. This is used by Wire when starting the API to perform the
+// necessary dependency injection
+func NewTagController(todoService services.TodoService, logger *slog.Logger) TagController {
+	return TagController{responder{logger}, todoService}
+}
+
+// A TagListResponse represents the body of a response listing every tag in use
+type TagListResponse struct {
+	Tags []services.TagCount `json:"Tags"`
+}
+
+// A RenameTagRequest represents the body of a request to rename a tag, Name being the new name of the tag
+type RenameTagRequest struct {
+	Name string `json:"Name"`
+}
+
+// A MergeTagsRequest represents the body of a request to merge tags, replacing each of the Sources with the Target
+type MergeTagsRequest struct {
+	Sources []string `json:"Sources"`
+	Target  string   `json:"Target"`
+}
+
+// ListTags returns every tag attached to a todo item, alongside the number of todo items it is attached to
+func (controller *TagController) ListTags(writer http.ResponseWriter, request *http.Request) {
+	tags, err := controller.todoService.ListTags(request.Context())
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, TagListResponse{Tags: tags})
+}
+
+// RenameTag renames the tag passed as a path parameter on every todo item it is attached to, returning the renamed tag
+// with the number of todo items it is attached to. A 404 is returned if the tag is not in use, and a 409 if the new name
+// is already in use, in which case the tags should be merged instead
+func (controller *TagController) RenameTag(writer http.ResponseWriter, request *http.Request) {
+	var body RenameTagRequest
+	if !controller.decodeBody(writer, request, &body) {
+		return
+	}
+	tag, err := controller.todoService.RenameTag(request.Context(), mux.Vars(request)["tag"], body.Name)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, tag)
+}
+
+// MergeTags replaces each of the source tags with the target tag on every todo item they are attached to, returning the
+// target tag with the number of todo items it is attached to. Either every todo item is changed or, if any of the
+// source tags is not in use, none are and a 404 is returned
+func (controller *TagController) MergeTags(writer http.ResponseWriter, request *http.Request) {
+	var body MergeTagsRequest
+	if !controller.decodeBody(writer, request, &body) {
+		return
+	}
+	tag, err := controller.todoService.MergeTags(request.Context(), body.Sources, body.Target)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, tag)
+}
+
+// RegisterRoutes registers the routes under the "tags/" URI with router, handling requests to them by calling methods
+// within TagController
+func (controller TagController) RegisterRoutes(myRouter *mux.Router) {
+	myRouter.HandleFunc("/tags", controller.ListTags).Methods("GET")
+	myRouter.HandleFunc("/tags/merge", controller.MergeTags).Methods("POST")
+	myRouter.HandleFunc("/tags/{tag}/rename", controller.RenameTag).Methods("POST")
+}
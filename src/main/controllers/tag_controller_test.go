@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"TodoApp/src/main/services"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTagController(t *testing.T) {
+	tests := map[string]struct {
+		method           string
+		target           string
+		body             string
+		expectedCode     int
+		expectedResponse interface{}
+		mockSetup        func(mockedComponent *MockTodoServiceImpl)
+	}{
+		"List Tags": {
+			method:       http.MethodGet,
+			target:       "/tags",
+			expectedCode: http.StatusOK,
+			expectedResponse: TagListResponse{Tags: []services.TagCount{
+				{Name: "home", Count: 2},
+				{Name: "work", Count: 1},
+			}},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ListTags").Return([]services.TagCount{{Name: "home", Count: 2}, {Name: "work", Count: 1}}, nil)
+			},
+		},
+		"Rename Tag": {
+			method:           http.MethodPost,
+			target:           "/tags/home/rename",
+			body:             `{"Name": "house"}`,
+			expectedCode:     http.StatusOK,
+			expectedResponse: services.TagCount{Name: "house", Count: 2},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("RenameTag", "home", "house").Return(services.TagCount{Name: "house", Count: 2}, nil)
+			},
+		},
+		"Rename Tag To Name In Use": {
+			method:           http.MethodPost,
+			target:           "/tags/home/rename",
+			body:             `{"Name": "work"}`,
+			expectedCode:     http.StatusConflict,
+			expectedResponse: problem(http.StatusConflict, "/tags/home/rename", "Tag with id [work] already exists"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("RenameTag", "home", "work").Return(services.TagCount{}, &services.ConflictError{Resource: "tag", Id: "work"})
+			},
+		},
+		"Merge Tags": {
+			method:           http.MethodPost,
+			target:           "/tags/merge",
+			body:             `{"Sources": ["home", "house"], "Target": "household"}`,
+			expectedCode:     http.StatusOK,
+			expectedResponse: services.TagCount{Name: "household", Count: 3},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("MergeTags", []string{"home", "house"}, "household").Return(services.TagCount{Name: "household", Count: 3}, nil)
+			},
+		},
+		"Merge Unknown Tag": {
+			method:           http.MethodPost,
+			target:           "/tags/merge",
+			body:             `{"Sources": ["garden"], "Target": "household"}`,
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/tags/merge", "Could not find tag with id [garden]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("MergeTags", []string{"garden"}, "household").Return(services.TagCount{}, &services.NotFoundError{Resource: "tag", Id: "garden"})
+			},
+		},
+		"Malformed Merge Request": {
+			method:           http.MethodPost,
+			target:           "/tags/merge",
+			body:             `{"Sources": "home"}`,
+			expectedCode:     http.StatusBadRequest,
+			expectedResponse: problem(http.StatusBadRequest, "/tags/merge", "Malformed request body"),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockTodoService := new(MockTodoServiceImpl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockTodoService)
+			}
+			router := mux.NewRouter()
+			NewTagController(mockTodoService, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+
+			httpWriter := httptest.NewRecorder()
+			router.ServeHTTP(httpWriter, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if httpWriter.Code != tt.expectedCode {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
+			}
+			expectedResponse, _ := json.Marshal(tt.expectedResponse)
+			require.JSONEq(t, string(expectedResponse), httpWriter.Body.String())
+		})
+	}
+}
@@ -5,118 +5,490 @@ import (
 	"TodoApp/src/main/services"
 	"TodoApp/src/main/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	"io"
-	"log"
+	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // A TodoController represents a REST controller for handling HTTP requests to the API under the "todo/" URI
 type TodoController struct {
+	responder
 	todoService services.TodoService
 }
 
 // NewTodoController creates a new TodoController object. This is used by Wire when starting the API to perform the
 // necessary dependency injection
-func NewTodoController(todoService services.TodoService) TodoController {
-	return TodoController{todoService}
+func NewTodoController(todoService services.TodoService, logger *slog.Logger) TodoController {
+	return TodoController{responder{logger}, todoService}
 }
 
-// ReturnAllTodos returns all todos items persisted within the DB
-func (controller *TodoController) ReturnAllTodos(writer http.ResponseWriter, _ *http.Request) {
-	fmt.Println("Endpoint Hit: returnAllTodos")
-	todos := controller.todoService.ReturnAllTodos()
-	utils.ReturnJsonResponse(writer, http.StatusOK, todos)
+// A TodoPageResponse represents the body of a response to a query for todo items, containing a single page of todo
+// items alongside the details needed to fetch the following page
+type TodoPageResponse struct {
+	Todos      []models.Todo `json:"Todos"`
+	Pagination Pagination    `json:"Pagination"`
+}
+
+// A TodoCollectionResponse represents the body of a response containing every todo item matching a request, unpaginated
+type TodoCollectionResponse struct {
+	Todos []models.Todo `json:"Todos"`
+}
+
+// An OccurrencesResponse represents the body of a response previewing when the next occurrences of a recurring todo
+// item will be due
+type OccurrencesResponse struct {
+	Occurrences []time.Time `json:"Occurrences"`
+}
+
+// A HistoryResponse represents the body of a response containing every revision of a todo item, in the order they were
+// recorded
+type HistoryResponse struct {
+	Revisions []models.Revision `json:"Revisions"`
+}
+
+// A Pagination describes a page of results. Composed of the following fields:
+//
+// Limit: The maximum number of items which could have been returned in the page
+//
+// Count: The number of items returned in the page
+//
+// Next: An opaque cursor which can be passed as the "cursor" query parameter to fetch the next page, omitted when there
+// are no further pages
+type Pagination struct {
+	Limit int    `json:"Limit"`
+	Count int    `json:"Count"`
+	Next  string `json:"Next,omitempty"`
+}
+
+// QueryTodos returns a page of the todo items persisted within the DB. The todo items returned can be filtered, sorted
+// and paginated using the following query parameters:
+//
+// completed: Only return todo items whose Completed value matches, either "true" or "false"
+//
+// q: Only return todo items whose title or description contains this text, ignoring case
+//
+// tag: Only return todo items with this tag, may be repeated to require several tags
+//
+// tag_match: Whether todo items must have "all" (the default) or "any" of the requested tags
+//
+// priority: Only return todo items with this priority, one of "low", "medium" or "high"
+//
+// due_before: Only return todo items due before this RFC 3339 timestamp
+//
+// due_after: Only return todo items due after this RFC 3339 timestamp
+//
+// overdue: Only return todo items which are, or are not, still open after they were due, either "true" or "false"
+//
+// ready: Only return todo items which are, or are not, open with every todo item they depend on completed, either
+// "true" or "false"
+//
+// sort: The field to sort by, one of "id" (the default), "title", "completed", "created", "updated", "due" or
+// "priority". Todo items without a due date are treated as due after every other todo item
+//
+// order: The direction to sort in, either "asc" (the default) or "desc"
+//
+// limit: The maximum number of todo items to return, between 1 and 500, defaults to 50
+//
+// cursor: The Next value of the previous page, used to fetch the page following it
+//
+// Links to the first and next pages are included within the Link header
+func (controller *TodoController) QueryTodos(writer http.ResponseWriter, request *http.Request) {
+	query, problems := parseTodoQuery(request.URL.Query())
+	if len(problems) > 0 {
+		controller.returnProblem(writer, request, http.StatusBadRequest, "Invalid query parameters", problems...)
+		return
+	}
+	page, err := controller.todoService.QueryTodos(request.Context(), query)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnTodoPage(writer, request, page)
+}
+
+// returnTodoPage responds with a page of todo items, including links to the first and next pages within the Link header
+func (controller responder) returnTodoPage(writer http.ResponseWriter, request *http.Request, page services.TodoPage) {
+	links := []string{pageLink(request, "", "first")}
+	if page.Next != "" {
+		links = append(links, pageLink(request, page.Next, "next"))
+	}
+	writer.Header().Set("Link", strings.Join(links, ", "))
+	controller.returnJson(writer, request, http.StatusOK, TodoPageResponse{
+		Todos:      page.Todos,
+		Pagination: Pagination{Limit: page.Limit, Count: len(page.Todos), Next: page.Next},
+	})
+}
+
+// parseTodoQuery builds a TodoQuery from the query parameters of a request, returning a problem for each parameter
+// which could not be parsed
+func parseTodoQuery(values url.Values) (services.TodoQuery, []utils.ProblemError) {
+	var problems []utils.ProblemError
+	query := services.TodoQuery{
+		Search: values.Get("q"),
+		Tags:   values["tag"],
+		SortBy: values.Get("sort"),
+		Cursor: values.Get("cursor"),
+	}
+	query.Completed, problems = parseBool(values, "completed", problems)
+	switch values.Get("tag_match") {
+	case "", "all":
+	case "any":
+		query.AnyTag = true
+	default:
+		problems = append(problems, utils.ProblemError{Field: "tag_match", Message: "must be either all or any"})
+	}
+	if priority := values.Get("priority"); priority != "" {
+		parsed := models.Priority(priority)
+		query.Priority = &parsed
+	}
+	query.DueBefore, problems = parseTimestamp(values, "due_before", problems)
+	query.DueAfter, problems = parseTimestamp(values, "due_after", problems)
+	query.Overdue, problems = parseBool(values, "overdue", problems)
+	query.Ready, problems = parseBool(values, "ready", problems)
+	switch values.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Descending = true
+	default:
+		problems = append(problems, utils.ProblemError{Field: "order", Message: "must be either asc or desc"})
+	}
+	if limit := values.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 {
+			problems = append(problems, utils.ProblemError{Field: "limit", Message: "must be a positive whole number"})
+		} else {
+			query.Limit = parsed
+		}
+	}
+	return query, problems
+}
+
+// parseBool parses the boolean held in the query parameter field, if it was supplied, appending a problem to problems if
+// it could not be parsed
+func parseBool(values url.Values, field string, problems []utils.ProblemError) (*bool, []utils.ProblemError) {
+	value := values.Get(field)
+	if value == "" {
+		return nil, problems
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, append(problems, utils.ProblemError{Field: field, Message: "must be either true or false"})
+	}
+	return &parsed, problems
+}
+
+// parseTimestamp parses the RFC 3339 timestamp held in the query parameter field, if it was supplied, appending a
+// problem to problems if it could not be parsed
+func parseTimestamp(values url.Values, field string, problems []utils.ProblemError) (*time.Time, []utils.ProblemError) {
+	value := values.Get(field)
+	if value == "" {
+		return nil, problems
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, append(problems, utils.ProblemError{Field: field, Message: "must be an RFC 3339 timestamp"})
+	}
+	return &parsed, problems
+}
+
+// pageLink builds an entry for the Link header pointing at the page starting from cursor, keeping every other query
+// parameter of the original request
+func pageLink(request *http.Request, cursor string, rel string) string {
+	values := request.URL.Query()
+	values.Del("cursor")
+	if cursor != "" {
+		values.Set("cursor", cursor)
+	}
+	target := url.URL{Path: request.URL.Path, RawQuery: values.Encode()}
+	return fmt.Sprintf("<%s>; rel=\"%s\"", target.String(), rel)
 }
 
 // ReturnSingleTodo returns a single todo item persisted within the DB with an id matching the id passed as a path parameter.
 // The path param is accessed via the map within request parameter. If an existing todo item with an id matching that of
-// the new todo item is not found, and error will be returned instead
+// the new todo item is not found, and error will be returned instead. The todo item includes whether it is Blocked by
+// any of the todo items it depends on. The version of the todo item is returned in the
+// ETag header, and if it matches the If-None-Match header a 304 is returned without a body
 func (controller *TodoController) ReturnSingleTodo(writer http.ResponseWriter, request *http.Request) {
-	fmt.Println("Endpoint Hit: returnSingleTodo")
 	vars := mux.Vars(request)
 	todoId := vars["id"]
-	todo, err := controller.todoService.ReturnSingleTodo(todoId)
+	todo, err := controller.todoService.ReturnSingleTodo(request.Context(), todoId)
 
 	if err != nil {
-		log.Println(err.Error())
-		utils.ReturnJsonResponse(writer, http.StatusNotFound, fmt.Sprintf("Could not find todo with id [%s]", todoId))
+		controller.returnServiceError(writer, request, err)
+	} else if noneMatch(request, todo.Version) {
+		writer.Header().Set("ETag", etag(todo.Version))
+		writer.WriteHeader(http.StatusNotModified)
 	} else {
-		utils.ReturnJsonResponse(writer, http.StatusOK, todo)
-
+		controller.returnTodo(writer, request, http.StatusOK, todo)
 	}
 }
 
-// CreateNewTodo creates a new todo item and persist it within the DB. If an existing todo item with an id matching
-// that of the new todo item is found, and error will be returned instead
+// CreateNewTodo creates a new todo item and persist it within the DB, generating its id if none was supplied. The
+// location of the new todo item is returned in the Location header. If an existing todo item with an id matching
+// that of the new todo item is found, and error will be returned instead. Malformed request bodies are rejected with a
+// 400 and todo items which fail validation with a 422
 func (controller *TodoController) CreateNewTodo(writer http.ResponseWriter, request *http.Request) {
-	fmt.Println("Endpoint Hit: createNewTodo")
-	reqBody, _ := io.ReadAll(request.Body)
+	reqBody, err := io.ReadAll(request.Body)
+	if err != nil {
+		controller.returnBadRequest(writer, request, err)
+		return
+	}
 	var todo models.Todo
-	err := json.Unmarshal(reqBody, &todo)
+	err = json.Unmarshal(reqBody, &todo)
 	if err != nil {
-		log.Println("Error deserializing the request", err)
-		utils.ReturnJsonResponse(writer, http.StatusInternalServerError, "Internal Server Error")
+		controller.returnBadRequest(writer, request, err)
 		return
 	}
-	response, err := controller.todoService.CreateNewTodo(todo)
+	response, err := controller.todoService.CreateNewTodo(request.Context(), todo)
 	if err != nil {
-		log.Println(err.Error())
-		utils.ReturnJsonResponse(writer, http.StatusConflict, fmt.Sprintf("Todo with id [%s] already exists", todo.Id))
+		controller.returnServiceError(writer, request, err)
 	} else {
-		utils.ReturnJsonResponse(writer, http.StatusCreated, response)
+		controller.returnCreated(writer, request, response)
 	}
 }
 
-// DeleteTodo removes a todo item persisted within the DB with an id matching the id passed as a path parameter.
-// The path param is accessed via the map within request parameter
+// DeleteTodo moves a todo item persisted within the DB with an id matching the id passed as a path parameter to the
+// trash. The path param is accessed via the map within request parameter. If no todo item with a matching id exists a
+// 404 is returned. When an If-Match header is sent the todo item is only removed if it is still at that version, otherwise a
+// 412 is returned
 func (controller *TodoController) DeleteTodo(writer http.ResponseWriter, request *http.Request) {
-	fmt.Println("Endpoint Hit: deleteTodo")
 	vars := mux.Vars(request)
 	todoId := vars["id"]
-	controller.todoService.DeleteTodo(todoId)
-	utils.ReturnJsonResponse(writer, http.StatusOK, "Todo Deleted Successfully")
+	conditions, ok := parsePreconditions(request)
+	if !ok {
+		controller.returnPreconditionFailed(writer, request, "If-Match must be either * or a single strong ETag")
+		return
+	}
+	err := controller.todoService.DeleteTodo(request.Context(), todoId, conditions.version)
+	if conditions.ifMatch && errors.Is(err, services.ErrNotFound) {
+		controller.returnPreconditionFailed(writer, request, capitalise(err.Error()))
+		return
+	}
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, "Todo Deleted Successfully")
 }
 
 // UpdateTodo modifies an existing todo item with the details from the todo item passed in the request. If an existing
-// todo item with an id matching that of the new todo item is not found, and error will be returned instead
+// todo item with an id matching that of the new todo item is not found, a new todo item will be created instead. The
+// following conditional headers are honoured, returning a 412 when they are not satisfied:
+//
+// If-Match: The todo item must already exist and, unless "*" is sent, be at the version of the ETag sent
+//
+// If-None-Match: When "*" is sent the todo item must not already exist, so it is only ever created
 func (controller *TodoController) UpdateTodo(writer http.ResponseWriter, request *http.Request) {
-	fmt.Println("Endpoint Hit: updateTodo")
-	reqBody, _ := io.ReadAll(request.Body)
-	var todo models.Todo
-	err := json.Unmarshal(reqBody, &todo)
+	reqBody, err := io.ReadAll(request.Body)
 	if err != nil {
-		log.Println("Error deserializing the request", err)
-		utils.ReturnJsonResponse(writer, http.StatusInternalServerError, "Internal Server Error")
+		controller.returnBadRequest(writer, request, err)
 		return
 	}
-	response, err := controller.todoService.UpdateTodo(todo)
+	var todo models.Todo
+	err = json.Unmarshal(reqBody, &todo)
 	if err != nil {
-		log.Printf("Failed to find existing todo item with id [%v] attempting to create new todo item\n", todo.Id)
-		response, err := controller.todoService.CreateNewTodo(todo)
+		controller.returnBadRequest(writer, request, err)
+		return
+	}
+	conditions, ok := parsePreconditions(request)
+	if !ok {
+		controller.returnPreconditionFailed(writer, request, "If-Match must be either * or a single strong ETag")
+		return
+	}
+	if conditions.createOnly {
+		response, err := controller.todoService.CreateNewTodo(request.Context(), todo)
+		if errors.Is(err, services.ErrConflict) {
+			controller.returnPreconditionFailed(writer, request, capitalise(err.Error()))
+		} else if err != nil {
+			controller.returnServiceError(writer, request, err)
+		} else {
+			controller.returnCreated(writer, request, response)
+		}
+		return
+	}
+	response, err := controller.todoService.UpdateTodo(request.Context(), todo, conditions.version)
+	if conditions.ifMatch && errors.Is(err, services.ErrNotFound) {
+		controller.returnPreconditionFailed(writer, request, capitalise(err.Error()))
+	} else if errors.Is(err, services.ErrNotFound) {
+		controller.logger.InfoContext(request.Context(), "Todo not found, creating it instead", "id", todo.Id)
+		response, err := controller.todoService.CreateNewTodo(request.Context(), todo)
 		if err != nil {
-			log.Println(err.Error())
-			utils.ReturnJsonResponse(writer, http.StatusConflict, fmt.Sprintf("Todo with id [%s] already exists", todo.Id))
+			controller.returnServiceError(writer, request, err)
 		} else {
-			utils.ReturnJsonResponse(writer, http.StatusCreated, response)
+			controller.returnCreated(writer, request, response)
 		}
+	} else if err != nil {
+		controller.returnServiceError(writer, request, err)
 	} else {
-		utils.ReturnJsonResponse(writer, http.StatusOK, response)
+		controller.returnTodo(writer, request, http.StatusOK, response)
+	}
+}
+
+// PatchTodo applies a patch document from the request body to the todo item with an id matching the id passed as a
+// path parameter. The format of the patch document is chosen by the Content-Type header, which must be either
+// "application/merge-patch+json" (RFC 7396) or "application/json-patch+json" (RFC 6902), any other type is rejected
+// with a 415. The patched todo item is validated before it is persisted, and its id cannot be changed. When an If-Match
+// header is sent the patch is only applied if the todo item is still at that version, otherwise a 412 is returned
+func (controller *TodoController) PatchTodo(writer http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	todoId := vars["id"]
+	mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if err != nil || (mediaType != services.MergePatchType && mediaType != services.JsonPatchType) {
+		writer.Header().Set("Accept-Patch", services.MergePatchType+", "+services.JsonPatchType)
+		controller.returnProblem(writer, request, http.StatusUnsupportedMediaType,
+			fmt.Sprintf("Patch documents must be sent as either %s or %s", services.MergePatchType, services.JsonPatchType))
+		return
+	}
+	document, err := io.ReadAll(request.Body)
+	if err != nil {
+		controller.returnBadRequest(writer, request, err)
+		return
+	}
+	conditions, ok := parsePreconditions(request)
+	if !ok {
+		controller.returnPreconditionFailed(writer, request, "If-Match must be either * or a single strong ETag")
+		return
+	}
+	patch := services.TodoPatch{Type: mediaType, Document: document}
+	response, err := controller.todoService.PatchTodo(request.Context(), todoId, patch, conditions.version)
+	if conditions.ifMatch && errors.Is(err, services.ErrNotFound) {
+		controller.returnPreconditionFailed(writer, request, capitalise(err.Error()))
+		return
+	}
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnTodo(writer, request, http.StatusOK, response)
+}
+
+// ReturnChildren returns the todo items which are direct subtasks of the todo item with an id matching the id passed as
+// a path parameter, in the order they were created. If no todo item with a matching id exists a 404 is returned
+func (controller *TodoController) ReturnChildren(writer http.ResponseWriter, request *http.Request) {
+	children, err := controller.todoService.ReturnChildren(request.Context(), mux.Vars(request)["id"])
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
 	}
+	controller.returnJson(writer, request, http.StatusOK, TodoCollectionResponse{Todos: children})
 }
 
-// HandleRequests initializes a new MUX router to receive requests under the "todo/" URI and handles them by calling
-// methods within TodoController
-func (controller TodoController) HandleRequests() {
-	fmt.Println("Starting TodoController...")
-	myRouter := mux.NewRouter().StrictSlash(true)
+// ReturnTodoTree returns the todo item with an id matching the id passed as a path parameter, with all of its subtasks
+// nested beneath it in Children. Every todo item within the tree includes its Progress, the share of its subtasks which
+// have been completed. If no todo item with a matching id exists a 404 is returned
+func (controller *TodoController) ReturnTodoTree(writer http.ResponseWriter, request *http.Request) {
+	tree, err := controller.todoService.ReturnTodoTree(request.Context(), mux.Vars(request)["id"])
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, tree)
+}
+
+// PreviewOccurrences returns when the next occurrences of the todo item with an id matching the id passed as a path
+// parameter will be due, according to its Recurrence. The "count" query parameter sets how many are returned, between 1
+// and 100, defaulting to 5. Fewer are returned once the rule ends, and none if the todo item does not recur. If no todo
+// item with a matching id exists a 404 is returned
+func (controller *TodoController) PreviewOccurrences(writer http.ResponseWriter, request *http.Request) {
+	count := 5
+	if value := request.URL.Query().Get("count"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			controller.returnProblem(writer, request, http.StatusBadRequest, "Invalid query parameters",
+				utils.ProblemError{Field: "count", Message: "must be a positive whole number"})
+			return
+		}
+		count = parsed
+	}
+	occurrences, err := controller.todoService.PreviewOccurrences(request.Context(), mux.Vars(request)["id"], count)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, OccurrencesResponse{Occurrences: occurrences})
+}
+
+// ReturnHistory returns every revision of the todo item with an id matching the id passed as a path parameter, in the
+// order they were recorded. Revisions are kept once the todo item has been deleted, and if no todo item with a matching
+// id has ever existed a 404 is returned
+func (controller *TodoController) ReturnHistory(writer http.ResponseWriter, request *http.Request) {
+	revisions, err := controller.todoService.ReturnHistory(request.Context(), mux.Vars(request)["id"])
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, HistoryResponse{Revisions: revisions})
+}
+
+// RevertTodo returns the todo item with an id matching the id passed as a path parameter to how it was after the
+// revision passed as a path parameter, returning the reverted todo item with its ETag. The revert is made only if the
+// todo item still matches the If-Match header, when one is sent. If the todo item, or the revision, does not exist a 404
+// is returned, and a revision which removed the todo item cannot be reverted to
+func (controller *TodoController) RevertTodo(writer http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	revision, err := strconv.ParseInt(vars["revision"], 10, 64)
+	if err != nil || revision < 1 {
+		controller.returnProblem(writer, request, http.StatusBadRequest, "Invalid path parameters",
+			utils.ProblemError{Field: "revision", Message: "must be a positive whole number"})
+		return
+	}
+	conditions, ok := parsePreconditions(request)
+	if !ok {
+		controller.returnPreconditionFailed(writer, request, "If-Match must be either * or a single strong ETag")
+		return
+	}
+	todo, err := controller.todoService.RevertTodo(request.Context(), vars["id"], revision, conditions.version)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnTodo(writer, request, http.StatusOK, todo)
+}
+
+// PlanTodos returns every open todo item in an order they can be worked through, each after all of the open todo items
+// it depends on. Todo items which do not depend on one another keep the order they were created in
+func (controller *TodoController) PlanTodos(writer http.ResponseWriter, request *http.Request) {
+	todos, err := controller.todoService.PlanTodos(request.Context())
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, TodoCollectionResponse{Todos: todos})
+}
+
+// returnTodo responds with a single todo item, including its version within the ETag header
+func (controller responder) returnTodo(writer http.ResponseWriter, request *http.Request, code int, todo models.Todo) {
+	writer.Header().Set("ETag", etag(todo.Version))
+	controller.returnJson(writer, request, code, todo)
+}
+
+// returnCreated responds with a newly created todo item, including its location within the Location header
+func (controller responder) returnCreated(writer http.ResponseWriter, request *http.Request, todo models.Todo) {
+	writer.Header().Set("Location", "/todo/"+url.PathEscape(todo.Id))
+	controller.returnTodo(writer, request, http.StatusCreated, todo)
+}
+
+// RegisterRoutes registers the routes under the "todo/" URI with router, handling requests to them by calling methods
+// within TodoController
+func (controller TodoController) RegisterRoutes(myRouter *mux.Router) {
 	myRouter.HandleFunc("/todo", controller.CreateNewTodo).Methods("POST")
 	myRouter.HandleFunc("/todo", controller.UpdateTodo).Methods("PUT")
-	myRouter.HandleFunc("/todo", controller.ReturnAllTodos).Methods("GET")
+	myRouter.HandleFunc("/todo", controller.QueryTodos).Methods("GET")
+	myRouter.HandleFunc("/todo/{id}", controller.PatchTodo).Methods("PATCH")
 	myRouter.HandleFunc("/todo/{id}", controller.DeleteTodo).Methods("DELETE")
 	myRouter.HandleFunc("/todo/{id}", controller.ReturnSingleTodo).Methods("GET")
-	fmt.Println("TodoController Listening...")
-	log.Fatalln(http.ListenAndServe(":10000", myRouter))
-
+	myRouter.HandleFunc("/todo/{id}/children", controller.ReturnChildren).Methods("GET")
+	myRouter.HandleFunc("/todo/{id}/tree", controller.ReturnTodoTree).Methods("GET")
+	myRouter.HandleFunc("/todo/{id}/occurrences", controller.PreviewOccurrences).Methods("GET")
+	myRouter.HandleFunc("/todo/{id}/history", controller.ReturnHistory).Methods("GET")
+	myRouter.HandleFunc("/todo/{id}/history/{revision}/revert", controller.RevertTodo).Methods("POST")
+	myRouter.HandleFunc("/plan", controller.PlanTodos).Methods("GET")
 }
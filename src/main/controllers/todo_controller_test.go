@@ -2,16 +2,20 @@ package controllers
 
 import (
 	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/utils"
+	"context"
 	"encoding/json"
-	"errors"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 var todoController TodoController
@@ -21,12 +25,27 @@ type MockTodoServiceImpl struct {
 	Todos []models.Todo
 }
 
-func (service MockTodoServiceImpl) ReturnAllTodos() []models.Todo {
+func (service *MockTodoServiceImpl) ReturnAllTodos(_ context.Context) ([]models.Todo, error) {
 	args := service.Called()
-	return args.Get(0).([]models.Todo)
+	return args.Get(0).([]models.Todo), args.Error(1)
 }
 
-func (service MockTodoServiceImpl) ReturnSingleTodo(id string) (models.Todo, error) {
+func (service *MockTodoServiceImpl) QueryTodos(_ context.Context, query services.TodoQuery) (services.TodoPage, error) {
+	args := service.Called(query)
+	return args.Get(0).(services.TodoPage), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) CountTodos(_ context.Context) (services.TodoCounts, error) {
+	args := service.Called()
+	return args.Get(0).(services.TodoCounts), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) CheckHealth(_ context.Context) []services.ComponentHealth {
+	args := service.Called()
+	return args.Get(0).([]services.ComponentHealth)
+}
+
+func (service *MockTodoServiceImpl) ReturnSingleTodo(_ context.Context, id string) (models.Todo, error) {
 	args := service.Called(id)
 	if args.Error(1) == nil {
 		return args.Get(0).(models.Todo), nil
@@ -34,7 +53,7 @@ func (service MockTodoServiceImpl) ReturnSingleTodo(id string) (models.Todo, err
 	return args.Get(0).(models.Todo), args.Get(1).(error)
 }
 
-func (service MockTodoServiceImpl) CreateNewTodo(newTodo models.Todo) (models.Todo, error) {
+func (service *MockTodoServiceImpl) CreateNewTodo(_ context.Context, newTodo models.Todo) (models.Todo, error) {
 	args := service.Called(newTodo)
 	if args.Error(1) == nil {
 		return args.Get(0).(models.Todo), nil
@@ -42,20 +61,126 @@ func (service MockTodoServiceImpl) CreateNewTodo(newTodo models.Todo) (models.To
 	return args.Get(0).(models.Todo), args.Get(1).(error)
 }
 
-func (service MockTodoServiceImpl) DeleteTodo(id string) {
-	return
+func (service *MockTodoServiceImpl) DeleteTodo(_ context.Context, id string, expectedVersion *int64) error {
+	args := service.Called(id, expectedVersion)
+	return args.Error(0)
 }
 
-func (service MockTodoServiceImpl) UpdateTodo(newTodo models.Todo) (models.Todo, error) {
-	args := service.Called(newTodo)
+func (service *MockTodoServiceImpl) UpdateTodo(_ context.Context, newTodo models.Todo, expectedVersion *int64) (models.Todo, error) {
+	args := service.Called(newTodo, expectedVersion)
 	if args.Error(1) == nil {
 		return args.Get(0).(models.Todo), nil
 	}
 	return args.Get(0).(models.Todo), args.Get(1).(error)
 }
 
+func (service *MockTodoServiceImpl) PatchTodo(_ context.Context, id string, patch services.TodoPatch, expectedVersion *int64) (models.Todo, error) {
+	args := service.Called(id, patch, expectedVersion)
+	return args.Get(0).(models.Todo), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) ListTags(_ context.Context) ([]services.TagCount, error) {
+	args := service.Called()
+	return args.Get(0).([]services.TagCount), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) RenameTag(_ context.Context, tag string, name string) (services.TagCount, error) {
+	args := service.Called(tag, name)
+	return args.Get(0).(services.TagCount), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) MergeTags(_ context.Context, sources []string, target string) (services.TagCount, error) {
+	args := service.Called(sources, target)
+	return args.Get(0).(services.TagCount), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) ReturnChildren(_ context.Context, id string) ([]models.Todo, error) {
+	args := service.Called(id)
+	return args.Get(0).([]models.Todo), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) ReturnTodoTree(_ context.Context, id string) (services.TodoTree, error) {
+	args := service.Called(id)
+	return args.Get(0).(services.TodoTree), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) PlanTodos(_ context.Context) ([]models.Todo, error) {
+	args := service.Called()
+	return args.Get(0).([]models.Todo), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) PreviewOccurrences(_ context.Context, id string, count int) ([]time.Time, error) {
+	args := service.Called(id, count)
+	return args.Get(0).([]time.Time), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) ReturnTrash(_ context.Context) ([]models.Todo, error) {
+	args := service.Called()
+	return args.Get(0).([]models.Todo), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) RestoreTodo(_ context.Context, id string) (models.Todo, error) {
+	args := service.Called(id)
+	return args.Get(0).(models.Todo), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) PurgeTodo(_ context.Context, id string) error {
+	args := service.Called(id)
+	return args.Error(0)
+}
+
+func (service *MockTodoServiceImpl) PurgeTrash(_ context.Context, olderThan time.Duration) (int, error) {
+	args := service.Called(olderThan)
+	return args.Int(0), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) ReturnHistory(_ context.Context, id string) ([]models.Revision, error) {
+	args := service.Called(id)
+	return args.Get(0).([]models.Revision), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) QueryAudit(_ context.Context, query services.AuditQuery) (services.AuditPage, error) {
+	args := service.Called(query)
+	return args.Get(0).(services.AuditPage), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) RevertTodo(_ context.Context, id string, revision int64, expectedVersion *int64) (models.Todo, error) {
+	args := service.Called(id, revision, expectedVersion)
+	return args.Get(0).(models.Todo), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) ReturnAllLists(_ context.Context) ([]models.List, error) {
+	args := service.Called()
+	return args.Get(0).([]models.List), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) ReturnSingleList(_ context.Context, id string) (models.List, error) {
+	args := service.Called(id)
+	return args.Get(0).(models.List), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) CreateNewList(_ context.Context, newList models.List) (models.List, error) {
+	args := service.Called(newList)
+	return args.Get(0).(models.List), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) UpdateList(_ context.Context, newList models.List, expectedVersion *int64) (models.List, error) {
+	args := service.Called(newList, expectedVersion)
+	return args.Get(0).(models.List), args.Error(1)
+}
+
+func (service *MockTodoServiceImpl) DeleteList(_ context.Context, id string, mode services.ListDeleteMode, expectedVersion *int64) error {
+	args := service.Called(id, mode, expectedVersion)
+	return args.Error(0)
+}
+
+func (service *MockTodoServiceImpl) MoveTodo(_ context.Context, id string, listId string, expectedVersion *int64) (models.Todo, error) {
+	args := service.Called(id, listId, expectedVersion)
+	return args.Get(0).(models.Todo), args.Error(1)
+}
+
 func setupTodoController(service *MockTodoServiceImpl) {
-	todoController = NewTodoController(service)
+	todoController = NewTodoController(service, slog.New(slog.NewTextHandler(io.Discard, nil)))
 }
 
 func getHttpResponse(t *testing.T, res *http.Response) []byte {
@@ -70,58 +195,44 @@ func getHttpResponse(t *testing.T, res *http.Response) []byte {
 	return data
 }
 
-func TestReturnAllTodos(t *testing.T) {
+func problem(httpCode int, instance string, detail string, errors ...utils.ProblemError) utils.Problem {
+	return utils.Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(httpCode),
+		Status:   httpCode,
+		Detail:   detail,
+		Instance: instance,
+		Errors:   errors,
+	}
+}
 
+func TestQueryTodos(t *testing.T) {
+	completed := true
+	high := models.PriorityHigh
+	dueBefore := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
 	tests := map[string]struct {
+		rawQuery         string
 		expectedCode     int
-		expectedResponse []models.Todo
+		expectedResponse interface{}
+		expectedLink     string
 		mockSetup        func(mockedComponent *MockTodoServiceImpl)
 	}{
-		"Return Single Todo": {
+		"No Todos Found": {
 			expectedCode: http.StatusOK,
-			expectedResponse: []models.Todo{
-				{
-					Id:        "1",
-					Title:     "Bake cake",
-					Desc:      "Bake a carrot cake for tomorrow's fate",
-					Completed: false,
-				},
+			expectedResponse: TodoPageResponse{
+				Todos:      []models.Todo{},
+				Pagination: Pagination{Limit: 50, Count: 0},
 			},
+			expectedLink: `</todo>; rel="first"`,
 			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
-				mockedComponent.On("ReturnAllTodos").Return([]models.Todo{
-					{
-						Id:        "1",
-						Title:     "Bake cake",
-						Desc:      "Bake a carrot cake for tomorrow's fate",
-						Completed: false,
-					},
-				})
+				mockedComponent.On("QueryTodos", services.TodoQuery{}).
+					Return(services.TodoPage{Todos: []models.Todo{}, Limit: 50}, nil)
 			},
 		},
 		"Return Multiple Todos": {
 			expectedCode: http.StatusOK,
-			expectedResponse: []models.Todo{
-				{
-					Id:        "1",
-					Title:     "Bake cake",
-					Desc:      "Bake a carrot cake for tomorrow's fate",
-					Completed: false,
-				},
-				{
-					Id:        "2",
-					Title:     "Iron shirts",
-					Desc:      "Iron shirts that are in the dryer",
-					Completed: false,
-				},
-				{
-					Id:        "3",
-					Title:     "Walk dog",
-					Desc:      "Walk the dog around the town",
-					Completed: false,
-				},
-			},
-			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
-				mockedComponent.On("ReturnAllTodos").Return([]models.Todo{
+			expectedResponse: TodoPageResponse{
+				Todos: []models.Todo{
 					{
 						Id:        "1",
 						Title:     "Bake cake",
@@ -134,20 +245,131 @@ func TestReturnAllTodos(t *testing.T) {
 						Desc:      "Iron shirts that are in the dryer",
 						Completed: false,
 					},
+				},
+				Pagination: Pagination{Limit: 50, Count: 2},
+			},
+			expectedLink: `</todo>; rel="first"`,
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("QueryTodos", services.TodoQuery{}).Return(services.TodoPage{
+					Todos: []models.Todo{
+						{
+							Id:        "1",
+							Title:     "Bake cake",
+							Desc:      "Bake a carrot cake for tomorrow's fate",
+							Completed: false,
+						},
+						{
+							Id:        "2",
+							Title:     "Iron shirts",
+							Desc:      "Iron shirts that are in the dryer",
+							Completed: false,
+						},
+					},
+					Limit: 50,
+				}, nil)
+			},
+		},
+		"Filtered Sorted And Paginated": {
+			rawQuery:     "completed=true&q=cake&sort=title&order=desc&limit=1",
+			expectedCode: http.StatusOK,
+			expectedResponse: TodoPageResponse{
+				Todos: []models.Todo{
 					{
-						Id:        "3",
-						Title:     "Walk dog",
-						Desc:      "Walk the dog around the town",
-						Completed: false,
+						Id:        "1",
+						Title:     "Bake cake",
+						Desc:      "Bake a carrot cake for tomorrow's fate",
+						Completed: true,
+					},
+				},
+				Pagination: Pagination{Limit: 1, Count: 1, Next: "abc"},
+			},
+			expectedLink: `</todo?completed=true&limit=1&order=desc&q=cake&sort=title>; rel="first", ` +
+				`</todo?completed=true&cursor=abc&limit=1&order=desc&q=cake&sort=title>; rel="next"`,
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("QueryTodos", services.TodoQuery{
+					Completed:  &completed,
+					Search:     "cake",
+					SortBy:     "title",
+					Descending: true,
+					Limit:      1,
+				}).Return(services.TodoPage{
+					Todos: []models.Todo{
+						{
+							Id:        "1",
+							Title:     "Bake cake",
+							Desc:      "Bake a carrot cake for tomorrow's fate",
+							Completed: true,
+						},
 					},
-				})
+					Limit: 1,
+					Next:  "abc",
+				}, nil)
 			},
 		},
-		"No Todos Found": {
-			expectedCode:     http.StatusOK,
-			expectedResponse: []models.Todo{},
+		"Due Date And Priority Filters": {
+			rawQuery:     "priority=high&due_before=2024-03-01T12:00:00Z&overdue=true&sort=due",
+			expectedCode: http.StatusOK,
+			expectedResponse: TodoPageResponse{
+				Todos:      []models.Todo{},
+				Pagination: Pagination{Limit: 50, Count: 0},
+			},
+			expectedLink: `</todo?due_before=2024-03-01T12%3A00%3A00Z&overdue=true&priority=high&sort=due>; rel="first"`,
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("QueryTodos", services.TodoQuery{
+					Priority:  &high,
+					DueBefore: &dueBefore,
+					Overdue:   &completed,
+					SortBy:    "due",
+				}).Return(services.TodoPage{Todos: []models.Todo{}, Limit: 50}, nil)
+			},
+		},
+		"Tag Filters": {
+			rawQuery:     "tag=home&tag=work&tag_match=any",
+			expectedCode: http.StatusOK,
+			expectedResponse: TodoPageResponse{
+				Todos:      []models.Todo{},
+				Pagination: Pagination{Limit: 50, Count: 0},
+			},
+			expectedLink: `</todo?tag=home&tag=work&tag_match=any>; rel="first"`,
 			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
-				mockedComponent.On("ReturnAllTodos").Return([]models.Todo{})
+				mockedComponent.On("QueryTodos", services.TodoQuery{Tags: []string{"home", "work"}, AnyTag: true}).
+					Return(services.TodoPage{Todos: []models.Todo{}, Limit: 50}, nil)
+			},
+		},
+		"Ready Filter": {
+			rawQuery:     "ready=true",
+			expectedCode: http.StatusOK,
+			expectedResponse: TodoPageResponse{
+				Todos:      []models.Todo{},
+				Pagination: Pagination{Limit: 50, Count: 0},
+			},
+			expectedLink: `</todo?ready=true>; rel="first"`,
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("QueryTodos", services.TodoQuery{Ready: &completed}).
+					Return(services.TodoPage{Todos: []models.Todo{}, Limit: 50}, nil)
+			},
+		},
+		"Invalid Query Parameters": {
+			rawQuery:     "completed=maybe&tag_match=some&due_before=tomorrow&due_after=2024-03-01&overdue=soon&ready=never&order=sideways&limit=0",
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: problem(http.StatusBadRequest, "/todo", "Invalid query parameters",
+				utils.ProblemError{Field: "completed", Message: "must be either true or false"},
+				utils.ProblemError{Field: "tag_match", Message: "must be either all or any"},
+				utils.ProblemError{Field: "due_before", Message: "must be an RFC 3339 timestamp"},
+				utils.ProblemError{Field: "due_after", Message: "must be an RFC 3339 timestamp"},
+				utils.ProblemError{Field: "overdue", Message: "must be either true or false"},
+				utils.ProblemError{Field: "ready", Message: "must be either true or false"},
+				utils.ProblemError{Field: "order", Message: "must be either asc or desc"},
+				utils.ProblemError{Field: "limit", Message: "must be a positive whole number"}),
+		},
+		"Invalid Query Rejected By Service": {
+			rawQuery:     "sort=colour",
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedResponse: problem(http.StatusUnprocessableEntity, "/todo", "Query sort is not a sortable field",
+				utils.ProblemError{Field: "sort", Message: "is not a sortable field"}),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("QueryTodos", services.TodoQuery{SortBy: "colour"}).Return(services.TodoPage{},
+					&services.ValidationError{Resource: "query", Fields: []services.FieldError{{Field: "sort", Message: "is not a sortable field"}}})
 			},
 		},
 	}
@@ -155,19 +377,24 @@ func TestReturnAllTodos(t *testing.T) {
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
 			mockTodoService := new(MockTodoServiceImpl)
-			tt.mockSetup(mockTodoService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockTodoService)
+			}
 			setupTodoController(mockTodoService)
 
-			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req := httptest.NewRequest(http.MethodGet, "/todo?"+tt.rawQuery, nil)
 			httpWriter := httptest.NewRecorder()
 
-			todoController.ReturnAllTodos(httpWriter, req)
+			todoController.QueryTodos(httpWriter, req)
 			res := httpWriter.Result()
 			defer res.Body.Close()
 			data := getHttpResponse(t, res)
 			if httpWriter.Code != tt.expectedCode {
 				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
 			}
+			if res.Header.Get("Link") != tt.expectedLink {
+				t.Errorf("unexpected Link header, expected [%v] but recieved [%v]", tt.expectedLink, res.Header.Get("Link"))
+			}
 			expectedResponse, _ := json.Marshal(tt.expectedResponse)
 			require.JSONEq(t, string(expectedResponse), string(data))
 		})
@@ -186,10 +413,10 @@ func TestReturnSingleTodo(t *testing.T) {
 		"No Todo With Matching Id Found": {
 			todoIdPathParam:  "999",
 			expectedCode:     http.StatusNotFound,
-			expectedResponse: "Could not find todo with id [999]",
+			expectedResponse: problem(http.StatusNotFound, "/todo/999", "Could not find todo with id [999]"),
 			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
 				mockedComponent.On("ReturnSingleTodo", "999").
-					Return(models.Todo{}, errors.New("could not find todo with id [999]"))
+					Return(models.Todo{}, &services.NotFoundError{Resource: "todo", Id: "999"})
 			},
 		},
 		"Todo With Matching Id Found": {
@@ -219,7 +446,7 @@ func TestReturnSingleTodo(t *testing.T) {
 			tt.mockSetup(mockTodoService)
 			setupTodoController(mockTodoService)
 
-			req := httptest.NewRequest(http.MethodGet, "/"+tt.todoIdPathParam, nil)
+			req := httptest.NewRequest(http.MethodGet, "/todo/"+tt.todoIdPathParam, nil)
 			reqPathParams := map[string]string{
 				"id": tt.todoIdPathParam,
 			}
@@ -239,17 +466,282 @@ func TestReturnSingleTodo(t *testing.T) {
 	}
 }
 
+func TestHierarchyRoutes(t *testing.T) {
+	tests := map[string]struct {
+		path             string
+		expectedCode     int
+		expectedResponse interface{}
+		mockSetup        func(mockedComponent *MockTodoServiceImpl)
+	}{
+		"Return Children": {
+			path:         "/todo/1/children",
+			expectedCode: http.StatusOK,
+			expectedResponse: TodoCollectionResponse{Todos: []models.Todo{
+				{Id: "2", Title: "Buy flour", ParentId: "1"},
+			}},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnChildren", "1").Return([]models.Todo{{Id: "2", Title: "Buy flour", ParentId: "1"}}, nil)
+			},
+		},
+		"Return Children Of Missing Todo": {
+			path:             "/todo/999/children",
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/todo/999/children", "Could not find todo with id [999]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnChildren", "999").Return([]models.Todo(nil), &services.NotFoundError{Resource: "todo", Id: "999"})
+			},
+		},
+		"Return Tree": {
+			path:         "/todo/1/tree",
+			expectedCode: http.StatusOK,
+			expectedResponse: map[string]interface{}{
+				"Id": "1", "Title": "Bake cake", "Desc": "", "Completed": false, "Version": 1,
+				"CreatedAt": "0001-01-01T00:00:00Z", "UpdatedAt": "0001-01-01T00:00:00Z",
+				"Progress": map[string]int{"Total": 1, "Completed": 1, "Percent": 100},
+				"Children": []map[string]interface{}{{
+					"Id": "2", "Title": "Buy flour", "Desc": "", "Completed": true, "Version": 1, "ParentId": "1",
+					"CreatedAt": "0001-01-01T00:00:00Z", "UpdatedAt": "0001-01-01T00:00:00Z",
+					"Progress": map[string]int{"Total": 0, "Completed": 0, "Percent": 100},
+					"Children": []interface{}{},
+				}},
+			},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnTodoTree", "1").Return(services.TodoTree{
+					Todo:     models.Todo{Id: "1", Title: "Bake cake", Version: 1},
+					Progress: services.Progress{Total: 1, Completed: 1, Percent: 100},
+					Children: []services.TodoTree{{
+						Todo:     models.Todo{Id: "2", Title: "Buy flour", Completed: true, Version: 1, ParentId: "1"},
+						Progress: services.Progress{Percent: 100},
+						Children: []services.TodoTree{},
+					}},
+				}, nil)
+			},
+		},
+		"Return Plan": {
+			path:         "/plan",
+			expectedCode: http.StatusOK,
+			expectedResponse: TodoCollectionResponse{Todos: []models.Todo{
+				{Id: "2", Title: "Buy flour"},
+				{Id: "1", Title: "Bake cake", DependsOn: []string{"2"}, Blocked: true},
+			}},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("PlanTodos").Return([]models.Todo{
+					{Id: "2", Title: "Buy flour"},
+					{Id: "1", Title: "Bake cake", DependsOn: []string{"2"}, Blocked: true},
+				}, nil)
+			},
+		},
+		"Return Occurrences": {
+			path:         "/todo/1/occurrences?count=2",
+			expectedCode: http.StatusOK,
+			expectedResponse: OccurrencesResponse{Occurrences: []time.Time{
+				time.Date(2024, time.March, 11, 18, 0, 0, 0, time.UTC),
+				time.Date(2024, time.March, 18, 18, 0, 0, 0, time.UTC),
+			}},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("PreviewOccurrences", "1", 2).Return([]time.Time{
+					time.Date(2024, time.March, 11, 18, 0, 0, 0, time.UTC),
+					time.Date(2024, time.March, 18, 18, 0, 0, 0, time.UTC),
+				}, nil)
+			},
+		},
+		"Return Occurrences Of Missing Todo": {
+			path:             "/todo/999/occurrences",
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/todo/999/occurrences", "Could not find todo with id [999]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("PreviewOccurrences", "999", 5).Return([]time.Time(nil), &services.NotFoundError{Resource: "todo", Id: "999"})
+			},
+		},
+		"Return Occurrences With Invalid Count": {
+			path:         "/todo/1/occurrences?count=none",
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: problem(http.StatusBadRequest, "/todo/1/occurrences", "Invalid query parameters",
+				utils.ProblemError{Field: "count", Message: "must be a positive whole number"}),
+		},
+		"Return Occurrences With Count Rejected By Service": {
+			path:         "/todo/1/occurrences?count=500",
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedResponse: problem(http.StatusUnprocessableEntity, "/todo/1/occurrences", "Query count must be between 1 and 100",
+				utils.ProblemError{Field: "count", Message: "must be between 1 and 100"}),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("PreviewOccurrences", "1", 500).Return([]time.Time(nil),
+					&services.ValidationError{Resource: "query", Fields: []services.FieldError{{Field: "count", Message: "must be between 1 and 100"}}})
+			},
+		},
+		"Return Tree Of Missing Todo": {
+			path:             "/todo/999/tree",
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/todo/999/tree", "Could not find todo with id [999]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnTodoTree", "999").Return(services.TodoTree{}, &services.NotFoundError{Resource: "todo", Id: "999"})
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockTodoService := new(MockTodoServiceImpl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockTodoService)
+			}
+			setupTodoController(mockTodoService)
+			router := mux.NewRouter()
+			todoController.RegisterRoutes(router)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			httpWriter := httptest.NewRecorder()
+			router.ServeHTTP(httpWriter, req)
+			res := httpWriter.Result()
+			defer res.Body.Close()
+			data := getHttpResponse(t, res)
+			if httpWriter.Code != tt.expectedCode {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
+			}
+			expectedResponse, _ := json.Marshal(tt.expectedResponse)
+			require.JSONEq(t, string(expectedResponse), string(data))
+		})
+	}
+}
+
+func TestHistoryRoutes(t *testing.T) {
+	at := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	revisions := []models.Revision{
+		{Id: 1, TodoId: "1", Version: 1, Action: models.ActionCreate, Actor: "alice", At: at,
+			Changes: []models.FieldChange{{Field: "Title", New: json.RawMessage(`"Bake cake"`)}},
+			Todo:    models.Todo{Id: "1", Title: "Bake cake", Version: 1}},
+		{Id: 4, TodoId: "1", Version: 2, Action: models.ActionUpdate, Actor: "bob", At: at,
+			Changes: []models.FieldChange{{Field: "Title", Old: json.RawMessage(`"Bake cake"`), New: json.RawMessage(`"Bake bread"`)}},
+			Todo:    models.Todo{Id: "1", Title: "Bake bread", Version: 2}},
+	}
+	version := int64(2)
+	tests := map[string]struct {
+		method           string
+		target           string
+		ifMatch          string
+		expectedCode     int
+		expectedETag     string
+		expectedResponse interface{}
+		mockSetup        func(mockedComponent *MockTodoServiceImpl)
+	}{
+		"Return History": {
+			method:           http.MethodGet,
+			target:           "/todo/1/history",
+			expectedCode:     http.StatusOK,
+			expectedResponse: HistoryResponse{Revisions: revisions},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnHistory", "1").Return(revisions, nil)
+			},
+		},
+		"Return History Todo Not Found": {
+			method:           http.MethodGet,
+			target:           "/todo/999/history",
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/todo/999/history", "Could not find todo with id [999]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnHistory", "999").Return([]models.Revision(nil), &services.NotFoundError{Resource: "todo", Id: "999"})
+			},
+		},
+		"Revert Todo": {
+			method:           http.MethodPost,
+			target:           "/todo/1/history/1/revert",
+			ifMatch:          `"2"`,
+			expectedCode:     http.StatusOK,
+			expectedETag:     `"3"`,
+			expectedResponse: models.Todo{Id: "1", Title: "Bake cake", Version: 3},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("RevertTodo", "1", int64(1), &version).Return(models.Todo{Id: "1", Title: "Bake cake", Version: 3}, nil)
+			},
+		},
+		"Revert Todo Invalid Revision": {
+			method:       http.MethodPost,
+			target:       "/todo/1/history/first/revert",
+			expectedCode: http.StatusBadRequest,
+			expectedResponse: problem(http.StatusBadRequest, "/todo/1/history/first/revert", "Invalid path parameters",
+				utils.ProblemError{Field: "revision", Message: "must be a positive whole number"}),
+		},
+		"Revert Todo Revision Not Found": {
+			method:           http.MethodPost,
+			target:           "/todo/1/history/99/revert",
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/todo/1/history/99/revert", "Could not find revision with id [99]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("RevertTodo", "1", int64(99), (*int64)(nil)).Return(models.Todo{}, &services.NotFoundError{Resource: "revision", Id: "99"})
+			},
+		},
+		"Revert Todo Stale Version": {
+			method:       http.MethodPost,
+			target:       "/todo/1/history/1/revert",
+			ifMatch:      `"2"`,
+			expectedCode: http.StatusPreconditionFailed,
+			expectedResponse: problem(http.StatusPreconditionFailed, "/todo/1/history/1/revert",
+				"Todo with id [1] is at version [3] not the expected version [2]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("RevertTodo", "1", int64(1), &version).Return(models.Todo{},
+					&services.VersionMismatchError{Resource: "todo", Id: "1", Expected: 2, Actual: 3})
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockTodoService := new(MockTodoServiceImpl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockTodoService)
+			}
+			setupTodoController(mockTodoService)
+			router := mux.NewRouter()
+			todoController.RegisterRoutes(router)
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			httpWriter := httptest.NewRecorder()
+			router.ServeHTTP(httpWriter, req)
+			if httpWriter.Code != tt.expectedCode {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
+			}
+			if etag := httpWriter.Header().Get("ETag"); etag != tt.expectedETag {
+				t.Errorf("unexpected ETag, expected [%v] but recieved [%v]", tt.expectedETag, etag)
+			}
+			expectedResponse, _ := json.Marshal(tt.expectedResponse)
+			require.JSONEq(t, string(expectedResponse), httpWriter.Body.String())
+		})
+	}
+}
+
 func TestCreateNewTodo(t *testing.T) {
 	tests := map[string]struct {
 		requestBody      interface{}
+		maxBodyBytes     int64
 		expectedCode     int
 		expectedResponse interface{}
+		expectedLocation string
 		mockSetup        func(mockedComponent *MockTodoServiceImpl)
 	}{
 		"Request Body Invalid": {
 			requestBody:      "{invalid:json}}",
-			expectedCode:     http.StatusInternalServerError,
-			expectedResponse: "Internal Server Error",
+			expectedCode:     http.StatusBadRequest,
+			expectedResponse: problem(http.StatusBadRequest, "/todo", "Malformed request body"),
+		},
+		"Request Body Too Large": {
+			requestBody:      models.Todo{Title: "Bake cake"},
+			maxBodyBytes:     8,
+			expectedCode:     http.StatusRequestEntityTooLarge,
+			expectedResponse: problem(http.StatusRequestEntityTooLarge, "/todo", "Request body exceeds the maximum size of 8 bytes"),
+		},
+		"Todo Fails Validation": {
+			requestBody: models.Todo{
+				Title: "Bake cake",
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedResponse: problem(http.StatusUnprocessableEntity, "/todo", "Todo Id cannot be null",
+				utils.ProblemError{Field: "Id", Message: "cannot be null"}),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("CreateNewTodo", mock.Anything).Return(models.Todo{},
+					&services.ValidationError{Resource: "todo", Fields: []services.FieldError{{Field: "Id", Message: "cannot be null"}}})
+			},
 		},
 		"Todo With Matching Id Already Exists": {
 			requestBody: models.Todo{
@@ -259,10 +751,10 @@ func TestCreateNewTodo(t *testing.T) {
 				Completed: false,
 			},
 			expectedCode:     http.StatusConflict,
-			expectedResponse: "Todo with id [1] already exists",
+			expectedResponse: problem(http.StatusConflict, "/todo", "Todo with id [1] already exists"),
 			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
 				mockedComponent.On("CreateNewTodo", mock.Anything).
-					Return(models.Todo{}, errors.New("todo with id [1] already exists"))
+					Return(models.Todo{}, &services.ConflictError{Resource: "todo", Id: "1"})
 			},
 		},
 		"Todo Created Successfully": {
@@ -272,7 +764,8 @@ func TestCreateNewTodo(t *testing.T) {
 				Desc:      "Bake a carrot cake for tomorrow's fate",
 				Completed: false,
 			},
-			expectedCode: http.StatusCreated,
+			expectedCode:     http.StatusCreated,
+			expectedLocation: "/todo/1",
 			expectedResponse: models.Todo{
 				Id:        "1",
 				Title:     "Bake cake",
@@ -302,8 +795,11 @@ func TestCreateNewTodo(t *testing.T) {
 
 			mockTodoJson, _ := json.Marshal(tt.requestBody)
 			bodyReader := strings.NewReader(string(mockTodoJson))
-			req := httptest.NewRequest(http.MethodPost, "/", bodyReader)
+			req := httptest.NewRequest(http.MethodPost, "/todo", bodyReader)
 			httpWriter := httptest.NewRecorder()
+			if tt.maxBodyBytes > 0 {
+				req.Body = http.MaxBytesReader(httpWriter, req.Body, tt.maxBodyBytes)
+			}
 			todoController.CreateNewTodo(httpWriter, req)
 
 			res := httpWriter.Result()
@@ -312,6 +808,12 @@ func TestCreateNewTodo(t *testing.T) {
 			if httpWriter.Code != tt.expectedCode {
 				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
 			}
+			if res.Header.Get("Location") != tt.expectedLocation {
+				t.Errorf("unexpected Location header, expected [%v] but recieved [%v]", tt.expectedLocation, res.Header.Get("Location"))
+			}
+			if httpWriter.Code >= http.StatusBadRequest && res.Header.Get("Content-Type") != utils.ProblemContentType {
+				t.Errorf("unexpected content type for error response [%v]", res.Header.Get("Content-Type"))
+			}
 			expectedResponse, _ := json.Marshal(tt.expectedResponse)
 			require.JSONEq(t, string(expectedResponse), string(data))
 		})
@@ -330,7 +832,31 @@ func TestDeleteTodo(t *testing.T) {
 			expectedCode:     http.StatusOK,
 			expectedResponse: "Todo Deleted Successfully",
 			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
-				mockedComponent.On("DeleteTodo", "1").Return()
+				mockedComponent.On("DeleteTodo", "1", (*int64)(nil)).Return(nil)
+			},
+		},
+		"No Todo With Matching Id Found": {
+			todoId:           "999",
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/todo/999", "Could not find todo with id [999]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("DeleteTodo", "999", (*int64)(nil)).Return(&services.NotFoundError{Resource: "todo", Id: "999"})
+			},
+		},
+		"Todo Still Has Subtasks": {
+			todoId:           "1",
+			expectedCode:     http.StatusConflict,
+			expectedResponse: problem(http.StatusConflict, "/todo/1", "Todo with id [1] still has [2] subtasks"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("DeleteTodo", "1", (*int64)(nil)).Return(&services.HasSubtasksError{Id: "1", Subtasks: 2})
+			},
+		},
+		"Todo Still A Prerequisite": {
+			todoId:           "1",
+			expectedCode:     http.StatusConflict,
+			expectedResponse: problem(http.StatusConflict, "/todo/1", "Todo with id [1] is still a prerequisite of [3] todo items"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("DeleteTodo", "1", (*int64)(nil)).Return(&services.HasDependantsError{Id: "1", Dependants: 3})
 			},
 		},
 	}
@@ -343,7 +869,7 @@ func TestDeleteTodo(t *testing.T) {
 			reqPathParams := map[string]string{
 				"id": tt.todoId,
 			}
-			req := httptest.NewRequest(http.MethodDelete, "/"+tt.todoId, nil)
+			req := httptest.NewRequest(http.MethodDelete, "/todo/"+tt.todoId, nil)
 			req = mux.SetURLVars(req, reqPathParams)
 			httpWriter := httptest.NewRecorder()
 			todoController.DeleteTodo(httpWriter, req)
@@ -369,8 +895,20 @@ func TestUpdateTodo(t *testing.T) {
 	}{
 		"Invalid Request Body": {
 			requestBody:      "{invalid:json}}",
-			expectedCode:     http.StatusInternalServerError,
-			expectedResponse: "Internal Server Error",
+			expectedCode:     http.StatusBadRequest,
+			expectedResponse: problem(http.StatusBadRequest, "/todo", "Malformed request body"),
+		},
+		"Todo Fails Validation Is Not Created": {
+			requestBody: models.Todo{
+				Title: "Bake cake",
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedResponse: problem(http.StatusUnprocessableEntity, "/todo", "Todo Id cannot be null",
+				utils.ProblemError{Field: "Id", Message: "cannot be null"}),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("UpdateTodo", mock.Anything, (*int64)(nil)).Return(models.Todo{},
+					&services.ValidationError{Resource: "todo", Fields: []services.FieldError{{Field: "Id", Message: "cannot be null"}}})
+			},
 		},
 		"Todo Not Found Is Not Created Successfully Due To Duplicate Id": {
 			todoId: "1",
@@ -381,12 +919,12 @@ func TestUpdateTodo(t *testing.T) {
 				Completed: false,
 			},
 			expectedCode:     http.StatusConflict,
-			expectedResponse: "Todo with id [1] already exists",
+			expectedResponse: problem(http.StatusConflict, "/todo", "Todo with id [1] already exists"),
 			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
-				mockedComponent.On("UpdateTodo", mock.Anything).
-					Return(models.Todo{}, errors.New("could not find todo with id [1]"))
+				mockedComponent.On("UpdateTodo", mock.Anything, (*int64)(nil)).
+					Return(models.Todo{}, &services.NotFoundError{Resource: "todo", Id: "1"})
 				mockedComponent.On("CreateNewTodo", mock.Anything).
-					Return(models.Todo{}, errors.New("todo with id [1] already exists"))
+					Return(models.Todo{}, &services.ConflictError{Resource: "todo", Id: "1"})
 			},
 		},
 		"Todo Not Found Is Then Created Successfully": {
@@ -405,8 +943,8 @@ func TestUpdateTodo(t *testing.T) {
 				Completed: false,
 			},
 			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
-				mockedComponent.On("UpdateTodo", mock.Anything).
-					Return(models.Todo{}, errors.New("could not find todo with id [1]"))
+				mockedComponent.On("UpdateTodo", mock.Anything, (*int64)(nil)).
+					Return(models.Todo{}, &services.NotFoundError{Resource: "todo", Id: "1"})
 				mockedComponent.On("CreateNewTodo", mock.Anything).
 					Return(models.Todo{
 						Id:        "1",
@@ -431,7 +969,7 @@ func TestUpdateTodo(t *testing.T) {
 				Completed: false,
 			},
 			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
-				mockedComponent.On("UpdateTodo", mock.Anything).Return(models.Todo{
+				mockedComponent.On("UpdateTodo", mock.Anything, (*int64)(nil)).Return(models.Todo{
 					Id:        "1",
 					Title:     "Bake cake",
 					Desc:      "Bake a carrot cake for tomorrow's fate",
@@ -452,7 +990,7 @@ func TestUpdateTodo(t *testing.T) {
 			setupTodoController(mockTodoService)
 			mockTodoJson, _ := json.Marshal(tt.requestBody)
 			bodyReader := strings.NewReader(string(mockTodoJson))
-			req := httptest.NewRequest(http.MethodPut, "/"+tt.todoId, bodyReader)
+			req := httptest.NewRequest(http.MethodPut, "/todo", bodyReader)
 			reqPathParams := map[string]string{
 				"id": tt.todoId,
 			}
@@ -470,3 +1008,290 @@ func TestUpdateTodo(t *testing.T) {
 		})
 	}
 }
+
+func TestPatchTodo(t *testing.T) {
+	tests := map[string]struct {
+		todoId           string
+		contentType      string
+		requestBody      string
+		expectedCode     int
+		expectedResponse interface{}
+		mockSetup        func(mockedComponent *MockTodoServiceImpl)
+	}{
+		"Unsupported Content Type": {
+			todoId:           "1",
+			contentType:      "application/json",
+			requestBody:      `{"Completed": true}`,
+			expectedCode:     http.StatusUnsupportedMediaType,
+			expectedResponse: problem(http.StatusUnsupportedMediaType, "/todo/1", "Patch documents must be sent as either application/merge-patch+json or application/json-patch+json"),
+		},
+		"Merge Patch Applied": {
+			todoId:       "1",
+			contentType:  "application/merge-patch+json; charset=utf-8",
+			requestBody:  `{"Completed": true}`,
+			expectedCode: http.StatusOK,
+			expectedResponse: models.Todo{
+				Id:        "1",
+				Title:     "Bake cake",
+				Completed: true,
+			},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("PatchTodo", "1", services.TodoPatch{Type: services.MergePatchType, Document: []byte(`{"Completed": true}`)}, (*int64)(nil)).
+					Return(models.Todo{Id: "1", Title: "Bake cake", Completed: true}, nil)
+			},
+		},
+		"Json Patch Applied": {
+			todoId:       "1",
+			contentType:  "application/json-patch+json",
+			requestBody:  `[{"op": "replace", "path": "/Completed", "value": true}]`,
+			expectedCode: http.StatusOK,
+			expectedResponse: models.Todo{
+				Id:        "1",
+				Title:     "Bake cake",
+				Completed: true,
+			},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("PatchTodo", "1", mock.Anything, (*int64)(nil)).
+					Return(models.Todo{Id: "1", Title: "Bake cake", Completed: true}, nil)
+			},
+		},
+		"Todo Not Found": {
+			todoId:           "999",
+			contentType:      "application/merge-patch+json",
+			requestBody:      `{"Completed": true}`,
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/todo/999", "Could not find todo with id [999]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("PatchTodo", "999", mock.Anything, (*int64)(nil)).
+					Return(models.Todo{}, &services.NotFoundError{Resource: "todo", Id: "999"})
+			},
+		},
+		"Invalid Patch Document": {
+			todoId:           "1",
+			contentType:      "application/json-patch+json",
+			requestBody:      `{}`,
+			expectedCode:     http.StatusBadRequest,
+			expectedResponse: problem(http.StatusBadRequest, "/todo/1", "Patch could not be applied: invalid operation"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("PatchTodo", "1", mock.Anything, (*int64)(nil)).
+					Return(models.Todo{}, &services.PatchError{Reason: "invalid operation"})
+			},
+		},
+		"Failed Test Operation": {
+			todoId:           "1",
+			contentType:      "application/json-patch+json",
+			requestBody:      `[{"op": "test", "path": "/Completed", "value": true}]`,
+			expectedCode:     http.StatusConflict,
+			expectedResponse: problem(http.StatusConflict, "/todo/1", "Patch could not be applied: test failed"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("PatchTodo", "1", mock.Anything, (*int64)(nil)).
+					Return(models.Todo{}, &services.PatchError{Reason: "test failed", TestFailed: true})
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockTodoService := new(MockTodoServiceImpl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockTodoService)
+			}
+			setupTodoController(mockTodoService)
+
+			req := httptest.NewRequest(http.MethodPatch, "/todo/"+tt.todoId, strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", tt.contentType)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.todoId})
+			httpWriter := httptest.NewRecorder()
+			todoController.PatchTodo(httpWriter, req)
+			res := httpWriter.Result()
+			defer res.Body.Close()
+			data := getHttpResponse(t, res)
+			if httpWriter.Code != tt.expectedCode {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
+			}
+			expectedResponse, _ := json.Marshal(tt.expectedResponse)
+			require.JSONEq(t, string(expectedResponse), string(data))
+		})
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	version := int64(3)
+	tests := map[string]struct {
+		method           string
+		headers          map[string]string
+		requestBody      string
+		expectedCode     int
+		expectedETag     string
+		expectedResponse interface{}
+		mockSetup        func(mockedComponent *MockTodoServiceImpl)
+	}{
+		"Get Returns ETag": {
+			method:           http.MethodGet,
+			expectedCode:     http.StatusOK,
+			expectedETag:     `"3"`,
+			expectedResponse: models.Todo{Id: "1", Title: "Bake cake", Version: 3},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnSingleTodo", "1").Return(models.Todo{Id: "1", Title: "Bake cake", Version: 3}, nil)
+			},
+		},
+		"Get Matching If-None-Match Not Modified": {
+			method:       http.MethodGet,
+			headers:      map[string]string{"If-None-Match": `"2", W/"3"`},
+			expectedCode: http.StatusNotModified,
+			expectedETag: `"3"`,
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnSingleTodo", "1").Return(models.Todo{Id: "1", Title: "Bake cake", Version: 3}, nil)
+			},
+		},
+		"Get Stale If-None-Match Returns Todo": {
+			method:           http.MethodGet,
+			headers:          map[string]string{"If-None-Match": `"2"`},
+			expectedCode:     http.StatusOK,
+			expectedETag:     `"3"`,
+			expectedResponse: models.Todo{Id: "1", Title: "Bake cake", Version: 3},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnSingleTodo", "1").Return(models.Todo{Id: "1", Title: "Bake cake", Version: 3}, nil)
+			},
+		},
+		"Put Matching If-Match Updates": {
+			method:           http.MethodPut,
+			headers:          map[string]string{"If-Match": `"3"`},
+			requestBody:      `{"Id": "1", "Title": "Bake cake"}`,
+			expectedCode:     http.StatusOK,
+			expectedETag:     `"4"`,
+			expectedResponse: models.Todo{Id: "1", Title: "Bake cake", Version: 4},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("UpdateTodo", models.Todo{Id: "1", Title: "Bake cake"}, &version).
+					Return(models.Todo{Id: "1", Title: "Bake cake", Version: 4}, nil)
+			},
+		},
+		"Put Stale If-Match Precondition Failed": {
+			method:           http.MethodPut,
+			headers:          map[string]string{"If-Match": `"3"`},
+			requestBody:      `{"Id": "1", "Title": "Bake cake"}`,
+			expectedCode:     http.StatusPreconditionFailed,
+			expectedResponse: problem(http.StatusPreconditionFailed, "/todo", "Todo with id [1] is at version [4] not the expected version [3]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("UpdateTodo", mock.Anything, &version).
+					Return(models.Todo{}, &services.VersionMismatchError{Resource: "todo", Id: "1", Expected: 3, Actual: 4})
+			},
+		},
+		"Put If-Match On Missing Todo Is Not Created": {
+			method:           http.MethodPut,
+			headers:          map[string]string{"If-Match": "*"},
+			requestBody:      `{"Id": "1", "Title": "Bake cake"}`,
+			expectedCode:     http.StatusPreconditionFailed,
+			expectedResponse: problem(http.StatusPreconditionFailed, "/todo", "Could not find todo with id [1]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("UpdateTodo", mock.Anything, (*int64)(nil)).
+					Return(models.Todo{}, &services.NotFoundError{Resource: "todo", Id: "1"})
+			},
+		},
+		"Put Weak If-Match Precondition Failed": {
+			method:           http.MethodPut,
+			headers:          map[string]string{"If-Match": `W/"3"`},
+			requestBody:      `{"Id": "1", "Title": "Bake cake"}`,
+			expectedCode:     http.StatusPreconditionFailed,
+			expectedResponse: problem(http.StatusPreconditionFailed, "/todo", "If-Match must be either * or a single strong ETag"),
+		},
+		"Put If-None-Match Creates": {
+			method:           http.MethodPut,
+			headers:          map[string]string{"If-None-Match": "*"},
+			requestBody:      `{"Id": "1", "Title": "Bake cake"}`,
+			expectedCode:     http.StatusCreated,
+			expectedETag:     `"1"`,
+			expectedResponse: models.Todo{Id: "1", Title: "Bake cake", Version: 1},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("CreateNewTodo", models.Todo{Id: "1", Title: "Bake cake"}).
+					Return(models.Todo{Id: "1", Title: "Bake cake", Version: 1}, nil)
+			},
+		},
+		"Put If-None-Match On Existing Todo Precondition Failed": {
+			method:           http.MethodPut,
+			headers:          map[string]string{"If-None-Match": "*"},
+			requestBody:      `{"Id": "1", "Title": "Bake cake"}`,
+			expectedCode:     http.StatusPreconditionFailed,
+			expectedResponse: problem(http.StatusPreconditionFailed, "/todo", "Todo with id [1] already exists"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("CreateNewTodo", mock.Anything).
+					Return(models.Todo{}, &services.ConflictError{Resource: "todo", Id: "1"})
+			},
+		},
+		"Patch Matching If-Match Applied": {
+			method:           http.MethodPatch,
+			headers:          map[string]string{"If-Match": `"3"`, "Content-Type": services.MergePatchType},
+			requestBody:      `{"Completed": true}`,
+			expectedCode:     http.StatusOK,
+			expectedETag:     `"4"`,
+			expectedResponse: models.Todo{Id: "1", Title: "Bake cake", Completed: true, Version: 4},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("PatchTodo", "1", mock.Anything, &version).
+					Return(models.Todo{Id: "1", Title: "Bake cake", Completed: true, Version: 4}, nil)
+			},
+		},
+		"Delete Stale If-Match Precondition Failed": {
+			method:           http.MethodDelete,
+			headers:          map[string]string{"If-Match": `"3"`},
+			expectedCode:     http.StatusPreconditionFailed,
+			expectedResponse: problem(http.StatusPreconditionFailed, "/todo/1", "Todo with id [1] is at version [4] not the expected version [3]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("DeleteTodo", "1", &version).
+					Return(&services.VersionMismatchError{Resource: "todo", Id: "1", Expected: 3, Actual: 4})
+			},
+		},
+		"Delete If-Match On Missing Todo Precondition Failed": {
+			method:           http.MethodDelete,
+			headers:          map[string]string{"If-Match": "*"},
+			expectedCode:     http.StatusPreconditionFailed,
+			expectedResponse: problem(http.StatusPreconditionFailed, "/todo/1", "Could not find todo with id [1]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("DeleteTodo", "1", (*int64)(nil)).Return(&services.NotFoundError{Resource: "todo", Id: "1"})
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockTodoService := new(MockTodoServiceImpl)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockTodoService)
+			}
+			setupTodoController(mockTodoService)
+
+			target, handler := "/todo/1", todoController.ReturnSingleTodo
+			switch tt.method {
+			case http.MethodPut:
+				target, handler = "/todo", todoController.UpdateTodo
+			case http.MethodPatch:
+				handler = todoController.PatchTodo
+			case http.MethodDelete:
+				handler = todoController.DeleteTodo
+			}
+			req := httptest.NewRequest(tt.method, target, strings.NewReader(tt.requestBody))
+			for header, value := range tt.headers {
+				req.Header.Set(header, value)
+			}
+			req = mux.SetURLVars(req, map[string]string{"id": "1"})
+			httpWriter := httptest.NewRecorder()
+			handler(httpWriter, req)
+			res := httpWriter.Result()
+			defer res.Body.Close()
+			data := getHttpResponse(t, res)
+			if httpWriter.Code != tt.expectedCode {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
+			}
+			if etag := res.Header.Get("ETag"); etag != tt.expectedETag {
+				t.Errorf("unexpected ETag header, expected [%v] but recieved [%v]", tt.expectedETag, etag)
+			}
+			if tt.expectedResponse == nil {
+				if len(data) != 0 {
+					t.Errorf("unexpected response body [%s]", data)
+				}
+				return
+			}
+			expectedResponse, _ := json.Marshal(tt.expectedResponse)
+			require.JSONEq(t, string(expectedResponse), string(data))
+		})
+	}
+}
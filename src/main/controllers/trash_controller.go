@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"TodoApp/src/main/services"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+)
+
+// A TrashController represents a REST controller for handling HTTP requests to the API under the "trash/" URI, used to
+// recover or permanently remove deleted todo items
+type TrashController struct {
+	responder
+	todoService services.TodoService
+}
+
+// NewTrashController creates a new TrashController object. This is used by Wire when starting the API to perform the
+// necessary dependency injection
+func NewTrashController(todoService services.TodoService, logger *slog.Logger) TrashController {
+	return TrashController{responder{logger}, todoService}
+}
+
+// ReturnTrash returns every todo item within the trash, in the order they were deleted
+func (controller *TrashController) ReturnTrash(writer http.ResponseWriter, request *http.Request) {
+	todos, err := controller.todoService.ReturnTrash(request.Context())
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, TodoCollectionResponse{Todos: todos})
+}
+
+// RestoreTodo moves the todo item with an id matching the id passed as a path parameter out of the trash, returning the
+// restored todo item with its ETag. If no such todo item is within the trash a 404 is returned
+func (controller *TrashController) RestoreTodo(writer http.ResponseWriter, request *http.Request) {
+	todo, err := controller.todoService.RestoreTodo(request.Context(), mux.Vars(request)["id"])
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnTodo(writer, request, http.StatusOK, todo)
+}
+
+// PurgeTodo permanently removes the todo item with an id matching the id passed as a path parameter from the trash. If
+// no such todo item is within the trash a 404 is returned
+func (controller *TrashController) PurgeTodo(writer http.ResponseWriter, request *http.Request) {
+	err := controller.todoService.PurgeTodo(request.Context(), mux.Vars(request)["id"])
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, "Todo Purged Successfully")
+}
+
+// RegisterRoutes registers the routes under the "trash/" URI with router, handling requests to them by calling methods
+// within TrashController
+func (controller TrashController) RegisterRoutes(myRouter *mux.Router) {
+	myRouter.HandleFunc("/trash", controller.ReturnTrash).Methods("GET")
+	myRouter.HandleFunc("/trash/{id}/restore", controller.RestoreTodo).Methods("POST")
+	myRouter.HandleFunc("/trash/{id}", controller.PurgeTodo).Methods("DELETE")
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTrashController(t *testing.T) {
+	deletedAt := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		method           string
+		target           string
+		expectedCode     int
+		expectedETag     string
+		expectedResponse interface{}
+		mockSetup        func(mockedComponent *MockTodoServiceImpl)
+	}{
+		"Return Trash": {
+			method:       http.MethodGet,
+			target:       "/trash",
+			expectedCode: http.StatusOK,
+			expectedResponse: TodoCollectionResponse{Todos: []models.Todo{
+				{Id: "1", Title: "Bake cake", Version: 3, DeletedAt: &deletedAt},
+			}},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("ReturnTrash").Return([]models.Todo{{Id: "1", Title: "Bake cake", Version: 3, DeletedAt: &deletedAt}}, nil)
+			},
+		},
+		"Restore Todo": {
+			method:           http.MethodPost,
+			target:           "/trash/1/restore",
+			expectedCode:     http.StatusOK,
+			expectedETag:     `"4"`,
+			expectedResponse: models.Todo{Id: "1", Title: "Bake cake", Version: 4},
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("RestoreTodo", "1").Return(models.Todo{Id: "1", Title: "Bake cake", Version: 4}, nil)
+			},
+		},
+		"Restore Todo Not In Trash": {
+			method:           http.MethodPost,
+			target:           "/trash/999/restore",
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/trash/999/restore", "Could not find trashed todo with id [999]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("RestoreTodo", "999").Return(models.Todo{}, &services.NotFoundError{Resource: "trashed todo", Id: "999"})
+			},
+		},
+		"Purge Todo": {
+			method:           http.MethodDelete,
+			target:           "/trash/1",
+			expectedCode:     http.StatusOK,
+			expectedResponse: "Todo Purged Successfully",
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("PurgeTodo", "1").Return(nil)
+			},
+		},
+		"Purge Todo Not In Trash": {
+			method:           http.MethodDelete,
+			target:           "/trash/999",
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/trash/999", "Could not find trashed todo with id [999]"),
+			mockSetup: func(mockedComponent *MockTodoServiceImpl) {
+				mockedComponent.On("PurgeTodo", "999").Return(&services.NotFoundError{Resource: "trashed todo", Id: "999"})
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockTodoService := new(MockTodoServiceImpl)
+			tt.mockSetup(mockTodoService)
+			router := mux.NewRouter()
+			NewTrashController(mockTodoService, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+
+			httpWriter := httptest.NewRecorder()
+			router.ServeHTTP(httpWriter, httptest.NewRequest(tt.method, tt.target, nil))
+			if httpWriter.Code != tt.expectedCode {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
+			}
+			if etag := httpWriter.Header().Get("ETag"); etag != tt.expectedETag {
+				t.Errorf("unexpected ETag, expected [%v] but recieved [%v]", tt.expectedETag, etag)
+			}
+			expectedResponse, _ := json.Marshal(tt.expectedResponse)
+			require.JSONEq(t, string(expectedResponse), httpWriter.Body.String())
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/webhooks"
+	"errors"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+// A WebhookController represents a REST controller for handling HTTP requests to the API under the "webhooks/" URI,
+// including the deliveries made to each webhook
+type WebhookController struct {
+	responder
+	webhookService webhooks.Service
+}
+
+// NewWebhookController creates a new WebhookController object. This is used by Wire when starting the API to perform
+// the necessary dependency injection
+func NewWebhookController(webhookService webhooks.Service, logger *slog.Logger) WebhookController {
+	return WebhookController{responder{logger}, webhookService}
+}
+
+// A WebhookCollectionResponse represents the body of a response containing every webhook
+type WebhookCollectionResponse struct {
+	Webhooks []models.Webhook `json:"Webhooks"`
+}
+
+// A DeliveryCollectionResponse represents the body of a response containing the deliveries made to a webhook
+type DeliveryCollectionResponse struct {
+	Deliveries []models.Delivery `json:"Deliveries"`
+}
+
+// ReturnAllWebhooks returns every webhook, in the order they were created, without their secrets
+func (controller *WebhookController) ReturnAllWebhooks(writer http.ResponseWriter, request *http.Request) {
+	hooks, err := controller.webhookService.ReturnAllWebhooks(request.Context())
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, WebhookCollectionResponse{Webhooks: hooks})
+}
+
+// ReturnSingleWebhook returns the webhook with an id matching the webhookId path parameter, without its secret. The
+// version of the webhook is returned in the ETag header
+func (controller *WebhookController) ReturnSingleWebhook(writer http.ResponseWriter, request *http.Request) {
+	webhook, err := controller.webhookService.ReturnSingleWebhook(request.Context(), mux.Vars(request)["webhookId"])
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnWebhook(writer, request, http.StatusOK, webhook)
+}
+
+// CreateNewWebhook creates a new webhook, generating its secret if none was supplied. The secret is only returned within
+// this response. The location of the new webhook is returned in the Location header
+func (controller *WebhookController) CreateNewWebhook(writer http.ResponseWriter, request *http.Request) {
+	var webhook models.Webhook
+	if !controller.decodeBody(writer, request, &webhook) {
+		return
+	}
+	response, err := controller.webhookService.CreateNewWebhook(request.Context(), webhook)
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	writer.Header().Set("Location", "/webhooks/"+url.PathEscape(response.Id))
+	controller.returnWebhook(writer, request, http.StatusCreated, response)
+}
+
+// UpdateWebhook replaces the webhook with an id matching the webhookId path parameter with the webhook passed in the
+// request, keeping its secret if none was supplied. When an If-Match header is sent the webhook is only changed if it is
+// still at that version, otherwise a 412 is returned
+func (controller *WebhookController) UpdateWebhook(writer http.ResponseWriter, request *http.Request) {
+	var webhook models.Webhook
+	if !controller.decodeBody(writer, request, &webhook) {
+		return
+	}
+	conditions, ok := parsePreconditions(request)
+	if !ok {
+		controller.returnPreconditionFailed(writer, request, "If-Match must be either * or a single strong ETag")
+		return
+	}
+	webhook.Id = mux.Vars(request)["webhookId"]
+	response, err := controller.webhookService.UpdateWebhook(request.Context(), webhook, conditions.version)
+	if conditions.ifMatch && errors.Is(err, services.ErrNotFound) {
+		controller.returnPreconditionFailed(writer, request, capitalise(err.Error()))
+		return
+	}
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnWebhook(writer, request, http.StatusOK, response)
+}
+
+// DeleteWebhook removes the webhook with an id matching the webhookId path parameter, abandoning any deliveries to it
+// which are still pending. When an If-Match header is sent the webhook is only removed if it is still at that version,
+// otherwise a 412 is returned
+func (controller *WebhookController) DeleteWebhook(writer http.ResponseWriter, request *http.Request) {
+	conditions, ok := parsePreconditions(request)
+	if !ok {
+		controller.returnPreconditionFailed(writer, request, "If-Match must be either * or a single strong ETag")
+		return
+	}
+	err := controller.webhookService.DeleteWebhook(request.Context(), mux.Vars(request)["webhookId"], conditions.version)
+	if conditions.ifMatch && errors.Is(err, services.ErrNotFound) {
+		controller.returnPreconditionFailed(writer, request, capitalise(err.Error()))
+		return
+	}
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, "Webhook Deleted Successfully")
+}
+
+// ReturnDeliveries returns the deliveries made to the webhook with an id matching the webhookId path parameter, newest
+// first, including those still pending
+func (controller *WebhookController) ReturnDeliveries(writer http.ResponseWriter, request *http.Request) {
+	deliveries, err := controller.webhookService.ReturnDeliveries(request.Context(), mux.Vars(request)["webhookId"])
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusOK, DeliveryCollectionResponse{Deliveries: deliveries})
+}
+
+// Redeliver queues the payload of the delivery with an id matching the deliveryId path parameter to be sent to the
+// webhook with an id matching the webhookId path parameter again, returning the new delivery with a 202 as it is sent
+// in the background
+func (controller *WebhookController) Redeliver(writer http.ResponseWriter, request *http.Request) {
+	vars := mux.Vars(request)
+	delivery, err := controller.webhookService.Redeliver(request.Context(), vars["webhookId"], vars["deliveryId"])
+	if err != nil {
+		controller.returnServiceError(writer, request, err)
+		return
+	}
+	controller.returnJson(writer, request, http.StatusAccepted, delivery)
+}
+
+// returnWebhook responds with a single webhook, including its version within the ETag header
+func (controller responder) returnWebhook(writer http.ResponseWriter, request *http.Request, code int, webhook models.Webhook) {
+	writer.Header().Set("ETag", etag(webhook.Version))
+	controller.returnJson(writer, request, code, webhook)
+}
+
+// RegisterRoutes registers the routes under the "webhooks/" URI with router, handling requests to them by calling
+// methods within WebhookController
+func (controller WebhookController) RegisterRoutes(myRouter *mux.Router) {
+	myRouter.HandleFunc("/webhooks", controller.CreateNewWebhook).Methods("POST")
+	myRouter.HandleFunc("/webhooks", controller.ReturnAllWebhooks).Methods("GET")
+	myRouter.HandleFunc("/webhooks/{webhookId}", controller.UpdateWebhook).Methods("PUT")
+	myRouter.HandleFunc("/webhooks/{webhookId}", controller.DeleteWebhook).Methods("DELETE")
+	myRouter.HandleFunc("/webhooks/{webhookId}", controller.ReturnSingleWebhook).Methods("GET")
+	myRouter.HandleFunc("/webhooks/{webhookId}/deliveries", controller.ReturnDeliveries).Methods("GET")
+	myRouter.HandleFunc("/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", controller.Redeliver).Methods("POST")
+}
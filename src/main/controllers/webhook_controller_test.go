@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"TodoApp/src/main/utils"
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (service *MockWebhookService) ReturnAllWebhooks(_ context.Context) ([]models.Webhook, error) {
+	args := service.Called()
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (service *MockWebhookService) ReturnSingleWebhook(_ context.Context, id string) (models.Webhook, error) {
+	args := service.Called(id)
+	return args.Get(0).(models.Webhook), args.Error(1)
+}
+
+func (service *MockWebhookService) CreateNewWebhook(_ context.Context, webhook models.Webhook) (models.Webhook, error) {
+	args := service.Called(webhook)
+	return args.Get(0).(models.Webhook), args.Error(1)
+}
+
+func (service *MockWebhookService) UpdateWebhook(_ context.Context, webhook models.Webhook, expectedVersion *int64) (models.Webhook, error) {
+	args := service.Called(webhook, expectedVersion)
+	return args.Get(0).(models.Webhook), args.Error(1)
+}
+
+func (service *MockWebhookService) DeleteWebhook(_ context.Context, id string, expectedVersion *int64) error {
+	args := service.Called(id, expectedVersion)
+	return args.Error(0)
+}
+
+func (service *MockWebhookService) ReturnDeliveries(_ context.Context, id string) ([]models.Delivery, error) {
+	args := service.Called(id)
+	return args.Get(0).([]models.Delivery), args.Error(1)
+}
+
+func (service *MockWebhookService) Redeliver(_ context.Context, id string, deliveryId string) (models.Delivery, error) {
+	args := service.Called(id, deliveryId)
+	return args.Get(0).(models.Delivery), args.Error(1)
+}
+
+func TestWebhookController(t *testing.T) {
+	version := int64(2)
+	at := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	webhook := models.Webhook{Id: "hook", Url: "https://example.com/hook", Events: []models.WebhookEvent{models.WebhookTodoCreated},
+		Version: 1, CreatedAt: at, UpdatedAt: at}
+	withSecret := webhook
+	withSecret.Secret = "shh"
+	delivery := models.Delivery{Id: "d2", WebhookId: "hook", Event: models.WebhookTodoCreated,
+		Payload: json.RawMessage(`{"Id":"e1"}`), Status: models.DeliveryPending, NextAttemptAt: &at, CreatedAt: at}
+	tests := map[string]struct {
+		method           string
+		target           string
+		body             string
+		headers          map[string]string
+		expectedCode     int
+		expectedHeaders  map[string]string
+		expectedResponse interface{}
+		mockSetup        func(mockedComponent *MockWebhookService)
+	}{
+		"Return All Webhooks": {
+			method:           http.MethodGet,
+			target:           "/webhooks",
+			expectedCode:     http.StatusOK,
+			expectedResponse: WebhookCollectionResponse{Webhooks: []models.Webhook{webhook}},
+			mockSetup: func(mockedComponent *MockWebhookService) {
+				mockedComponent.On("ReturnAllWebhooks").Return([]models.Webhook{webhook}, nil)
+			},
+		},
+		"Return Single Webhook": {
+			method:           http.MethodGet,
+			target:           "/webhooks/hook",
+			expectedCode:     http.StatusOK,
+			expectedHeaders:  map[string]string{"ETag": `"1"`},
+			expectedResponse: webhook,
+			mockSetup: func(mockedComponent *MockWebhookService) {
+				mockedComponent.On("ReturnSingleWebhook", "hook").Return(webhook, nil)
+			},
+		},
+		"Return Missing Webhook": {
+			method:           http.MethodGet,
+			target:           "/webhooks/hook",
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/webhooks/hook", "Could not find webhook with id [hook]"),
+			mockSetup: func(mockedComponent *MockWebhookService) {
+				mockedComponent.On("ReturnSingleWebhook", "hook").Return(models.Webhook{},
+					&services.NotFoundError{Resource: "webhook", Id: "hook"})
+			},
+		},
+		"Create Webhook": {
+			method:           http.MethodPost,
+			target:           "/webhooks",
+			body:             `{"Url": "https://example.com/hook", "Events": ["todo.created"]}`,
+			expectedCode:     http.StatusCreated,
+			expectedHeaders:  map[string]string{"Location": "/webhooks/hook", "ETag": `"1"`},
+			expectedResponse: withSecret,
+			mockSetup: func(mockedComponent *MockWebhookService) {
+				mockedComponent.On("CreateNewWebhook", models.Webhook{Url: "https://example.com/hook",
+					Events: []models.WebhookEvent{models.WebhookTodoCreated}}).Return(withSecret, nil)
+			},
+		},
+		"Create Invalid Webhook": {
+			method:       http.MethodPost,
+			target:       "/webhooks",
+			body:         `{"Url": "ftp://example.com", "Events": ["todo.created"]}`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedResponse: problem(http.StatusUnprocessableEntity, "/webhooks", "Webhook Url must be an absolute http or https URL",
+				utils.ProblemError{Field: "Url", Message: "must be an absolute http or https URL"}),
+			mockSetup: func(mockedComponent *MockWebhookService) {
+				mockedComponent.On("CreateNewWebhook", mock.Anything).Return(models.Webhook{}, &services.ValidationError{
+					Resource: "webhook", Fields: []services.FieldError{{Field: "Url", Message: "must be an absolute http or https URL"}}})
+			},
+		},
+		"Create Malformed Webhook": {
+			method:           http.MethodPost,
+			target:           "/webhooks",
+			body:             `{"Url": `,
+			expectedCode:     http.StatusBadRequest,
+			expectedResponse: problem(http.StatusBadRequest, "/webhooks", "Malformed request body"),
+		},
+		"Update Webhook If Match": {
+			method:           http.MethodPut,
+			target:           "/webhooks/hook",
+			body:             `{"Id": "ignored", "Url": "https://example.com/hook", "Events": ["todo.created"]}`,
+			headers:          map[string]string{"If-Match": `"2"`},
+			expectedCode:     http.StatusOK,
+			expectedHeaders:  map[string]string{"ETag": `"1"`},
+			expectedResponse: webhook,
+			mockSetup: func(mockedComponent *MockWebhookService) {
+				mockedComponent.On("UpdateWebhook", models.Webhook{Id: "hook", Url: "https://example.com/hook",
+					Events: []models.WebhookEvent{models.WebhookTodoCreated}}, &version).Return(webhook, nil)
+			},
+		},
+		"Update Missing Webhook If Match": {
+			method:           http.MethodPut,
+			target:           "/webhooks/hook",
+			body:             `{"Url": "https://example.com/hook", "Events": ["todo.created"]}`,
+			headers:          map[string]string{"If-Match": "*"},
+			expectedCode:     http.StatusPreconditionFailed,
+			expectedResponse: problem(http.StatusPreconditionFailed, "/webhooks/hook", "Could not find webhook with id [hook]"),
+			mockSetup: func(mockedComponent *MockWebhookService) {
+				mockedComponent.On("UpdateWebhook", mock.Anything, (*int64)(nil)).Return(models.Webhook{},
+					&services.NotFoundError{Resource: "webhook", Id: "hook"})
+			},
+		},
+		"Delete Stale Webhook": {
+			method:       http.MethodDelete,
+			target:       "/webhooks/hook",
+			headers:      map[string]string{"If-Match": `"2"`},
+			expectedCode: http.StatusPreconditionFailed,
+			expectedResponse: problem(http.StatusPreconditionFailed, "/webhooks/hook",
+				"Webhook with id [hook] is at version [3] not the expected version [2]"),
+			mockSetup: func(mockedComponent *MockWebhookService) {
+				mockedComponent.On("DeleteWebhook", "hook", &version).Return(&services.VersionMismatchError{
+					Resource: "webhook", Id: "hook", Expected: 2, Actual: 3})
+			},
+		},
+		"Delete Webhook": {
+			method:           http.MethodDelete,
+			target:           "/webhooks/hook",
+			expectedCode:     http.StatusOK,
+			expectedResponse: "Webhook Deleted Successfully",
+			mockSetup: func(mockedComponent *MockWebhookService) {
+				mockedComponent.On("DeleteWebhook", "hook", (*int64)(nil)).Return(nil)
+			},
+		},
+		"Return Deliveries": {
+			method:           http.MethodGet,
+			target:           "/webhooks/hook/deliveries",
+			expectedCode:     http.StatusOK,
+			expectedResponse: DeliveryCollectionResponse{Deliveries: []models.Delivery{delivery}},
+			mockSetup: func(mockedComponent *MockWebhookService) {
+				mockedComponent.On("ReturnDeliveries", "hook").Return([]models.Delivery{delivery}, nil)
+			},
+		},
+		"Redeliver": {
+			method:           http.MethodPost,
+			target:           "/webhooks/hook/deliveries/d1/redeliver",
+			expectedCode:     http.StatusAccepted,
+			expectedResponse: delivery,
+			mockSetup: func(mockedComponent *MockWebhookService) {
+				mockedComponent.On("Redeliver", "hook", "d1").Return(delivery, nil)
+			},
+		},
+		"Redeliver Missing Delivery": {
+			method:           http.MethodPost,
+			target:           "/webhooks/hook/deliveries/d9/redeliver",
+			expectedCode:     http.StatusNotFound,
+			expectedResponse: problem(http.StatusNotFound, "/webhooks/hook/deliveries/d9/redeliver", "Could not find delivery with id [d9]"),
+			mockSetup: func(mockedComponent *MockWebhookService) {
+				mockedComponent.On("Redeliver", "hook", "d9").Return(models.Delivery{},
+					&services.NotFoundError{Resource: "delivery", Id: "d9"})
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockWebhookService := new(MockWebhookService)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockWebhookService)
+			}
+			router := mux.NewRouter()
+			NewWebhookController(mockWebhookService, slog.New(slog.NewTextHandler(io.Discard, nil))).RegisterRoutes(router)
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			for header, value := range tt.headers {
+				req.Header.Set(header, value)
+			}
+			httpWriter := httptest.NewRecorder()
+			router.ServeHTTP(httpWriter, req)
+			if httpWriter.Code != tt.expectedCode {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
+			}
+			for header, value := range tt.expectedHeaders {
+				if httpWriter.Header().Get(header) != value {
+					t.Errorf("unexpected %s header, expected [%v] but recieved [%v]", header, value, httpWriter.Header().Get(header))
+				}
+			}
+			expectedResponse, _ := json.Marshal(tt.expectedResponse)
+			require.JSONEq(t, string(expectedResponse), httpWriter.Body.String())
+			mockWebhookService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,289 @@
+// Package feed notifies subscribers of every change made to a todo item as it happens, so that clients can follow
+// changes instead of polling for them
+package feed
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is the number of notifications which can wait for a subscriber to receive them. A subscriber which
+// falls further behind is dropped rather than holding up the changes being published
+const subscriberBuffer = 64
+
+// The types of Notification which can be published, named after the action each is notified as, see
+// services.NotifiedAction
+const (
+	TypeCreate = string(models.ActionCreate)
+	TypeUpdate = string(models.ActionUpdate)
+	TypeDelete = string(models.ActionDelete)
+)
+
+// A Notification describes a single change made to a todo item. Composed of the following fields:
+//
+// Id: The id of the Revision recording the change, increasing in the order changes were made
+//
+// Type: What the change did to the todo item, one of "create", "update" or "delete". Restoring a todo item from the
+// trash is a "create", while purging one from the trash is not published as it had already been deleted
+//
+// Actor: The unauthenticated label the change was attributed to, see models.Revision
+//
+// At: When the change was made
+//
+// Todo: The todo item once the change had been made, or as it was removed for a "delete"
+type Notification struct {
+	Id    int64       `json:"Id"`
+	Type  string      `json:"Type"`
+	Actor string      `json:"Actor"`
+	At    time.Time   `json:"At"`
+	Todo  models.Todo `json:"Todo"`
+	// before holds the todo item prior to the change, so that a todo item leaving a filter is still published to it
+	before models.Todo
+}
+
+// A Filter narrows the notifications received by a subscriber. Composed of the following fields:
+//
+// ListId: Only notify of todo items within the list with this id
+//
+// Tag: Only notify of todo items with this tag
+//
+// Completed: When not nil, only notify of todo items whose Completed field matches it
+//
+// A change is notified when the todo item matched the filter either before or after it, so subscribers also learn of
+// todo items which stop matching
+type Filter struct {
+	ListId    string
+	Tag       string
+	Completed *bool
+}
+
+// Matches reports whether notification satisfies the filter
+func (filter Filter) Matches(notification Notification) bool {
+	return filter.matchesTodo(notification.Todo) || filter.matchesTodo(notification.before)
+}
+
+// matchesTodo reports whether todo satisfies the filter
+func (filter Filter) matchesTodo(todo models.Todo) bool {
+	if filter.ListId != "" && todo.ListId != filter.ListId {
+		return false
+	}
+	if filter.Tag != "" && !slices.Contains(todo.Tags, filter.Tag) {
+		return false
+	}
+	return filter.Completed == nil || todo.Completed == *filter.Completed
+}
+
+// A Subscription receives the notifications published after it was made. Its channel is closed once the subscriber is
+// dropped for falling behind, or once the Feed stops
+type Subscription struct {
+	notifications chan Notification
+	filter        Filter
+	// active reports whether the Subscription is counted by the Feed until it is passed to Unsubscribe
+	active bool
+}
+
+// Notifications returns the channel notifications are received from
+func (subscription *Subscription) Notifications() <-chan Notification {
+	return subscription.notifications
+}
+
+// A Feed publishes a Notification for every Revision recorded by a TodoService, keeping the most recent within a ring
+// buffer so that subscribers which reconnect can resume from the last notification they received. Changes are published
+// by calling Sync once they have been committed, which is registered as an observer of the Store, see services.Store
+type Feed struct {
+	todoService services.TodoService
+	logger      *slog.Logger
+	// syncMu guards syncing and pending. Only one call to Sync publishes at a time, so that each revision is published
+	// once and in order, while pending records that revisions were made during it which are still to be published
+	syncMu  sync.Mutex
+	syncing bool
+	pending bool
+	mu      sync.Mutex
+	cursor  int64
+	// buffer is a ring holding the most recent notifications, the oldest at head once it has filled, with every
+	// notification after floor still within it
+	buffer      []Notification
+	head        int
+	size        int
+	floor       int64
+	subscribers map[*Subscription]struct{}
+	// active counts the subscriptions which have not yet been passed to Unsubscribe
+	active  sync.WaitGroup
+	stopped bool
+}
+
+// New creates a new Feed publishing the changes made to the Todo items of todoService, keeping the most recent size
+// notifications to resume from. Revisions recorded before the Feed was created are not published. This is used by Wire
+// when starting the API to perform the necessary dependency injection
+func New(ctx context.Context, todoService services.TodoService, size int, logger *slog.Logger) (*Feed, error) {
+	latest, err := services.LatestRevision(ctx, todoService)
+	if err != nil {
+		return nil, err
+	}
+	return &Feed{todoService: todoService, logger: logger, size: size, cursor: latest, floor: latest,
+		subscribers: map[*Subscription]struct{}{}}, nil
+}
+
+// Sync publishes a Notification for each Revision recorded since Sync was last called. Publishing never waits for
+// subscribers, any subscriber whose notifications are not being received is dropped instead. Nor does it wait for
+// another call to Sync, a call made while one is already publishing leaves its revisions to that call
+func (feed *Feed) Sync(ctx context.Context) {
+	// The change has already been made, so it is published even if the request which made it has since been cancelled
+	ctx = context.WithoutCancel(ctx)
+	feed.syncMu.Lock()
+	feed.pending = true
+	if feed.syncing {
+		feed.syncMu.Unlock()
+		return
+	}
+	feed.syncing = true
+	for feed.pending {
+		feed.pending = false
+		feed.syncMu.Unlock()
+		feed.publishRecorded(ctx)
+		feed.syncMu.Lock()
+	}
+	feed.syncing = false
+	feed.syncMu.Unlock()
+}
+
+// publishRecorded publishes a Notification for each Revision recorded after the last one published. Revisions are read
+// from the cursor onwards, so only the new revisions are read. The caller must be the call to Sync which is publishing
+func (feed *Feed) publishRecorded(ctx context.Context) {
+	feed.mu.Lock()
+	cursor := feed.cursor
+	feed.mu.Unlock()
+	_, err := services.ReadRevisions(ctx, feed.todoService, cursor, func(revisions []models.Revision) error {
+		for _, revision := range revisions {
+			feed.publish(revision)
+		}
+		return nil
+	})
+	if err != nil {
+		feed.logger.Warn("Failed to read changes to publish", "error", err)
+	}
+}
+
+// Subscribe subscribes to the notifications matching filter. When lastId is not nil the notifications after it which
+// are still buffered are returned, to be sent before those received through the Subscription. reset reports that
+// notifications after lastId have already left the buffer, or that lastId was never published as the API has since
+// restarted, so the subscriber has missed changes and should fetch the todo items again. The Subscription must be
+// passed to Unsubscribe once it is no longer needed
+func (feed *Feed) Subscribe(lastId *int64, filter Filter) (subscription *Subscription, backlog []Notification, reset bool) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	subscription = &Subscription{notifications: make(chan Notification, subscriberBuffer), filter: filter}
+	if feed.stopped {
+		close(subscription.notifications)
+		return subscription, nil, false
+	}
+	feed.subscribers[subscription] = struct{}{}
+	subscription.active = true
+	feed.active.Add(1)
+	if lastId == nil {
+		return subscription, nil, false
+	}
+	for i := range feed.buffer {
+		notification := feed.buffer[(feed.head+i)%len(feed.buffer)]
+		if notification.Id > *lastId && filter.Matches(notification) {
+			backlog = append(backlog, notification)
+		}
+	}
+	return subscription, backlog, *lastId < feed.floor || *lastId > feed.cursor
+}
+
+// Unsubscribe stops subscription from receiving notifications
+func (feed *Feed) Unsubscribe(subscription *Subscription) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	if _, ok := feed.subscribers[subscription]; ok {
+		delete(feed.subscribers, subscription)
+		close(subscription.notifications)
+	}
+	if subscription.active {
+		subscription.active = false
+		feed.active.Done()
+	}
+}
+
+// Run waits until ctx is cancelled, then closes every Subscription so that the requests streaming them can complete
+// while the server shuts down. It returns once every Subscription has been passed to Unsubscribe, as the server does
+// not wait for requests whose connection was hijacked, such as WebSockets
+func (feed *Feed) Run(ctx context.Context) {
+	<-ctx.Done()
+	feed.mu.Lock()
+	feed.stopped = true
+	for subscription := range feed.subscribers {
+		delete(feed.subscribers, subscription)
+		close(subscription.notifications)
+	}
+	feed.mu.Unlock()
+	feed.active.Wait()
+}
+
+// publish buffers the Notification for revision and sends it to every matching subscriber, dropping those which have
+// fallen behind. The caller must be the call to Sync which is publishing
+func (feed *Feed) publish(revision models.Revision) {
+	notification, ok := newNotification(revision)
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	feed.cursor = revision.Id
+	if !ok {
+		return
+	}
+	if len(feed.buffer) < feed.size {
+		feed.buffer = append(feed.buffer, notification)
+	} else {
+		// The buffer is full, so the oldest notification is overwritten
+		feed.floor = feed.buffer[feed.head].Id
+		feed.buffer[feed.head] = notification
+		feed.head = (feed.head + 1) % feed.size
+	}
+	for subscription := range feed.subscribers {
+		if !subscription.filter.Matches(notification) {
+			continue
+		}
+		select {
+		case subscription.notifications <- notification:
+		default:
+			delete(feed.subscribers, subscription)
+			close(subscription.notifications)
+		}
+	}
+}
+
+// newNotification returns the Notification for revision, and false if it is not published
+func newNotification(revision models.Revision) (Notification, bool) {
+	action, ok := services.NotifiedAction(revision)
+	if !ok {
+		return Notification{}, false
+	}
+	return Notification{Id: revision.Id, Type: string(action), Actor: revision.Actor, At: revision.At, Todo: revision.Todo,
+		before: previousTodo(revision)}, true
+}
+
+// previousTodo returns the todo item recorded by revision as it was before the change, by undoing the changes the
+// revision recorded. Only the fields a Filter matches against are relied upon
+func previousTodo(revision models.Revision) models.Todo {
+	// Marshalling a todo item and unmarshalling it into a map cannot fail
+	fields := map[string]json.RawMessage{}
+	data, _ := json.Marshal(revision.Todo)
+	_ = json.Unmarshal(data, &fields)
+	for _, change := range revision.Changes {
+		if change.Old == nil {
+			delete(fields, change.Field)
+		} else {
+			fields[change.Field] = change.Old
+		}
+	}
+	var before models.Todo
+	data, _ = json.Marshal(fields)
+	_ = json.Unmarshal(data, &before)
+	return before
+}
@@ -0,0 +1,242 @@
+package feed
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"context"
+	"github.com/google/go-cmp/cmp"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+func setupFeedTest(t *testing.T, size int, prerequisite []models.Todo) (*Feed, services.Store) {
+	store := services.NewTodoServiceImpl([]models.Todo{}, services.Options{AllowClientIds: true})
+	for _, todo := range prerequisite {
+		_, err := store.CreateNewTodo(context.Background(), todo)
+		if err != nil {
+			t.Fatalf("Failed to persist prerequisite todo: [%v]", err)
+		}
+	}
+	changeFeed, err := New(context.Background(), store, size, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	store.Observe(changeFeed.Sync)
+	return changeFeed, store
+}
+
+// received returns the type and todo item id of every notification waiting on subscription
+func received(subscription *Subscription) []string {
+	var notifications []string
+	for len(subscription.notifications) > 0 {
+		notification := <-subscription.notifications
+		notifications = append(notifications, notification.Type+" "+notification.Todo.Id)
+	}
+	return notifications
+}
+
+func TestFeedPublish(t *testing.T) {
+	completed := true
+	tests := map[string]struct {
+		filter   Filter
+		expected []string
+	}{
+		"No Filter": {
+			expected: []string{"update 1", "create 2", "update 2", "delete 2", "create 2", "delete 1"},
+		},
+		"Tag Filter Includes Todos Leaving It": {
+			filter:   Filter{Tag: "home"},
+			expected: []string{"update 1"},
+		},
+		"Completed Filter": {
+			filter:   Filter{Completed: &completed},
+			expected: []string{"update 2", "delete 2", "create 2"},
+		},
+		"List Filter": {
+			filter: Filter{ListId: "chores"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			changeFeed, store := setupFeedTest(t, 10, []models.Todo{{Id: "1", Title: "Bake cake", Tags: []string{"home"}}})
+			subscription, backlog, reset := changeFeed.Subscribe(nil, tt.filter)
+			defer changeFeed.Unsubscribe(subscription)
+			if len(backlog) > 0 || reset {
+				t.Fatalf("unexpected backlog [%v] or reset [%v] for a new subscriber", backlog, reset)
+			}
+
+			_, err := store.UpdateTodo(ctx, models.Todo{Id: "1", Title: "Bake cake", Tags: []string{"work"}}, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = store.CreateNewTodo(ctx, models.Todo{Id: "2", Title: "Buy flour"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = store.UpdateTodo(ctx, models.Todo{Id: "2", Title: "Buy flour", Completed: true}, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = store.DeleteTodo(ctx, "2", nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = store.RestoreTodo(ctx, "2")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = store.DeleteTodo(ctx, "1", nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = store.PurgeTodo(ctx, "1")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+
+			diff := cmp.Diff(tt.expected, received(subscription))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestFeedResume(t *testing.T) {
+	tests := map[string]struct {
+		lastId          int64
+		expectedBacklog []int64
+		expectedReset   bool
+	}{
+		"Up To Date": {
+			lastId: 4,
+		},
+		"Missed Buffered Changes": {
+			lastId:          2,
+			expectedBacklog: []int64{3, 4},
+		},
+		"Missed Changes No Longer Buffered": {
+			lastId:          1,
+			expectedBacklog: []int64{3, 4},
+			expectedReset:   true,
+		},
+		"Id From Before Restart": {
+			lastId:        99,
+			expectedReset: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			changeFeed, store := setupFeedTest(t, 2, []models.Todo{{Id: "1", Title: "Bake cake"}})
+			for _, title := range []string{"Bake bread", "Bake pie", "Bake tart"} {
+				_, err := store.UpdateTodo(ctx, models.Todo{Id: "1", Title: title}, nil)
+				if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+			}
+
+			subscription, backlog, reset := changeFeed.Subscribe(&tt.lastId, Filter{})
+			defer changeFeed.Unsubscribe(subscription)
+			var ids []int64
+			for _, notification := range backlog {
+				ids = append(ids, notification.Id)
+			}
+			diff := cmp.Diff(tt.expectedBacklog, ids)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			if reset != tt.expectedReset {
+				t.Errorf("unexpected reset, expected [%v] but recieved [%v]", tt.expectedReset, reset)
+			}
+		})
+	}
+}
+
+func TestFeedPublishesConcurrentChangesInOrder(t *testing.T) {
+	changeFeed, store := setupFeedTest(t, 100, []models.Todo{})
+	subscription, _, _ := changeFeed.Subscribe(nil, Filter{})
+	defer changeFeed.Unsubscribe(subscription)
+	var wg sync.WaitGroup
+	for range subscriberBuffer {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.CreateNewTodo(context.Background(), models.Todo{Title: "Bake cake"})
+			if err != nil {
+				t.Errorf("Error occured when none expected: [%v]", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every change has been published once the last call to Sync returns, each once and in order
+	var ids []int64
+	for len(subscription.notifications) > 0 {
+		ids = append(ids, (<-subscription.notifications).Id)
+	}
+	expected := make([]int64, subscriberBuffer)
+	for i := range expected {
+		expected[i] = int64(i + 1)
+	}
+	diff := cmp.Diff(expected, ids)
+	if diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestFeedDropsSlowSubscriber(t *testing.T) {
+	ctx := context.Background()
+	changeFeed, store := setupFeedTest(t, 10, []models.Todo{})
+	slow, _, _ := changeFeed.Subscribe(nil, Filter{})
+	defer changeFeed.Unsubscribe(slow)
+	for range subscriberBuffer + 1 {
+		_, err := store.CreateNewTodo(ctx, models.Todo{Title: "Bake cake"})
+		if err != nil {
+			t.Fatalf("Error occured when none expected: [%v]", err)
+		}
+	}
+
+	count := 0
+	for range slow.Notifications() {
+		count++
+	}
+	if count != subscriberBuffer {
+		t.Errorf("unexpected notification count, expected [%v] but recieved [%v]", subscriberBuffer, count)
+	}
+}
+
+func TestFeedRun(t *testing.T) {
+	changeFeed, _ := setupFeedTest(t, 10, []models.Todo{})
+	subscription, _, _ := changeFeed.Subscribe(nil, Filter{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stopped := make(chan struct{})
+	go func() {
+		changeFeed.Run(ctx)
+		close(stopped)
+	}()
+
+	_, ok := <-subscription.Notifications()
+	if ok {
+		t.Error("Subscription open when closed expected")
+	}
+	select {
+	case <-stopped:
+		t.Fatal("Run returned before the subscription was unsubscribed")
+	case <-time.After(10 * time.Millisecond):
+	}
+	changeFeed.Unsubscribe(subscription)
+	<-stopped
+	late, _, _ := changeFeed.Subscribe(nil, Filter{})
+	_, ok = <-late.Notifications()
+	if ok {
+		t.Error("Subscription made after stopping open when closed expected")
+	}
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+type contextKey int
+
+const requestIdKey contextKey = iota
+
+// New creates a new slog.Logger which writes JSON lines to writer, discarding records below level. Records logged with
+// a context belonging to a request include the id of that request, see WithRequestId
+func New(writer io.Writer, level slog.Level) *slog.Logger {
+	handler := slog.NewJSONHandler(writer, &slog.HandlerOptions{Level: level})
+	return slog.New(contextHandler{handler})
+}
+
+// WithRequestId returns a copy of ctx carrying the id of the request it belongs to
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey, requestId)
+}
+
+// RequestId returns the id of the request ctx belongs to, or an empty string if it does not belong to a request
+func RequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey).(string)
+	return requestId
+}
+
+// contextHandler is a slog.Handler which adds the request id carried by the context of a record, if any, before
+// passing it on to the wrapped handler
+type contextHandler struct {
+	slog.Handler
+}
+
+func (handler contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestId := RequestId(ctx); requestId != "" {
+		record.AddAttrs(slog.String("request_id", requestId))
+	}
+	return handler.Handler.Handle(ctx, record)
+}
+
+func (handler contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{handler.Handler.WithAttrs(attrs)}
+}
+
+func (handler contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{handler.Handler.WithGroup(name)}
+}
@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"bufio"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+	"unicode"
+)
+
+// RequestIdHeader is the header used to propagate the id of a request. An id sent by the client is reused, otherwise a
+// new one is generated, and in both cases it is returned in the response
+const RequestIdHeader = "X-Request-ID"
+
+// maxRequestIdLength is the longest request id accepted from a client, longer ids are replaced with a generated one
+const maxRequestIdLength = 128
+
+// Middleware wraps next, which should be router or a handler wrapping it, so that a single JSON line describing every
+// request is logged to logger once it has been handled. The id of the request is added to its context, so that it is
+// included in anything logged while handling the request
+func Middleware(logger *slog.Logger, router *mux.Router) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			start := time.Now()
+			requestId := request.Header.Get(RequestIdHeader)
+			if !validRequestId(requestId) {
+				requestId = uuid.NewString()
+			}
+			writer.Header().Set(RequestIdHeader, requestId)
+			ctx := WithRequestId(request.Context(), requestId)
+
+			recorder := &ResponseRecorder{ResponseWriter: writer, Status: http.StatusOK}
+			next.ServeHTTP(recorder, request.WithContext(ctx))
+
+			logger.LogAttrs(ctx, slog.LevelInfo, "Request handled",
+				slog.String("method", request.Method),
+				slog.String("route", RouteTemplate(router, request)),
+				slog.Int("status", recorder.Status),
+				slog.Int64("bytes", recorder.Bytes),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("remote_addr", request.RemoteAddr),
+			)
+		})
+	}
+}
+
+// RouteTemplate returns the template of the route within router matching request, e.g. "/todo/{id}", so that requests
+// to the same route can be grouped regardless of their path parameters. Requests which do not match a route return
+// "unmatched"
+func RouteTemplate(router *mux.Router, request *http.Request) string {
+	var match mux.RouteMatch
+	if router.Match(request, &match) && match.Route != nil {
+		template, err := match.Route.GetPathTemplate()
+		if err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
+
+// validRequestId reports whether a request id sent by a client is safe to reuse
+func validRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+	for _, char := range requestId {
+		if char > unicode.MaxASCII || !unicode.IsPrint(char) {
+			return false
+		}
+	}
+	return true
+}
+
+// A ResponseRecorder wraps a http.ResponseWriter, recording the status code and the number of bytes of the response
+// written through it
+type ResponseRecorder struct {
+	http.ResponseWriter
+	Status      int
+	Bytes       int64
+	wroteHeader bool
+}
+
+func (recorder *ResponseRecorder) WriteHeader(code int) {
+	if !recorder.wroteHeader {
+		recorder.Status = code
+		recorder.wroteHeader = true
+	}
+	recorder.ResponseWriter.WriteHeader(code)
+}
+
+func (recorder *ResponseRecorder) Write(data []byte) (int, error) {
+	recorder.wroteHeader = true
+	written, err := recorder.ResponseWriter.Write(data)
+	recorder.Bytes += int64(written)
+	return written, err
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, allowing a http.ResponseController to reach its optional methods
+func (recorder *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+// Hijack takes over the connection of the wrapped http.ResponseWriter, as is done when upgrading to a WebSocket. The
+// response is recorded as a 101, since whatever is written afterwards bypasses the recorder
+func (recorder *ResponseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, readWriter, err := http.NewResponseController(recorder.ResponseWriter).Hijack()
+	if err == nil && !recorder.wroteHeader {
+		recorder.Status = http.StatusSwitchingProtocols
+		recorder.wroteHeader = true
+	}
+	return conn, readWriter, err
+}
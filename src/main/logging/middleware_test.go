@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := map[string]struct {
+		path              string
+		requestId         string
+		expectedRoute     string
+		expectedStatus    float64
+		expectedBytes     float64
+		expectedRequestId string
+	}{
+		"Request Id Propagated": {
+			path:              "/todo/1",
+			requestId:         "abc-123",
+			expectedRoute:     "/todo/{id}",
+			expectedStatus:    http.StatusTeapot,
+			expectedBytes:     5,
+			expectedRequestId: "abc-123",
+		},
+		"Request Id Generated": {
+			path:           "/todo/1",
+			expectedRoute:  "/todo/{id}",
+			expectedStatus: http.StatusTeapot,
+			expectedBytes:  5,
+		},
+		"Invalid Request Id Replaced": {
+			path:           "/todo/1",
+			requestId:      strings.Repeat("a", maxRequestIdLength+1),
+			expectedRoute:  "/todo/{id}",
+			expectedStatus: http.StatusTeapot,
+			expectedBytes:  5,
+		},
+		"Unmatched Route": {
+			path:           "/missing",
+			expectedRoute:  "unmatched",
+			expectedStatus: http.StatusNotFound,
+			expectedBytes:  19,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output bytes.Buffer
+			logger := New(&output, slog.LevelInfo)
+			router := mux.NewRouter()
+			router.HandleFunc("/todo/{id}", func(writer http.ResponseWriter, request *http.Request) {
+				logger.InfoContext(request.Context(), "Handling request")
+				writer.WriteHeader(http.StatusTeapot)
+				writer.Write([]byte("hello"))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.requestId != "" {
+				req.Header.Set(RequestIdHeader, tt.requestId)
+			}
+			httpWriter := httptest.NewRecorder()
+			Middleware(logger, router)(router).ServeHTTP(httpWriter, req)
+
+			requestId := httpWriter.Header().Get(RequestIdHeader)
+			if tt.expectedRequestId != "" && requestId != tt.expectedRequestId {
+				t.Fatalf("unexpected request id, expected [%v] but recieved [%v]", tt.expectedRequestId, requestId)
+			}
+			if tt.expectedRequestId == "" && (requestId == "" || requestId == tt.requestId) {
+				t.Fatalf("request id was not generated, recieved [%v]", requestId)
+			}
+
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			var record map[string]any
+			err := json.Unmarshal([]byte(lines[len(lines)-1]), &record)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			expected := map[string]any{
+				"msg":        "Request handled",
+				"method":     http.MethodGet,
+				"route":      tt.expectedRoute,
+				"status":     tt.expectedStatus,
+				"bytes":      tt.expectedBytes,
+				"request_id": requestId,
+			}
+			for key, value := range expected {
+				if record[key] != value {
+					t.Errorf("unexpected value for [%s], expected [%v] but recieved [%v]", key, value, record[key])
+				}
+			}
+			if _, ok := record["latency"]; !ok {
+				t.Error("latency missing from request log")
+			}
+			for _, line := range lines {
+				if !strings.Contains(line, `"request_id":"`+requestId+`"`) {
+					t.Errorf("log line missing request id [%s]", line)
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareHijackedConnection(t *testing.T) {
+	var output bytes.Buffer
+	logger := New(&output, slog.LevelInfo)
+	router := mux.NewRouter()
+	router.HandleFunc("/todo/socket", func(writer http.ResponseWriter, request *http.Request) {
+		conn, readWriter, err := http.NewResponseController(writer).Hijack()
+		if err != nil {
+			t.Errorf("Error occured when none expected: [%v]", err)
+			return
+		}
+		defer conn.Close()
+		readWriter.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: close\r\n\r\n")
+		readWriter.Flush()
+	})
+	// The server does not wait for handlers whose connection was hijacked, so the request is only logged once handled
+	handled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		defer close(handled)
+		Middleware(logger, router)(router).ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/todo/socket")
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	response.Body.Close()
+	<-handled
+	if !strings.Contains(output.String(), `"status":101`) {
+		t.Errorf("request log missing status 101 [%s]", output.String())
+	}
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"TodoApp/src/main/logging"
+	"TodoApp/src/main/services"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics holds the collectors exposed by the API in the Prometheus text exposition format. Alongside the request
+// counters and latency histograms recorded by Middleware, the size of the store and statistics about the Go runtime
+// are collected each time the metrics are scraped
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// New creates a new Metrics object, sourcing the store size gauges from todoService. This is used by Wire when starting
+// the API to perform the necessary dependency injection
+func New(todoService services.TodoService) *Metrics {
+	metrics := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "todo_http_requests_total",
+			Help: "Number of HTTP requests handled, by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "todo_http_request_duration_seconds",
+			Help:    "Time taken to handle HTTP requests, by method, route and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+	metrics.registry.MustRegister(
+		metrics.requests,
+		metrics.latency,
+		newStoreCollector(todoService),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return metrics
+}
+
+// Handler returns the handler serving the metrics in the Prometheus text exposition format
+func (metrics *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(metrics.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware wraps next, which should be router or a handler wrapping it, so that every request it handles is counted
+// and timed. Requests are grouped by the template of the route within router they matched, rather than their path, to
+// keep the number of series bounded
+func (metrics *Metrics) Middleware(router *mux.Router) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			start := time.Now()
+			recorder := &logging.ResponseRecorder{ResponseWriter: writer, Status: http.StatusOK}
+			next.ServeHTTP(recorder, request)
+
+			labels := prometheus.Labels{
+				"method": request.Method,
+				"route":  logging.RouteTemplate(router, request),
+				"status": strconv.Itoa(recorder.Status),
+			}
+			metrics.requests.With(labels).Inc()
+			metrics.latency.With(labels).Observe(time.Since(start).Seconds())
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"context"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(t *testing.T, metrics *Metrics) string {
+	httpWriter := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(httpWriter, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if httpWriter.Code != http.StatusOK {
+		t.Fatalf("unexpected HTTP response code, expected [%v] but recieved [%v]", http.StatusOK, httpWriter.Code)
+	}
+	data, err := io.ReadAll(httpWriter.Body)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	return string(data)
+}
+
+func TestMetrics(t *testing.T) {
+	todoService := services.NewTodoServiceImpl([]models.Todo{}, services.Options{AllowClientIds: true})
+	for _, todo := range []models.Todo{{Id: "1", Title: "Bake cake", Completed: true}, {Id: "2", Title: "Eat cake"}} {
+		_, err := todoService.CreateNewTodo(context.Background(), todo)
+		if err != nil {
+			t.Fatalf("Error occured when none expected: [%v]", err)
+		}
+	}
+	metrics := New(todoService)
+	router := mux.NewRouter()
+	router.HandleFunc("/todo/{id}", func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusNotFound)
+	})
+	handler := metrics.Middleware(router)(router)
+	for _, path := range []string{"/todo/1", "/todo/2", "/missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	output := scrape(t, metrics)
+	expected := []string{
+		`todo_http_requests_total{method="GET",route="/todo/{id}",status="404"} 2`,
+		`todo_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`todo_http_request_duration_seconds_count{method="GET",route="/todo/{id}",status="404"} 2`,
+		"todo_store_todos 2",
+		"todo_store_completed_todos 1",
+		"todo_store_open_todos 1",
+		"go_goroutines",
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Errorf("metrics missing [%s]", line)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"TodoApp/src/main/services"
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// storeTimeout bounds how long a scrape waits for the store to be counted
+const storeTimeout = 5 * time.Second
+
+// storeCollector is a prometheus.Collector reporting the number of Todo items persisted by a TodoService. The Todo
+// items are counted each time the metrics are scraped, so the gauges are always current
+type storeCollector struct {
+	todoService services.TodoService
+	total       *prometheus.Desc
+	completed   *prometheus.Desc
+	open        *prometheus.Desc
+}
+
+func newStoreCollector(todoService services.TodoService) *storeCollector {
+	return &storeCollector{
+		todoService: todoService,
+		total:       prometheus.NewDesc("todo_store_todos", "Number of todo items in the store.", nil, nil),
+		completed:   prometheus.NewDesc("todo_store_completed_todos", "Number of completed todo items in the store.", nil, nil),
+		open:        prometheus.NewDesc("todo_store_open_todos", "Number of open todo items in the store.", nil, nil),
+	}
+}
+
+func (collector *storeCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- collector.total
+	descs <- collector.completed
+	descs <- collector.open
+}
+
+// Collect counts the Todo items in the store, reporting the failure against each gauge if they could not be counted
+func (collector *storeCollector) Collect(metrics chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	counts, err := collector.todoService.CountTodos(ctx)
+	if err != nil {
+		metrics <- prometheus.NewInvalidMetric(collector.total, err)
+		metrics <- prometheus.NewInvalidMetric(collector.completed, err)
+		metrics <- prometheus.NewInvalidMetric(collector.open, err)
+		return
+	}
+	metrics <- prometheus.MustNewConstMetric(collector.total, prometheus.GaugeValue, float64(counts.Total))
+	metrics <- prometheus.MustNewConstMetric(collector.completed, prometheus.GaugeValue, float64(counts.Completed))
+	metrics <- prometheus.MustNewConstMetric(collector.open, prometheus.GaugeValue, float64(counts.Open()))
+}
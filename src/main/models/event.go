@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// EventType describes what happened in an Event
+type EventType string
+
+// The types of Event which can be recorded
+const (
+	EventTodoCreated   EventType = "TodoCreated"
+	EventTodoRenamed   EventType = "TodoRenamed"
+	EventTodoCompleted EventType = "TodoCompleted"
+	EventTodoReopened  EventType = "TodoReopened"
+	EventTodoUpdated   EventType = "TodoUpdated"
+	EventTodoDeleted   EventType = "TodoDeleted"
+	EventTodoRestored  EventType = "TodoRestored"
+	EventTodoPurged    EventType = "TodoPurged"
+	EventListCreated   EventType = "ListCreated"
+	EventListUpdated   EventType = "ListUpdated"
+	EventListDeleted   EventType = "ListDeleted"
+)
+
+// Event a single fact recorded within an event stream, from which the current state of every todo item and list is
+// derived. Composed of the following fields:
+//
+// Seq: The position of the event within the stream, increasing by one for each event
+//
+// Type: What happened, e.g. "TodoRenamed"
+//
+// TodoId: The id of the todo item the event happened to, empty for list events
+//
+// ListId: The id of the list the event happened to, empty for todo item events
+//
+// Revision: The id of the Revision the event belongs to. A single revision can be recorded as several events, such as a
+// todo item being renamed and completed at once. Empty for list events
+//
+// Version: The version of the todo item or list once the event had happened
+//
+// Actor: The label the client attributed the change to within the X-Actor header of the request, or "anonymous" when
+// it was not sent. Clients are not authenticated, so the label is only what the client claimed and cannot be relied
+// upon to identify who caused the event
+//
+// At: When the event happened
+//
+// Changes: The fields of the todo item which the event altered, sorted by field name
+//
+// Todo: The todo item as it was created, only set for "TodoCreated"
+//
+// List: The list as it was saved, only set for "ListCreated" and "ListUpdated"
+type Event struct {
+	Seq      int64         `json:"Seq"`
+	Type     EventType     `json:"Type"`
+	TodoId   string        `json:"TodoId,omitempty"`
+	ListId   string        `json:"ListId,omitempty"`
+	Revision int64         `json:"Revision,omitempty"`
+	Version  int64         `json:"Version"`
+	Actor    string        `json:"Actor"`
+	At       time.Time     `json:"At"`
+	Changes  []FieldChange `json:"Changes,omitempty"`
+	Todo     *Todo         `json:"Todo,omitempty"`
+	List     *List         `json:"List,omitempty"`
+}
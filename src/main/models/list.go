@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// List a list of Todo items, such as a project or a sprint. Composed of the following fields:
+//
+// Id: A unique identifier of the list
+//
+// Name: A short name for the list
+//
+// Desc: A longer, more detailed description of the list
+//
+// Version: The number of times the list has been saved, set by the service and used for optimistic concurrency
+//
+// CreatedAt: When the list was created, set by the service
+//
+// UpdatedAt: When the list was last saved, set by the service
+type List struct {
+	Id        string    `json:"Id"`
+	Name      string    `json:"Name"`
+	Desc      string    `json:"Desc"`
+	Version   int64     `json:"Version"`
+	CreatedAt time.Time `json:"CreatedAt"`
+	UpdatedAt time.Time `json:"UpdatedAt"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RevisionAction describes the kind of change a Revision records
+type RevisionAction string
+
+// The actions a Revision can record
+const (
+	ActionCreate  RevisionAction = "create"
+	ActionUpdate  RevisionAction = "update"
+	ActionDelete  RevisionAction = "delete"
+	ActionRestore RevisionAction = "restore"
+	ActionPurge   RevisionAction = "purge"
+)
+
+// RevisionActions lists every valid RevisionAction
+var RevisionActions = []RevisionAction{ActionCreate, ActionUpdate, ActionDelete, ActionRestore, ActionPurge}
+
+// Revision a single recorded change to a todo item. Composed of the following fields:
+//
+// Id: A unique identifier of the revision, increasing in the order revisions were recorded
+//
+// TodoId: The id of the todo item which was changed
+//
+// Version: The version of the todo item once the change had been made
+//
+// Action: What the change was, one of "create", "update", "delete", "restore" or "purge"
+//
+// Actor: The label the client attributed the change to within the X-Actor header of the request, or "anonymous" when
+// it was not sent. Clients are not authenticated, so the label is only what the client claimed and cannot be relied
+// upon to identify who made the change
+//
+// At: When the change was made
+//
+// Changes: The fields of the todo item which the change altered, sorted by field name. Version and UpdatedAt change on
+// every revision so are not included
+//
+// Todo: The todo item once the change had been made. For a delete or purge this is the todo item as it was removed
+type Revision struct {
+	Id      int64          `json:"Id"`
+	TodoId  string         `json:"TodoId"`
+	Version int64          `json:"Version"`
+	Action  RevisionAction `json:"Action"`
+	Actor   string         `json:"Actor"`
+	At      time.Time      `json:"At"`
+	Changes []FieldChange  `json:"Changes,omitempty"`
+	Todo    Todo           `json:"Todo"`
+}
+
+// FieldChange a change made to a single field of a todo item. Composed of the following fields:
+//
+// Field: The name of the field, as it appears within the JSON representation of a todo item
+//
+// Old: The value of the field before the change, omitted when the field was not set
+//
+// New: The value of the field after the change, omitted when the field is no longer set
+type FieldChange struct {
+	Field string          `json:"Field"`
+	Old   json.RawMessage `json:"Old,omitempty"`
+	New   json.RawMessage `json:"New,omitempty"`
+}
@@ -1,5 +1,21 @@
 package models
 
+import "time"
+
+// Priority describes how urgent a todo item is. The zero value means the todo item has no priority
+type Priority string
+
+// The priorities a todo item can be given, from least to most urgent
+const (
+	PriorityNone   Priority = ""
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+)
+
+// Priorities lists every valid Priority, from least to most urgent
+var Priorities = []Priority{PriorityNone, PriorityLow, PriorityMedium, PriorityHigh}
+
 // Todo a Todo item. Composed of the following fields:
 //
 // Id: A unique identifier of the todo item
@@ -9,9 +25,63 @@ package models
 // Desc: A longer, more detailed description of the todo item
 //
 // Completed: boolean value indicating whether the todo item has been completed or not
+//
+// Version: The number of times the todo item has been saved, set by the service and used for optimistic concurrency
+//
+// CreatedAt: When the todo item was created, set by the service
+//
+// UpdatedAt: When the todo item was last saved, set by the service
+//
+// CompletedAt: When the todo item was completed, set by the service and omitted while it is not completed
+//
+// DueAt: When the todo item is due to be completed, if it has a deadline. The timezone supplied by the client is kept
+//
+// Priority: How urgent the todo item is, one of "low", "medium" or "high", or omitted when it has no priority
+//
+// ListId: The id of the list the todo item belongs to, omitted when it does not belong to a list
+//
+// ParentId: The id of the todo item this is a subtask of, omitted when it is not a subtask
+//
+// DependsOn: The ids of the todo items which must be completed before this todo item can be started, kept sorted
+// without duplicates
+//
+// Blocked: Whether any of the todo items this depends on is still open. Computed by the service when todo items are
+// read, and ignored when they are saved
+//
+// Recurrence: An iCalendar RRULE, such as "FREQ=WEEKLY;BYDAY=MO", which repeats the todo item from its DueAt. When the
+// todo item is completed the next occurrence is created as a new todo item
+//
+// Occurrence: Which occurrence of its Recurrence the todo item is, counting from 1, set by the service
+//
+// NextId: The id of the todo item created for the next occurrence when this one was completed, set by the service
+//
+// DeletedAt: When the todo item was moved to the trash, set by the service and only present on todo items in the trash
+//
+// Tags: The labels used to categorise the todo item. Tags are lower cased, with whitespace replaced by "-", and are kept
+// sorted without duplicates
 type Todo struct {
-	Id        string `json:"Id"`
-	Title     string `json:"Title"`
-	Desc      string `json:"Desc"`
-	Completed bool   `json:"Completed"`
+	Id          string     `json:"Id"`
+	Title       string     `json:"Title"`
+	Desc        string     `json:"Desc"`
+	Completed   bool       `json:"Completed"`
+	Version     int64      `json:"Version"`
+	CreatedAt   time.Time  `json:"CreatedAt"`
+	UpdatedAt   time.Time  `json:"UpdatedAt"`
+	CompletedAt *time.Time `json:"CompletedAt,omitempty"`
+	DueAt       *time.Time `json:"DueAt,omitempty"`
+	Priority    Priority   `json:"Priority,omitempty"`
+	ListId      string     `json:"ListId,omitempty"`
+	ParentId    string     `json:"ParentId,omitempty"`
+	DependsOn   []string   `json:"DependsOn,omitempty"`
+	Blocked     bool       `json:"Blocked,omitempty"`
+	Recurrence  string     `json:"Recurrence,omitempty"`
+	Occurrence  int        `json:"Occurrence,omitempty"`
+	NextId      string     `json:"NextId,omitempty"`
+	DeletedAt   *time.Time `json:"DeletedAt,omitempty"`
+	Tags        []string   `json:"Tags,omitempty"`
+}
+
+// Overdue reports whether the todo item is still open after it was due to be completed
+func (todo Todo) Overdue(now time.Time) bool {
+	return !todo.Completed && todo.DueAt != nil && todo.DueAt.Before(now)
 }
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookEvent names a kind of change to a todo item which a Webhook can subscribe to
+type WebhookEvent string
+
+// The events a Webhook can subscribe to
+const (
+	WebhookTodoCreated   WebhookEvent = "todo.created"
+	WebhookTodoUpdated   WebhookEvent = "todo.updated"
+	WebhookTodoCompleted WebhookEvent = "todo.completed"
+	WebhookTodoDeleted   WebhookEvent = "todo.deleted"
+)
+
+// WebhookEvents lists every valid WebhookEvent
+var WebhookEvents = []WebhookEvent{WebhookTodoCreated, WebhookTodoUpdated, WebhookTodoCompleted, WebhookTodoDeleted}
+
+// Webhook a subscription of another system to changes made to todo items, which are delivered to it over HTTP. Composed
+// of the following fields:
+//
+// Id: A unique identifier of the webhook, set by the service
+//
+// Url: The http or https URL each delivery is POSTed to
+//
+// Events: The events delivered to the webhook
+//
+// Secret: The key each delivery is signed with, generated by the service when none is supplied. It is only returned when
+// the webhook is created
+//
+// Version: The number of times the webhook has been saved, set by the service and used for optimistic concurrency
+//
+// CreatedAt: When the webhook was created, set by the service
+//
+// UpdatedAt: When the webhook was last saved, set by the service
+type Webhook struct {
+	Id        string         `json:"Id"`
+	Url       string         `json:"Url"`
+	Events    []WebhookEvent `json:"Events"`
+	Secret    string         `json:"Secret,omitempty"`
+	Version   int64          `json:"Version"`
+	CreatedAt time.Time      `json:"CreatedAt"`
+	UpdatedAt time.Time      `json:"UpdatedAt"`
+}
+
+// WebhookPayload the body of every delivery of an event to a webhook. Composed of the following fields:
+//
+// Id: A unique identifier of the event, shared by every delivery of it, which receivers can use to ignore duplicates
+//
+// Event: Which event happened
+//
+// Actor: The label the client attributed the change to within the X-Actor header of the request, or "anonymous" when
+// it was not sent. Clients are not authenticated, so the label is only what the client claimed and cannot be relied
+// upon to identify who made the change
+//
+// At: When the event happened
+//
+// Todo: The todo item once the change had been made, or as it was removed for "todo.deleted"
+type WebhookPayload struct {
+	Id    string       `json:"Id"`
+	Event WebhookEvent `json:"Event"`
+	Actor string       `json:"Actor"`
+	At    time.Time    `json:"At"`
+	Todo  Todo         `json:"Todo"`
+}
+
+// DeliveryStatus describes how far a Delivery has got
+type DeliveryStatus string
+
+// The statuses a Delivery can have
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery a single event queued to be sent to a webhook, recording each attempt made to send it. Composed of the
+// following fields:
+//
+// Id: A unique identifier of the delivery
+//
+// WebhookId: The id of the webhook the event is sent to
+//
+// Event: Which event is sent
+//
+// Payload: The body sent, see WebhookPayload
+//
+// Status: Either "pending" while the event is still to be sent, "succeeded" once the webhook responded with a 2xx, or
+// "failed" once every attempt allowed has been made without success
+//
+// Attempts: The number of attempts made to send the event
+//
+// NextAttemptAt: When the event will next be sent, only present while the delivery is pending
+//
+// LastAttemptAt: When the event was last sent, omitted until the first attempt
+//
+// ResponseStatus: The status code the webhook responded with to the last attempt, omitted when it did not respond
+//
+// Error: Why the last attempt failed, omitted when it succeeded
+//
+// CreatedAt: When the delivery was queued
+type Delivery struct {
+	Id             string          `json:"Id"`
+	WebhookId      string          `json:"WebhookId"`
+	Event          WebhookEvent    `json:"Event"`
+	Payload        json.RawMessage `json:"Payload"`
+	Status         DeliveryStatus  `json:"Status"`
+	Attempts       int             `json:"Attempts"`
+	NextAttemptAt  *time.Time      `json:"NextAttemptAt,omitempty"`
+	LastAttemptAt  *time.Time      `json:"LastAttemptAt,omitempty"`
+	ResponseStatus int             `json:"ResponseStatus,omitempty"`
+	Error          string          `json:"Error,omitempty"`
+	CreatedAt      time.Time       `json:"CreatedAt"`
+}
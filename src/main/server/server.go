@@ -0,0 +1,172 @@
+package server
+
+import (
+	"TodoApp/src/main/config"
+	"TodoApp/src/main/controllers"
+	"TodoApp/src/main/logging"
+	"TodoApp/src/main/metrics"
+	"TodoApp/src/main/services"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ActorHeader is the header carrying the label a client attributes its request to, recorded against every change to a
+// todo item it makes. Clients are not authenticated, so the label is untrusted: any client can send any label, and it is
+// recorded as sent rather than identifying who made the change
+const ActorHeader = "X-Actor"
+
+// maxActorLength is the longest actor accepted from a client, longer actors are ignored
+const maxActorLength = 128
+
+// A Job is work which runs in the background for as long as a Server is serving requests, such as purging the trash.
+// Run should return soon after ctx is cancelled
+type Job interface {
+	Run(ctx context.Context)
+}
+
+// A Server serves the API over HTTP, alongside any background jobs, shutting down gracefully once it is asked to stop
+type Server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+	jobs            []Job
+	logger          *slog.Logger
+}
+
+// NewRouter creates the router serving every route of the API, wrapped in the middleware shared by all of them. This is
+// used by Wire when starting the API to perform the necessary dependency injection
+func NewRouter(todoController controllers.TodoController, tagController controllers.TagController,
+	listController controllers.ListController, trashController controllers.TrashController,
+	auditController controllers.AuditController, eventController controllers.EventController,
+	feedController controllers.FeedController, socketController controllers.SocketController,
+	webhookController controllers.WebhookController, healthController controllers.HealthController, logger *slog.Logger, apiMetrics *metrics.Metrics) http.Handler {
+	router := mux.NewRouter().StrictSlash(true)
+	// The stream and socket are registered first so that "todo/stream" and "todo/socket" are not taken as the id of a
+	// todo item
+	feedController.RegisterRoutes(router)
+	socketController.RegisterRoutes(router)
+	todoController.RegisterRoutes(router)
+	tagController.RegisterRoutes(router)
+	listController.RegisterRoutes(router)
+	trashController.RegisterRoutes(router)
+	auditController.RegisterRoutes(router)
+	eventController.RegisterRoutes(router)
+	webhookController.RegisterRoutes(router)
+	healthController.RegisterRoutes(router)
+	router.Handle("/metrics", apiMetrics.Handler()).Methods("GET")
+	return logging.Middleware(logger, router)(apiMetrics.Middleware(router)(withActor(router)))
+}
+
+// withActor wraps next so that the untrusted label sent within the ActorHeader of each request is carried by its context
+// as its actor, see services.WithActor. Requests without a valid label are made anonymously
+func withActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if actor := request.Header.Get(ActorHeader); validActor(actor) {
+			request = request.WithContext(services.WithActor(request.Context(), actor))
+		}
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// validActor reports whether an actor label sent by a client is safe to record
+func validActor(actor string) bool {
+	if actor == "" || len(actor) > maxActorLength {
+		return false
+	}
+	for _, char := range actor {
+		if char > unicode.MaxASCII || !unicode.IsPrint(char) {
+			return false
+		}
+	}
+	return true
+}
+
+// New creates a new Server serving handler with the timeouts and size limits from cfg, running jobs in the background
+// while it serves. The body of every request is limited to cfg.MaxBodyBytes, reading past the limit fails with an
+// *http.MaxBytesError. This is used by Wire when starting the API to perform the necessary dependency injection
+func New(cfg config.ServerConfig, handler http.Handler, logger *slog.Logger, jobs ...Job) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           limitBody(cfg.MaxBodyBytes, handler),
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+			ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+		jobs:            jobs,
+		logger:          logger,
+	}
+}
+
+// limitBody wraps next so that the body of every request it receives can be no larger than maxBytes
+func limitBody(maxBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		request.Body = http.MaxBytesReader(writer, request.Body, maxBytes)
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// Run listens on the address of the server and serves requests until ctx is cancelled, see Serve
+func (server *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", server.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	return server.Serve(ctx, listener)
+}
+
+// Serve serves requests accepted by listener until ctx is cancelled. The server then stops accepting new connections
+// and waits up to its shutdown timeout for in-flight requests to complete before returning. An error is returned if the
+// server fails, or if requests were still in-flight once the shutdown timeout had passed. The background jobs of the
+// server run until it stops serving, and Serve only returns once every one of them has finished
+func (server *Server) Serve(ctx context.Context, listener net.Listener) error {
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	var jobs sync.WaitGroup
+	defer func() {
+		stopJobs()
+		jobs.Wait()
+	}()
+	for _, job := range server.jobs {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			job.Run(jobsCtx)
+		}()
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.httpServer.Serve(listener)
+	}()
+	server.logger.Info("Server listening", "addr", listener.Addr().String())
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	server.logger.Info("Shutting down server", "timeout", server.shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), server.shutdownTimeout)
+	defer cancel()
+	err := server.httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("failed to drain in-flight requests: %w", err)
+	}
+	err = <-served
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
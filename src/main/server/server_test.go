@@ -0,0 +1,160 @@
+package server
+
+import (
+	"TodoApp/src/main/config"
+	"TodoApp/src/main/services"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimitBody(t *testing.T) {
+	handler := limitBody(4, http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, err := io.ReadAll(request.Body)
+		if err != nil {
+			writer.WriteHeader(http.StatusRequestEntityTooLarge)
+		}
+	}))
+
+	tests := map[string]struct {
+		body         string
+		expectedCode int
+	}{
+		"Body Within Limit": {body: "1234", expectedCode: http.StatusOK},
+		"Body Over Limit":   {body: "12345", expectedCode: http.StatusRequestEntityTooLarge},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			httpWriter := httptest.NewRecorder()
+			handler.ServeHTTP(httpWriter, httptest.NewRequest(http.MethodPost, "/todo", strings.NewReader(tt.body)))
+			if httpWriter.Code != tt.expectedCode {
+				t.Errorf("unexpected HTTP response code, expected [%v] but recieved [%v]", tt.expectedCode, httpWriter.Code)
+			}
+		})
+	}
+}
+
+func TestWithActor(t *testing.T) {
+	handler := withActor(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = io.WriteString(writer, services.ActorFrom(request.Context()))
+	}))
+
+	tests := map[string]struct {
+		actor         string
+		expectedActor string
+	}{
+		"Actor Sent":          {actor: "alice", expectedActor: "alice"},
+		"No Actor Sent":       {expectedActor: services.AnonymousActor},
+		"Actor Too Long":      {actor: strings.Repeat("a", 129), expectedActor: services.AnonymousActor},
+		"Actor Not Printable": {actor: "alice\tbob", expectedActor: services.AnonymousActor},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPut, "/todo", nil)
+			if tt.actor != "" {
+				request.Header.Set(ActorHeader, tt.actor)
+			}
+			httpWriter := httptest.NewRecorder()
+			handler.ServeHTTP(httpWriter, request)
+			if actor := httpWriter.Body.String(); actor != tt.expectedActor {
+				t.Errorf("unexpected actor, expected [%v] but recieved [%v]", tt.expectedActor, actor)
+			}
+		})
+	}
+}
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		close(started)
+		<-release
+		writer.WriteHeader(http.StatusNoContent)
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- New(config.Default().Server, handler, slog.New(slog.NewTextHandler(io.Discard, nil))).Serve(ctx, listener)
+	}()
+
+	response := make(chan *http.Response, 1)
+	go func() {
+		res, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			t.Errorf("Error occured when none expected: [%v]", err)
+			close(response)
+			return
+		}
+		res.Body.Close()
+		response <- res
+	}()
+
+	<-started
+	cancel()
+	select {
+	case err := <-stopped:
+		t.Fatalf("Server stopped before the in-flight request completed: [%v]", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+
+	res := <-response
+	if res == nil || res.StatusCode != http.StatusNoContent {
+		t.Fatalf("In-flight request was not completed: [%v]", res)
+	}
+	err = <-stopped
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+}
+
+// jobFunc adapts a function into a Job
+type jobFunc func(ctx context.Context)
+
+func (job jobFunc) Run(ctx context.Context) {
+	job(ctx)
+}
+
+func TestServeRunsJobsUntilStopped(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	job := jobFunc(func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(finished)
+	})
+	handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {})
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- New(config.Default().Server, handler, slog.New(slog.NewTextHandler(io.Discard, nil)), job).Serve(ctx, listener)
+	}()
+
+	<-started
+	cancel()
+	err = <-stopped
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Fatal("Server stopped before its job had finished")
+	}
+}
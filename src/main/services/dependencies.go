@@ -0,0 +1,176 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"slices"
+	"strings"
+)
+
+// normaliseDependencies drops any empty or duplicated ids from the prerequisites of a Todo item and sorts them. Nil is
+// returned when no prerequisites remain
+func normaliseDependencies(ids []string) []string {
+	var normalised []string
+	for _, id := range ids {
+		if id != "" && !slices.Contains(normalised, id) {
+			normalised = append(normalised, id)
+		}
+	}
+	slices.Sort(normalised)
+	return normalised
+}
+
+// checkDependencies confirms that every prerequisite of todo exists and that depending on them would not create a
+// cycle, where todo would become a prerequisite of itself. find looks up a Todo item by id, returning false if it does
+// not exist. A ValidationError is returned if the prerequisites are invalid, which for a cycle includes the path of
+// dependencies forming it
+func checkDependencies(todo models.Todo, find func(id string) (models.Todo, bool, error)) error {
+	invalid := func(message string) error {
+		return &ValidationError{Resource: "todo", Fields: []FieldError{{Field: "DependsOn", Message: message}}}
+	}
+	for _, id := range todo.DependsOn {
+		if id == todo.Id {
+			return invalid("cannot include the todo item itself")
+		}
+		_, ok, err := find(id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return invalid("todo item [" + id + "] does not exist")
+		}
+	}
+
+	// A cycle exists if todo can be reached again by following the prerequisites of its own prerequisites. The path is
+	// built in reverse as the search unwinds
+	visited := make(map[string]bool)
+	var path []string
+	var reaches func(id string) (bool, error)
+	reaches = func(id string) (bool, error) {
+		if id == todo.Id {
+			return true, nil
+		}
+		if visited[id] {
+			return false, nil
+		}
+		visited[id] = true
+		current, ok, err := find(id)
+		if err != nil || !ok {
+			return false, err
+		}
+		for _, next := range current.DependsOn {
+			found, err := reaches(next)
+			if err != nil {
+				return false, err
+			}
+			if found {
+				path = append(path, next)
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	for _, id := range todo.DependsOn {
+		found, err := reaches(id)
+		if err != nil {
+			return err
+		}
+		if found {
+			path = append(path, id, todo.Id)
+			slices.Reverse(path)
+			return invalid("would create the cycle [" + strings.Join(path, " -> ") + "]")
+		}
+	}
+	return nil
+}
+
+// blocked reports whether any prerequisite of todo has not yet been completed. find looks up a Todo item by id,
+// returning false if it does not exist
+func blocked(todo models.Todo, find func(id string) (models.Todo, bool, error)) (bool, error) {
+	for _, id := range todo.DependsOn {
+		prerequisite, ok, err := find(id)
+		if err != nil {
+			return false, err
+		}
+		if ok && !prerequisite.Completed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// markBlocked returns a copy of todos with the Blocked value of each Todo item computed from the others
+func markBlocked(todos []models.Todo) []models.Todo {
+	byId := make(map[string]models.Todo, len(todos))
+	for _, todo := range todos {
+		byId[todo.Id] = todo
+	}
+	find := func(id string) (models.Todo, bool, error) {
+		todo, ok := byId[id]
+		return todo, ok, nil
+	}
+	marked := make([]models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		todo.Blocked, _ = blocked(todo, find)
+		marked = append(marked, todo)
+	}
+	return marked
+}
+
+// planTodos returns the Todo items within todos which have not been completed, ordered so that each comes after all of
+// its open prerequisites. Todo items which could be done at the same point keep the order they appear in within todos.
+// Should the dependencies contain a cycle, which is prevented when Todo items are saved, the Todo items within it are
+// placed at the end
+func planTodos(todos []models.Todo) []models.Todo {
+	todos = markBlocked(todos)
+	open := make(map[string]bool)
+	for _, todo := range todos {
+		if !todo.Completed {
+			open[todo.Id] = true
+		}
+	}
+	// waiting counts the open prerequisites of each Todo item which have not yet been placed in the plan
+	waiting := make(map[string]int)
+	dependants := make(map[string][]string)
+	for _, todo := range todos {
+		for _, id := range todo.DependsOn {
+			if open[todo.Id] && open[id] {
+				waiting[todo.Id]++
+				dependants[id] = append(dependants[id], todo.Id)
+			}
+		}
+	}
+
+	// ready holds the positions within todos of the Todo items which can be placed next, kept sorted so they are placed
+	// in their original order
+	var ready []int
+	for i, todo := range todos {
+		if open[todo.Id] && waiting[todo.Id] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	index := make(map[string]int, len(todos))
+	for i, todo := range todos {
+		index[todo.Id] = i
+	}
+	plan := make([]models.Todo, 0, len(open))
+	placed := make(map[string]bool, len(open))
+	for len(ready) > 0 {
+		next := todos[ready[0]]
+		ready = ready[1:]
+		plan = append(plan, next)
+		placed[next.Id] = true
+		for _, dependant := range dependants[next.Id] {
+			waiting[dependant]--
+			if waiting[dependant] == 0 {
+				position, _ := slices.BinarySearch(ready, index[dependant])
+				ready = slices.Insert(ready, position, index[dependant])
+			}
+		}
+	}
+	for _, todo := range todos {
+		if open[todo.Id] && !placed[todo.Id] {
+			plan = append(plan, todo)
+		}
+	}
+	return plan
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func dependenciesPrerequisite() []models.Todo {
+	return []models.Todo{
+		{Id: "design", Title: "Design", Completed: true},
+		{Id: "build", Title: "Build", DependsOn: []string{"design"}},
+		{Id: "test", Title: "Test", DependsOn: []string{"build"}},
+		{Id: "release", Title: "Release", DependsOn: []string{"test", "build", "test"}},
+		{Id: "docs", Title: "Docs"},
+	}
+}
+
+func TestDependenciesAcrossBackends(t *testing.T) {
+	for name, service := range setupBackends(t, dependenciesPrerequisite()) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			release, err := service.ReturnSingleTodo(ctx, "release")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff([]string{"build", "test"}, release.DependsOn)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			if !release.Blocked {
+				t.Fatalf("Expected [release] to be blocked")
+			}
+			build, err := service.ReturnSingleTodo(ctx, "build")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if build.Blocked {
+				t.Fatalf("Expected [build] not to be blocked as [design] is completed")
+			}
+
+			ready := true
+			page, err := service.QueryTodos(ctx, TodoQuery{Ready: &ready})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff([]string{"build", "docs"}, ids(page.Todos))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			ready = false
+			page, err = service.QueryTodos(ctx, TodoQuery{Ready: &ready})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff([]string{"design", "release", "test"}, ids(page.Todos))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+
+			plan, err := service.PlanTodos(ctx)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff([]string{"build", "test", "release", "docs"}, ids(plan))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+
+			err = service.DeleteTodo(ctx, "build", nil)
+			if !errors.Is(err, ErrConflict) || err.Error() != "todo with id [build] is still a prerequisite of [2] todo items" {
+				t.Fatalf("Conflict error expected but was [%v]", err)
+			}
+			err = service.DeleteTodo(ctx, "docs", nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+		})
+	}
+}
+
+func TestDependencyValidationAcrossBackends(t *testing.T) {
+	tests := map[string]struct {
+		todo     models.Todo
+		expected string
+	}{
+		"Depends On Itself": {
+			todo:     models.Todo{Id: "build", Title: "Build", DependsOn: []string{"build"}},
+			expected: "todo DependsOn cannot include the todo item itself",
+		},
+		"Depends On Missing Todo": {
+			todo:     models.Todo{Id: "build", Title: "Build", DependsOn: []string{"design", "missing"}},
+			expected: "todo DependsOn todo item [missing] does not exist",
+		},
+		"Direct Cycle": {
+			todo:     models.Todo{Id: "build", Title: "Build", DependsOn: []string{"test"}},
+			expected: "todo DependsOn would create the cycle [build -> test -> build]",
+		},
+		"Indirect Cycle": {
+			todo:     models.Todo{Id: "design", Title: "Design", DependsOn: []string{"docs", "release"}},
+			expected: "todo DependsOn would create the cycle [design -> release -> build -> design]",
+		},
+	}
+	for name, test := range tests {
+		for backend, service := range setupBackends(t, dependenciesPrerequisite()) {
+			t.Run(name+"/"+backend, func(t *testing.T) {
+				_, err := service.UpdateTodo(context.Background(), test.todo, nil)
+				if !errors.Is(err, ErrValidation) || err.Error() != test.expected {
+					t.Fatalf("Validation error [%s] expected but was [%v]", test.expected, err)
+				}
+			})
+		}
+	}
+}
+
+func TestDeleteListDetachesDependenciesAcrossBackends(t *testing.T) {
+	for name, service := range setupBackends(t, []models.Todo{}) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := service.CreateNewList(ctx, models.List{Id: "sprint", Name: "Sprint"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			for _, todo := range []models.Todo{
+				{Id: "inside", Title: "Inside", ListId: "sprint"},
+				{Id: "other", Title: "Other"},
+				{Id: "outside", Title: "Outside", DependsOn: []string{"inside", "other"}},
+			} {
+				_, err = service.CreateNewTodo(ctx, todo)
+				if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+			}
+			err = service.DeleteList(ctx, "sprint", ListDeleteCascade, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			outside, err := service.ReturnSingleTodo(ctx, "outside")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if outside.Version != 2 || !cmp.Equal([]string{"other"}, outside.DependsOn) {
+				t.Fatalf("Expected [outside] to only depend on [other] but was [%+v]", outside)
+			}
+		})
+	}
+}
+
+func TestPlanTodos(t *testing.T) {
+	tests := map[string]struct {
+		todos    []models.Todo
+		expected []string
+	}{
+		"No Dependencies Keeps Order": {
+			todos:    []models.Todo{{Id: "b"}, {Id: "a"}, {Id: "c"}},
+			expected: []string{"b", "a", "c"},
+		},
+		"Prerequisites Placed First": {
+			todos: []models.Todo{
+				{Id: "a", DependsOn: []string{"c"}},
+				{Id: "b"},
+				{Id: "c", DependsOn: []string{"d"}},
+				{Id: "d"},
+			},
+			expected: []string{"b", "d", "c", "a"},
+		},
+		"Completed Todos Omitted": {
+			todos: []models.Todo{
+				{Id: "a", DependsOn: []string{"b"}},
+				{Id: "b", Completed: true},
+			},
+			expected: []string{"a"},
+		},
+		"Cycles Placed Last": {
+			todos: []models.Todo{
+				{Id: "a", DependsOn: []string{"b"}},
+				{Id: "b", DependsOn: []string{"a"}},
+				{Id: "c"},
+			},
+			expected: []string{"c", "a", "b"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			diff := cmp.Diff(test.expected, ids(planTodos(test.todos)))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
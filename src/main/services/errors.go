@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors which the typed errors returned by the services match against when using errors.Is, allowing callers
+// to react to the kind of failure without needing to know the concrete error type
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	// ErrPreconditionFailed is matched when a change is rejected because the resource is not at the expected version
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// A NotFoundError is returned when no resource with the requested id exists. It matches ErrNotFound
+type NotFoundError struct {
+	Resource string
+	Id       string
+}
+
+func (err *NotFoundError) Error() string {
+	return fmt.Sprintf("could not find %s with id [%s]", err.Resource, err.Id)
+}
+
+func (err *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// A ConflictError is returned when a resource cannot be persisted because it clashes with an existing resource. It
+// matches ErrConflict
+type ConflictError struct {
+	Resource string
+	Id       string
+}
+
+func (err *ConflictError) Error() string {
+	return fmt.Sprintf("%s with id [%s] already exists", err.Resource, err.Id)
+}
+
+func (err *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// A FieldError describes why a single field of a resource failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// A ValidationError is returned when a resource fails validation, listing every field which was invalid. It matches
+// ErrValidation
+type ValidationError struct {
+	Resource string
+	Fields   []FieldError
+}
+
+func (err *ValidationError) Error() string {
+	messages := make([]string, 0, len(err.Fields))
+	for _, field := range err.Fields {
+		messages = append(messages, fmt.Sprintf("%s %s %s", err.Resource, field.Field, field.Message))
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (err *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// A VersionMismatchError is returned when a change is made against an expected version of a resource, but the resource
+// has since been changed. It matches ErrPreconditionFailed
+type VersionMismatchError struct {
+	Resource string
+	Id       string
+	Expected int64
+	Actual   int64
+}
+
+func (err *VersionMismatchError) Error() string {
+	return fmt.Sprintf("%s with id [%s] is at version [%d] not the expected version [%d]", err.Resource, err.Id, err.Actual, err.Expected)
+}
+
+func (err *VersionMismatchError) Is(target error) bool {
+	return target == ErrPreconditionFailed
+}
+
+// A ListNotEmptyError is returned when a list cannot be deleted as Todo items still belong to it. It matches ErrConflict
+type ListNotEmptyError struct {
+	Id    string
+	Todos int
+}
+
+func (err *ListNotEmptyError) Error() string {
+	return fmt.Sprintf("list with id [%s] still contains [%d] todo items", err.Id, err.Todos)
+}
+
+func (err *ListNotEmptyError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// A HasSubtasksError is returned when a Todo item cannot be deleted as it still has subtasks. It matches ErrConflict
+type HasSubtasksError struct {
+	Id       string
+	Subtasks int
+}
+
+func (err *HasSubtasksError) Error() string {
+	return fmt.Sprintf("todo with id [%s] still has [%d] subtasks", err.Id, err.Subtasks)
+}
+
+func (err *HasSubtasksError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// A HasDependantsError is returned when a Todo item cannot be deleted as other Todo items still depend on it. It matches
+// ErrConflict
+type HasDependantsError struct {
+	Id         string
+	Dependants int
+}
+
+func (err *HasDependantsError) Error() string {
+	return fmt.Sprintf("todo with id [%s] is still a prerequisite of [%d] todo items", err.Id, err.Dependants)
+}
+
+func (err *HasDependantsError) Is(target error) bool {
+	return target == ErrConflict
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EventStream is implemented by the backends which record every change as a stream of events, see EventTodoService.
+// Consumers read the stream in order, passing the Seq of the last event they have seen to fetch those which followed
+type EventStream interface {
+	ReturnEvents(ctx context.Context, query EventQuery) (EventPage, error)
+	RebuildProjection(ctx context.Context) (int, error)
+}
+
+// An EventQuery describes which events to return from an EventStream. Composed of the following fields:
+//
+// After: Only return events with a Seq greater than this, zero returns the stream from its start
+//
+// Limit: The maximum number of events to return, defaults to DefaultPageLimit
+type EventQuery struct {
+	After int64
+	Limit int
+}
+
+// An EventPage is a single page of events returned by ReturnEvents, in the order they were recorded. Next is the Seq of
+// the last event returned, as an After value to fetch the following page, it is empty when there are no further events
+type EventPage struct {
+	Events []models.Event
+	Limit  int
+	Next   string
+}
+
+// applyEventQuery returns the page of events, which must be in the order they were recorded, selected by query. If the
+// query is invalid a ValidationError is returned
+func applyEventQuery(events []models.Event, query EventQuery) (EventPage, error) {
+	var fields []FieldError
+	if query.After < 0 {
+		fields = append(fields, FieldError{Field: "after", Message: "cannot be negative"})
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultPageLimit
+	}
+	if query.Limit < 0 || query.Limit > MaxPageLimit {
+		fields = append(fields, FieldError{Field: "limit", Message: "must be between 1 and 500"})
+	}
+	if len(fields) > 0 {
+		return EventPage{}, &ValidationError{Resource: "query", Fields: fields}
+	}
+
+	// Seq starts from 1 and increases by one for each event, so the events following After start at that index
+	start := min(int(query.After), len(events))
+	end := min(start+query.Limit, len(events))
+	page := EventPage{Events: append([]models.Event{}, events[start:end]...), Limit: query.Limit}
+	if end < len(events) {
+		page.Next = strconv.FormatInt(events[end-1].Seq, 10)
+	}
+	return page, nil
+}
+
+// revisionEvents returns the events recording revision. An update is split by what it changed: a new title is recorded
+// as TodoRenamed, completing or reopening the Todo item as TodoCompleted or TodoReopened and any other change as
+// TodoUpdated, all sharing the revision and version
+func revisionEvents(revision models.Revision) []models.Event {
+	event := models.Event{TodoId: revision.TodoId, Revision: revision.Id, Version: revision.Version,
+		Actor: revision.Actor, At: revision.At}
+	switch revision.Action {
+	case models.ActionCreate:
+		todo := revision.Todo
+		event.Type, event.Todo = models.EventTodoCreated, &todo
+		return []models.Event{event}
+	case models.ActionDelete:
+		event.Type, event.Changes = models.EventTodoDeleted, revision.Changes
+		return []models.Event{event}
+	case models.ActionRestore:
+		event.Type, event.Changes = models.EventTodoRestored, revision.Changes
+		return []models.Event{event}
+	case models.ActionPurge:
+		event.Type = models.EventTodoPurged
+		return []models.Event{event}
+	}
+
+	var renamed, completion, other []models.FieldChange
+	var completionType models.EventType
+	for _, change := range revision.Changes {
+		switch change.Field {
+		case "Title":
+			renamed = append(renamed, change)
+		case "Completed":
+			completionType = models.EventTodoReopened
+			if string(change.New) == "true" {
+				completionType = models.EventTodoCompleted
+			}
+			completion = append(completion, change)
+		case "CompletedAt":
+			completion = append(completion, change)
+		default:
+			other = append(other, change)
+		}
+	}
+	if completionType == "" && len(completion) > 0 {
+		other = append(other, completion...)
+		slices.SortFunc(other, func(a, b models.FieldChange) int { return strings.Compare(a.Field, b.Field) })
+	}
+
+	var events []models.Event
+	if len(renamed) > 0 {
+		events = append(events, changeEvent(event, models.EventTodoRenamed, renamed))
+	}
+	if completionType != "" {
+		events = append(events, changeEvent(event, completionType, completion))
+	}
+	// A revision which only gave the Todo item a new version is still recorded, so that the version is projected
+	if len(other) > 0 || len(events) == 0 {
+		events = append(events, changeEvent(event, models.EventTodoUpdated, other))
+	}
+	return events
+}
+
+// changeEvents returns the events recording changes, the events of each revision in the order they were recorded
+// followed by those of the lists which were saved and then those which were deleted
+func changeEvents(changes changeSet) []models.Event {
+	var events []models.Event
+	for _, revision := range changes.revisions {
+		events = append(events, revisionEvents(revision)...)
+	}
+	for _, todoList := range changes.lists {
+		// A list is only ever at its first version when it has just been created
+		eventType := models.EventListUpdated
+		if todoList.Version == 1 {
+			eventType = models.EventListCreated
+		}
+		events = append(events, listEvent(changes, eventType, todoList))
+	}
+	for _, todoList := range changes.deletedLists {
+		events = append(events, listEvent(changes, models.EventListDeleted, todoList))
+	}
+	return events
+}
+
+// listEvent returns the event of type eventType recording a change made to todoList within changes
+func listEvent(changes changeSet, eventType models.EventType, todoList models.List) models.Event {
+	event := models.Event{Type: eventType, ListId: todoList.Id, Version: todoList.Version, Actor: changes.actor,
+		At: changes.at}
+	if eventType != models.EventListDeleted {
+		event.List = &todoList
+	}
+	return event
+}
+
+// changeEvent returns event as the given type, carrying changes
+func changeEvent(event models.Event, eventType models.EventType, changes []models.FieldChange) models.Event {
+	event.Type, event.Changes = eventType, changes
+	return event
+}
+
+// projectEvents applies events, which must be in the order they were recorded, to memory, deriving the Todo items,
+// lists and revisions they describe. The events belonging to each revision are applied together, recording that
+// revision again. The caller must hold the write lock, or memory must not yet be in use
+func projectEvents(memory *TodoServiceImpl, events []models.Event) error {
+	for start := 0; start < len(events); {
+		event := events[start]
+		switch event.Type {
+		case models.EventListCreated, models.EventListUpdated:
+			if element, exists := memory.lists[event.ListId]; exists {
+				element.Value = *event.List
+			} else {
+				memory.lists[event.ListId] = memory.listOrder.PushBack(*event.List)
+			}
+			start++
+			continue
+		case models.EventListDeleted:
+			if element, exists := memory.lists[event.ListId]; exists {
+				memory.listOrder.Remove(element)
+				delete(memory.lists, event.ListId)
+			}
+			start++
+			continue
+		}
+
+		end := start + 1
+		for end < len(events) && events[end].Revision == event.Revision && events[end].TodoId == event.TodoId {
+			end++
+		}
+		err := projectRevision(memory, events[start:end])
+		if err != nil {
+			return err
+		}
+		start = end
+	}
+	return nil
+}
+
+// projectRevision applies the events recording a single revision to memory. The caller must hold the write lock, or
+// memory must not yet be in use
+func projectRevision(memory *TodoServiceImpl, events []models.Event) error {
+	first := events[0]
+	action := models.ActionUpdate
+	switch first.Type {
+	case models.EventTodoCreated:
+		action = models.ActionCreate
+	case models.EventTodoDeleted:
+		action = models.ActionDelete
+	case models.EventTodoRestored:
+		action = models.ActionRestore
+	case models.EventTodoPurged:
+		action = models.ActionPurge
+	}
+
+	// A Todo item being restored or purged is found within the trash, any other change is made to a live Todo item
+	var before *models.Todo
+	elements := memory.todos
+	if action == models.ActionRestore || action == models.ActionPurge {
+		elements = memory.trash
+	}
+	if element, exists := elements[first.TodoId]; exists && action != models.ActionCreate {
+		existing := element.Value.(models.Todo)
+		before = &existing
+	}
+	if before == nil && action != models.ActionCreate {
+		return &NotFoundError{Resource: "todo", Id: first.TodoId}
+	}
+
+	var todo models.Todo
+	if before != nil {
+		todo = *before
+	}
+	for _, event := range events {
+		var err error
+		switch event.Type {
+		case models.EventTodoCreated:
+			todo = *event.Todo
+		case models.EventTodoPurged:
+		default:
+			todo, err = applyChanges(todo, event)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	after := &todo
+	switch action {
+	case models.ActionCreate, models.ActionUpdate:
+		memory.insert(todo)
+	case models.ActionDelete:
+		memory.order.Remove(memory.todos[todo.Id])
+		delete(memory.todos, todo.Id)
+		if existing, trashed := memory.trash[todo.Id]; trashed {
+			memory.trashOrder.Remove(existing)
+		}
+		memory.trash[todo.Id] = memory.trashOrder.PushBack(todo)
+	case models.ActionRestore:
+		memory.trashOrder.Remove(memory.trash[todo.Id])
+		delete(memory.trash, todo.Id)
+		memory.insert(todo)
+	case models.ActionPurge:
+		memory.trashOrder.Remove(memory.trash[todo.Id])
+		delete(memory.trash, todo.Id)
+		after = nil
+	}
+	revision := newRevision(WithActor(context.Background(), first.Actor), action, before, after, first.At)
+	revision.Id = first.Revision
+	memory.history = append(memory.history, revision)
+	return nil
+}
+
+// applyChanges returns todo with the changes carried by event applied to it. The version is taken from event, with the
+// Todo item marked as updated at the time of event when its version changes
+func applyChanges(todo models.Todo, event models.Event) (models.Todo, error) {
+	fields := todoFields(&todo)
+	for _, change := range event.Changes {
+		if change.New == nil {
+			delete(fields, change.Field)
+		} else {
+			fields[change.Field] = change.New
+		}
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	var changed models.Todo
+	err = json.Unmarshal(data, &changed)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	if changed.Version != event.Version {
+		changed.Version = event.Version
+		changed.UpdatedAt = event.At
+	}
+	return changed, nil
+}
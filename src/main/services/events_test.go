@@ -0,0 +1,140 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"encoding/json"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+// eventTypes returns the type of each event, in order
+func eventTypes(events []models.Event) []models.EventType {
+	result := make([]models.EventType, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.Type)
+	}
+	return result
+}
+
+func TestRevisionEvents(t *testing.T) {
+	tests := map[string]struct {
+		revision models.Revision
+		expected []models.Event
+	}{
+		"Created": {
+			revision: models.Revision{Id: 1, TodoId: "1", Version: 1, Action: models.ActionCreate, Actor: "alice", At: testTime,
+				Todo: models.Todo{Id: "1", Title: "Bake cake", Version: 1}},
+			expected: []models.Event{{Type: models.EventTodoCreated, TodoId: "1", Revision: 1, Version: 1, Actor: "alice",
+				At: testTime, Todo: &models.Todo{Id: "1", Title: "Bake cake", Version: 1}}},
+		},
+		"Renamed And Completed": {
+			revision: models.Revision{Id: 2, TodoId: "1", Version: 2, Action: models.ActionUpdate, Actor: "alice", At: testTime,
+				Changes: []models.FieldChange{
+					{Field: "Completed", Old: json.RawMessage(`false`), New: json.RawMessage(`true`)},
+					{Field: "CompletedAt", New: json.RawMessage(`"2024-03-01T12:00:00Z"`)},
+					{Field: "Priority", New: json.RawMessage(`"high"`)},
+					{Field: "Title", Old: json.RawMessage(`"Bake cake"`), New: json.RawMessage(`"Bake bread"`)},
+				}},
+			expected: []models.Event{
+				{Type: models.EventTodoRenamed, TodoId: "1", Revision: 2, Version: 2, Actor: "alice", At: testTime,
+					Changes: []models.FieldChange{{Field: "Title", Old: json.RawMessage(`"Bake cake"`), New: json.RawMessage(`"Bake bread"`)}}},
+				{Type: models.EventTodoCompleted, TodoId: "1", Revision: 2, Version: 2, Actor: "alice", At: testTime,
+					Changes: []models.FieldChange{
+						{Field: "Completed", Old: json.RawMessage(`false`), New: json.RawMessage(`true`)},
+						{Field: "CompletedAt", New: json.RawMessage(`"2024-03-01T12:00:00Z"`)},
+					}},
+				{Type: models.EventTodoUpdated, TodoId: "1", Revision: 2, Version: 2, Actor: "alice", At: testTime,
+					Changes: []models.FieldChange{{Field: "Priority", New: json.RawMessage(`"high"`)}}},
+			},
+		},
+		"Reopened": {
+			revision: models.Revision{Id: 3, TodoId: "1", Version: 3, Action: models.ActionUpdate, At: testTime,
+				Changes: []models.FieldChange{
+					{Field: "Completed", Old: json.RawMessage(`true`), New: json.RawMessage(`false`)},
+					{Field: "CompletedAt", Old: json.RawMessage(`"2024-03-01T12:00:00Z"`)},
+				}},
+			expected: []models.Event{{Type: models.EventTodoReopened, TodoId: "1", Revision: 3, Version: 3, At: testTime,
+				Changes: []models.FieldChange{
+					{Field: "Completed", Old: json.RawMessage(`true`), New: json.RawMessage(`false`)},
+					{Field: "CompletedAt", Old: json.RawMessage(`"2024-03-01T12:00:00Z"`)},
+				}}},
+		},
+		"Only Version Changed": {
+			revision: models.Revision{Id: 4, TodoId: "1", Version: 4, Action: models.ActionUpdate, At: testTime},
+			expected: []models.Event{{Type: models.EventTodoUpdated, TodoId: "1", Revision: 4, Version: 4, At: testTime}},
+		},
+		"Purged": {
+			revision: models.Revision{Id: 5, TodoId: "1", Version: 4, Action: models.ActionPurge, At: testTime,
+				Todo: models.Todo{Id: "1", Title: "Bake bread", Version: 4}},
+			expected: []models.Event{{Type: models.EventTodoPurged, TodoId: "1", Revision: 5, Version: 4, At: testTime}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			diff := cmp.Diff(tt.expected, revisionEvents(tt.revision))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestApplyEventQuery(t *testing.T) {
+	events := []models.Event{{Seq: 1}, {Seq: 2}, {Seq: 3}, {Seq: 4}}
+	tests := map[string]struct {
+		query         EventQuery
+		expectedSeqs  []int64
+		expectedNext  string
+		expectedError string
+	}{
+		"Every Event": {
+			query:        EventQuery{},
+			expectedSeqs: []int64{1, 2, 3, 4},
+		},
+		"First Page": {
+			query:        EventQuery{Limit: 2},
+			expectedSeqs: []int64{1, 2},
+			expectedNext: "2",
+		},
+		"After Seq": {
+			query:        EventQuery{After: 2, Limit: 2},
+			expectedSeqs: []int64{3, 4},
+		},
+		"After Last Event": {
+			query:        EventQuery{After: 9},
+			expectedSeqs: []int64{},
+		},
+		"Invalid Query": {
+			query:         EventQuery{After: -1, Limit: 501},
+			expectedError: "query after cannot be negative; query limit must be between 1 and 500",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			page, err := applyEventQuery(events, tt.query)
+			if tt.expectedError != "" {
+				if !errors.Is(err, ErrValidation) || err.Error() != tt.expectedError {
+					t.Fatalf("Validation error [%s] expected but was [%v]", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			seqs := []int64{}
+			for _, event := range page.Events {
+				seqs = append(seqs, event.Seq)
+			}
+			diff := cmp.Diff(tt.expectedSeqs, seqs)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			if page.Next != tt.expectedNext {
+				t.Fatalf("Expected next cursor [%s] but was [%s]", tt.expectedNext, page.Next)
+			}
+		})
+	}
+}
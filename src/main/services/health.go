@@ -0,0 +1,30 @@
+package services
+
+// The statuses a component can report when its health is checked
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+)
+
+// A ComponentHealth is the result of checking the health of a single component a TodoService depends upon. Composed of
+// the following fields:
+//
+// Name: The name of the component, e.g. "sqlite.connection"
+//
+// Status: Either HealthUp or HealthDown
+//
+// Detail: Why the component is unhealthy, empty when it is healthy
+type ComponentHealth struct {
+	Name   string `json:"Name"`
+	Status string `json:"Status"`
+	Detail string `json:"Detail,omitempty"`
+}
+
+// checkComponent runs check, describing the component named name as healthy if it succeeds
+func checkComponent(name string, check func() error) ComponentHealth {
+	err := check()
+	if err != nil {
+		return ComponentHealth{Name: name, Status: HealthDown, Detail: err.Error()}
+	}
+	return ComponentHealth{Name: name, Status: HealthUp}
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// AnonymousActor is the actor recorded against changes made with a context which does not carry one, see WithActor
+const AnonymousActor = "anonymous"
+
+type contextKey int
+
+const actorKey contextKey = iota
+
+// WithActor returns a copy of ctx carrying the actor label changes made through it are attributed to, which is recorded
+// in the Revision of each change. The label is recorded as given, it is up to the caller whether it was authenticated
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// ActorFrom returns the actor carried by ctx, or AnonymousActor if it does not carry one
+func ActorFrom(ctx context.Context) string {
+	if actor, _ := ctx.Value(actorKey).(string); actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
+
+// unrecordedFields lists the fields of a Todo item left out of the Changes of a Revision. Version and UpdatedAt change
+// on every revision, and Blocked is computed when Todo items are read rather than saved
+var unrecordedFields = []string{"Version", "UpdatedAt", "Blocked"}
+
+// newRevision builds the Revision recording action, made by the actor carried by ctx at now, which changed before into
+// after. before is nil when a Todo item is created and after is nil when one is purged. The Id of the Revision is left
+// for the backend to assign
+func newRevision(ctx context.Context, action models.RevisionAction, before *models.Todo, after *models.Todo, now time.Time) models.Revision {
+	snapshot := after
+	if snapshot == nil {
+		snapshot = before
+	}
+	return models.Revision{
+		TodoId:  snapshot.Id,
+		Version: snapshot.Version,
+		Action:  action,
+		Actor:   ActorFrom(ctx),
+		At:      now,
+		Changes: diffTodos(before, after),
+		Todo:    *snapshot,
+	}
+}
+
+// diffTodos returns the fields which differ between before and after, compared using their JSON representations and
+// sorted by field name. A nil Todo item has no fields set
+func diffTodos(before *models.Todo, after *models.Todo) []models.FieldChange {
+	oldFields, newFields := todoFields(before), todoFields(after)
+	names := make([]string, 0, len(oldFields)+len(newFields))
+	for name := range oldFields {
+		names = append(names, name)
+	}
+	for name := range newFields {
+		if _, ok := oldFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var changes []models.FieldChange
+	for _, name := range names {
+		if slices.Contains(unrecordedFields, name) || bytes.Equal(oldFields[name], newFields[name]) {
+			continue
+		}
+		changes = append(changes, models.FieldChange{Field: name, Old: oldFields[name], New: newFields[name]})
+	}
+	return changes
+}
+
+// todoFields returns the JSON representation of each field set on todo, keyed by field name
+func todoFields(todo *models.Todo) map[string]json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	if todo == nil {
+		return fields
+	}
+	// Marshalling a Todo item and unmarshalling it into a map cannot fail
+	data, _ := json.Marshal(todo)
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+// revertedTodo returns the Todo item with an id of id as it was after revision, ready to be saved by UpdateTodo. If
+// revision does not belong to that Todo item a NotFoundError is returned, and if it removed the Todo item a
+// ValidationError, as there is nothing to revert to
+func revertedTodo(revision models.Revision, id string) (models.Todo, error) {
+	if revision.TodoId != id {
+		return models.Todo{}, &NotFoundError{Resource: "revision", Id: strconv.FormatInt(revision.Id, 10)}
+	}
+	if revision.Action == models.ActionDelete || revision.Action == models.ActionPurge {
+		return models.Todo{}, &ValidationError{Resource: "revision", Fields: []FieldError{{Field: "Action",
+			Message: "cannot be reverted to as it removed the todo"}}}
+	}
+	reverted := revision.Todo
+	reverted.DeletedAt = nil
+	return reverted, nil
+}
+
+// AuditQuery describes which revisions QueryAudit returns. Composed of the following fields:
+//
+// Since: When set, only revisions made at or after it are returned
+//
+// Until: When set, only revisions made before it are returned
+//
+// Actor: When set, only revisions attributed to this actor label are returned
+//
+// Action: When set, only revisions recording it are returned
+//
+// Limit: The maximum number of revisions to return, defaults to DefaultPageLimit
+//
+// Cursor: The Next value of the previous page, used to continue from where it ended
+type AuditQuery struct {
+	Since  *time.Time
+	Until  *time.Time
+	Actor  string
+	Action models.RevisionAction
+	Limit  int
+	Cursor string
+}
+
+// An AuditPage is a single page of revisions returned by QueryAudit, in the order they were recorded. Next is an opaque
+// cursor which can be passed back in an AuditQuery to fetch the following page, it is empty when there are no further
+// revisions
+type AuditPage struct {
+	Revisions []models.Revision
+	Limit     int
+	Next      string
+}
+
+// applyAuditQuery filters and paginates revisions, which must be in the order they were recorded, according to query.
+// It is shared by every TodoService backend so that they all answer queries identically. If the query is invalid a
+// ValidationError is returned
+func applyAuditQuery(revisions []models.Revision, query AuditQuery) (AuditPage, error) {
+	var fields []FieldError
+	if query.Since != nil && query.Until != nil && !query.Until.After(*query.Since) {
+		fields = append(fields, FieldError{Field: "until", Message: "must be after since"})
+	}
+	if query.Action != "" && !slices.Contains(models.RevisionActions, query.Action) {
+		fields = append(fields, FieldError{Field: "action", Message: "must be one of create, update, delete, restore or purge"})
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultPageLimit
+	}
+	if query.Limit < 0 || query.Limit > MaxPageLimit {
+		fields = append(fields, FieldError{Field: "limit", Message: "must be between 1 and 500"})
+	}
+	var after int64
+	if query.Cursor != "" {
+		var err error
+		after, err = strconv.ParseInt(query.Cursor, 10, 64)
+		if err != nil || after < 1 {
+			fields = append(fields, FieldError{Field: "cursor", Message: "is not a valid cursor"})
+		}
+	}
+	if len(fields) > 0 {
+		return AuditPage{}, &ValidationError{Resource: "query", Fields: fields}
+	}
+
+	page := AuditPage{Revisions: []models.Revision{}, Limit: query.Limit}
+	// Revisions are ordered by their id, so those up to the cursor are skipped by a binary search rather than read, and
+	// following the audit log from the last revision seen costs nothing for the revisions before it
+	start, _ := slices.BinarySearchFunc(revisions, after+1, func(revision models.Revision, id int64) int {
+		return cmp.Compare(revision.Id, id)
+	})
+	for _, revision := range revisions[start:] {
+		if !matchesAudit(revision, query) {
+			continue
+		}
+		if len(page.Revisions) == query.Limit {
+			page.Next = strconv.FormatInt(page.Revisions[query.Limit-1].Id, 10)
+			break
+		}
+		page.Revisions = append(page.Revisions, revision)
+	}
+	return page, nil
+}
+
+// matchesAudit reports whether revision satisfies the filters of query
+func matchesAudit(revision models.Revision, query AuditQuery) bool {
+	if query.Since != nil && revision.At.Before(*query.Since) {
+		return false
+	}
+	if query.Until != nil && !revision.At.Before(*query.Until) {
+		return false
+	}
+	if query.Actor != "" && revision.Actor != query.Actor {
+		return false
+	}
+	return query.Action == "" || revision.Action == query.Action
+}
+
+// ReadRevisions calls read with each page of the revisions recorded by todoService after the one with an id of after, in
+// the order they were recorded, so that those following changes only read the revisions they have not yet seen. It
+// returns the id of the last revision read, which is after when there are none. If read returns an error no further
+// pages are read and it is returned alongside the id of the last revision of the page before
+func ReadRevisions(ctx context.Context, todoService TodoService, after int64, read func(revisions []models.Revision) error) (int64, error) {
+	for {
+		var cursor string
+		if after > 0 {
+			cursor = strconv.FormatInt(after, 10)
+		}
+		page, err := todoService.QueryAudit(ctx, AuditQuery{Limit: MaxPageLimit, Cursor: cursor})
+		if err != nil {
+			return after, err
+		}
+		if len(page.Revisions) > 0 {
+			err = read(page.Revisions)
+			if err != nil {
+				return after, err
+			}
+			after = page.Revisions[len(page.Revisions)-1].Id
+		}
+		if page.Next == "" {
+			return after, nil
+		}
+	}
+}
+
+// LatestRevision returns the id of the last revision recorded by todoService, or zero when there are none
+func LatestRevision(ctx context.Context, todoService TodoService) (int64, error) {
+	return ReadRevisions(ctx, todoService, 0, func([]models.Revision) error {
+		return nil
+	})
+}
+
+// NotifiedAction returns what revision did to its Todo item as notified to those following changes, such as the feed
+// and webhooks, which is one of create, update or delete. Restoring a Todo item from the trash creates it again, while
+// purging one is not notified as it had already been deleted, so false is returned
+func NotifiedAction(revision models.Revision) (models.RevisionAction, bool) {
+	switch revision.Action {
+	case models.ActionCreate, models.ActionRestore:
+		return models.ActionCreate, true
+	case models.ActionUpdate, models.ActionDelete:
+		return revision.Action, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+	"time"
+)
+
+// actions returns the action of each revision, in order
+func actions(revisions []models.Revision) []models.RevisionAction {
+	result := make([]models.RevisionAction, 0, len(revisions))
+	for _, revision := range revisions {
+		result = append(result, revision.Action)
+	}
+	return result
+}
+
+func TestDiffTodos(t *testing.T) {
+	deletedAt := testTime
+	tests := map[string]struct {
+		before   *models.Todo
+		after    *models.Todo
+		expected []models.FieldChange
+	}{
+		"Created": {
+			after: &models.Todo{Id: "1", Title: "Bake cake", Version: 1, CreatedAt: testTime, UpdatedAt: testTime},
+			expected: []models.FieldChange{
+				{Field: "Completed", New: json.RawMessage(`false`)},
+				{Field: "CreatedAt", New: json.RawMessage(`"2024-03-01T12:00:00Z"`)},
+				{Field: "Desc", New: json.RawMessage(`""`)},
+				{Field: "Id", New: json.RawMessage(`"1"`)},
+				{Field: "Title", New: json.RawMessage(`"Bake cake"`)},
+			},
+		},
+		"Updated": {
+			before: &models.Todo{Id: "1", Title: "Bake cake", Version: 1, Tags: []string{"home"}},
+			after:  &models.Todo{Id: "1", Title: "Bake bread", Version: 2, Priority: models.PriorityHigh, Blocked: true},
+			expected: []models.FieldChange{
+				{Field: "Priority", New: json.RawMessage(`"high"`)},
+				{Field: "Tags", Old: json.RawMessage(`["home"]`)},
+				{Field: "Title", Old: json.RawMessage(`"Bake cake"`), New: json.RawMessage(`"Bake bread"`)},
+			},
+		},
+		"Deleted": {
+			before: &models.Todo{Id: "1", Title: "Bake cake", Version: 1},
+			after:  &models.Todo{Id: "1", Title: "Bake cake", Version: 1, DeletedAt: &deletedAt},
+			expected: []models.FieldChange{
+				{Field: "DeletedAt", New: json.RawMessage(`"2024-03-01T12:00:00Z"`)},
+			},
+		},
+		"Only Managed Fields Changed": {
+			before: &models.Todo{Id: "1", Title: "Bake cake", Version: 1, UpdatedAt: testTime},
+			after:  &models.Todo{Id: "1", Title: "Bake cake", Version: 2, UpdatedAt: testTime.Add(time.Hour)},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			diff := cmp.Diff(tt.expected, diffTodos(tt.before, tt.after))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestApplyAuditQuery(t *testing.T) {
+	revisions := []models.Revision{
+		{Id: 1, TodoId: "1", Action: models.ActionCreate, Actor: "alice", At: testTime},
+		{Id: 2, TodoId: "1", Action: models.ActionUpdate, Actor: "bob", At: testTime.Add(time.Hour)},
+		{Id: 3, TodoId: "2", Action: models.ActionCreate, Actor: "alice", At: testTime.Add(2 * time.Hour)},
+		{Id: 4, TodoId: "1", Action: models.ActionDelete, Actor: "alice", At: testTime.Add(3 * time.Hour)},
+	}
+	since := testTime.Add(time.Hour)
+	until := testTime.Add(3 * time.Hour)
+	tests := map[string]struct {
+		query         AuditQuery
+		expectedIds   []int64
+		expectedNext  string
+		expectedError string
+	}{
+		"Every Revision": {
+			query:       AuditQuery{},
+			expectedIds: []int64{1, 2, 3, 4},
+		},
+		"Time Range": {
+			query:       AuditQuery{Since: &since, Until: &until},
+			expectedIds: []int64{2, 3},
+		},
+		"Actor And Action": {
+			query:       AuditQuery{Actor: "alice", Action: models.ActionCreate},
+			expectedIds: []int64{1, 3},
+		},
+		"First Page": {
+			query:        AuditQuery{Actor: "alice", Limit: 2},
+			expectedIds:  []int64{1, 3},
+			expectedNext: "3",
+		},
+		"Next Page": {
+			query:       AuditQuery{Actor: "alice", Limit: 2, Cursor: "3"},
+			expectedIds: []int64{4},
+		},
+		"Invalid Query": {
+			query: AuditQuery{Since: &until, Until: &since, Action: "archive", Limit: 501, Cursor: "last"},
+			expectedError: "query until must be after since; query action must be one of create, update, delete, restore or purge; " +
+				"query limit must be between 1 and 500; query cursor is not a valid cursor",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			page, err := applyAuditQuery(revisions, tt.query)
+			if tt.expectedError != "" {
+				if !errors.Is(err, ErrValidation) || err.Error() != tt.expectedError {
+					t.Fatalf("Validation error [%s] expected but was [%v]", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			revisionIds := []int64{}
+			for _, revision := range page.Revisions {
+				revisionIds = append(revisionIds, revision.Id)
+			}
+			diff := cmp.Diff(tt.expectedIds, revisionIds)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			if page.Next != tt.expectedNext {
+				t.Fatalf("Expected next cursor [%s] but was [%s]", tt.expectedNext, page.Next)
+			}
+		})
+	}
+}
+
+func TestHistoryAcrossBackends(t *testing.T) {
+	prerequisite := []models.Todo{{Id: "1", Title: "Bake cake"}, {Id: "2", Title: "Buy flour"}}
+	for name, service := range setupBackends(t, prerequisite) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			alice := WithActor(ctx, "alice")
+			_, err := service.UpdateTodo(alice, models.Todo{Id: "1", Title: "Bake bread"}, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = service.RenameTag(alice, "missing", "found")
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+			err = service.DeleteTodo(WithActor(ctx, "bob"), "1", nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = service.RestoreTodo(ctx, "1")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+
+			history, err := service.ReturnHistory(ctx, "1")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff([]models.RevisionAction{models.ActionCreate, models.ActionUpdate, models.ActionDelete,
+				models.ActionRestore}, actions(history))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			diff = cmp.Diff(models.Revision{Id: 3, TodoId: "1", Version: 2, Action: models.ActionUpdate, Actor: "alice",
+				At: testTime, Changes: []models.FieldChange{
+					{Field: "Title", Old: json.RawMessage(`"Bake cake"`), New: json.RawMessage(`"Bake bread"`)},
+				}, Todo: stamped(models.Todo{Id: "1", Title: "Bake bread", Version: 2})}, history[1])
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			if history[0].Actor != AnonymousActor || history[2].Actor != "bob" {
+				t.Fatalf("Expected the revisions to be made by [anonymous] and [bob] but were [%s] and [%s]",
+					history[0].Actor, history[2].Actor)
+			}
+
+			version := int64(3)
+			reverted, err := service.RevertTodo(alice, "1", history[0].Id, &version)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff(stamped(models.Todo{Id: "1", Title: "Bake cake", Version: 4}), reverted)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			_, err = service.RevertTodo(ctx, "1", history[0].Id, &version)
+			if !errors.Is(err, ErrPreconditionFailed) {
+				t.Fatalf("Precondition failed error expected but was [%v]", err)
+			}
+			_, err = service.RevertTodo(ctx, "1", history[2].Id, nil)
+			if !errors.Is(err, ErrValidation) || err.Error() != "revision Action cannot be reverted to as it removed the todo" {
+				t.Fatalf("Validation error expected but was [%v]", err)
+			}
+			_, err = service.RevertTodo(ctx, "2", history[0].Id, nil)
+			if !errors.Is(err, ErrNotFound) || err.Error() != "could not find revision with id [1]" {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+			_, err = service.RevertTodo(ctx, "1", 999, nil)
+			if !errors.Is(err, ErrNotFound) || err.Error() != "could not find revision with id [999]" {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+
+			// Revisions are kept once a Todo item has been purged
+			err = service.DeleteTodo(ctx, "2", nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = service.PurgeTodo(ctx, "2")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			history, err = service.ReturnHistory(ctx, "2")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff([]models.RevisionAction{models.ActionCreate, models.ActionDelete, models.ActionPurge}, actions(history))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			_, err = service.ReturnHistory(ctx, "missing")
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+
+			page, err := service.QueryAudit(ctx, AuditQuery{Actor: "alice"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff([]models.RevisionAction{models.ActionUpdate, models.ActionUpdate}, actions(page.Revisions))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			page, err = service.QueryAudit(ctx, AuditQuery{Limit: 3})
+			if err != nil || len(page.Revisions) != 3 || page.Next != "3" {
+				t.Fatalf("Expected the first [3] revisions but found %+v with error [%v]", page, err)
+			}
+		})
+	}
+}
+
+func TestHistoryRecordsCascadedChangesAcrossBackends(t *testing.T) {
+	dueAt := testTime
+	prerequisite := []models.Todo{
+		{Id: "release", Title: "Release", Tags: []string{"work"}},
+		{Id: "standup", Title: "Standup", DueAt: &dueAt, Recurrence: "FREQ=DAILY"},
+	}
+	for name, service := range setupBackends(t, prerequisite) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := service.RenameTag(ctx, "work", "job")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			standup, err := service.ReturnSingleTodo(ctx, "standup")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			standup.Completed = true
+			completed, err := service.UpdateTodo(ctx, standup, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+
+			history, err := service.ReturnHistory(ctx, "release")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff([]models.FieldChange{{Field: "Tags", Old: json.RawMessage(`["work"]`), New: json.RawMessage(`["job"]`)}},
+				history[len(history)-1].Changes)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			history, err = service.ReturnHistory(ctx, completed.NextId)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff([]models.RevisionAction{models.ActionCreate}, actions(history))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"container/list"
+	"context"
+	"slices"
+	"time"
+)
+
+// ListService is implemented by each of the backends able to persist lists of Todo items. As with TodoService, all
+// implementations are expected to behave identically
+//
+// Lists are versioned in the same way as Todo items, and the methods which change or remove a list accept an expected
+// version, returning a VersionMismatchError if the list is no longer at that version
+type ListService interface {
+	ReturnAllLists(ctx context.Context) ([]models.List, error)
+	ReturnSingleList(ctx context.Context, id string) (models.List, error)
+	CreateNewList(ctx context.Context, newList models.List) (models.List, error)
+	UpdateList(ctx context.Context, newList models.List, expectedVersion *int64) (models.List, error)
+	DeleteList(ctx context.Context, id string, mode ListDeleteMode, expectedVersion *int64) error
+	MoveTodo(ctx context.Context, id string, listId string, expectedVersion *int64) (models.Todo, error)
+}
+
+// Store is implemented by each backend, persisting Todo items alongside the lists they belong to so that changes
+// spanning both, such as deleting a list and its Todo items, are made atomically. Once each change has been committed
+// the observers registered with Observe are called, which is how the feed and webhooks learn of it
+type Store interface {
+	TodoService
+	ListService
+	Observe(observer func(ctx context.Context))
+}
+
+// A ListDeleteMode decides what happens to the Todo items within a list when it is deleted
+type ListDeleteMode string
+
+// The ways in which a list can be deleted
+const (
+	// ListDeleteDeny refuses to delete a list while Todo items belong to it, returning a ListNotEmptyError
+	ListDeleteDeny ListDeleteMode = "deny"
+	// ListDeleteCascade moves every Todo item which belongs to a list to the trash alongside deleting it. Todo items in
+	// other lists which were subtasks of, or depended on, those Todo items are kept with the references to them removed
+	ListDeleteCascade ListDeleteMode = "cascade"
+)
+
+// ReturnAllLists returns all lists currently persisted within the DB, in the order they were created
+func (service *TodoServiceImpl) ReturnAllLists(_ context.Context) ([]models.List, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	lists := make([]models.List, 0, service.listOrder.Len())
+	for element := service.listOrder.Front(); element != nil; element = element.Next() {
+		lists = append(lists, element.Value.(models.List))
+	}
+	return lists, nil
+}
+
+// ReturnSingleList returns a single list, identified via the id param. If no list is found with a matching id then a
+// NotFoundError is returned
+func (service *TodoServiceImpl) ReturnSingleList(_ context.Context, id string) (models.List, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	element, ok := service.lists[id]
+	if !ok {
+		return models.List{}, &NotFoundError{Resource: "list", Id: id}
+	}
+	return element.Value.(models.List), nil
+}
+
+// CreateNewList persists a new list in the DB, generating its id if none was supplied. If an existing list with a
+// matching id is found then a ConflictError is returned
+func (service *TodoServiceImpl) CreateNewList(ctx context.Context, newList models.List) (models.List, error) {
+	var err error
+	newList.Id, err = service.options.assignId("list", newList.Id)
+	if err != nil {
+		return models.List{}, err
+	}
+	err = validateList(newList)
+	if err != nil {
+		return models.List{}, err
+	}
+
+	defer service.lock(ctx)()
+	if _, exists := service.lists[newList.Id]; exists {
+		return models.List{}, &ConflictError{Resource: "list", Id: newList.Id}
+	}
+	now := service.options.now()
+	newList = stampListCreated(newList, now)
+	changes := newChangeSet(ctx, now)
+	changes.lists = append(changes.lists, newList)
+	err = service.commit(changes)
+	if err != nil {
+		return models.List{}, err
+	}
+	return newList, nil
+}
+
+// UpdateList updates the list with an id matching that of the list passed as a parameter. If no such list exists a
+// NotFoundError is returned
+func (service *TodoServiceImpl) UpdateList(ctx context.Context, newList models.List, expectedVersion *int64) (models.List, error) {
+	err := validateList(newList)
+	if err != nil {
+		return models.List{}, err
+	}
+
+	defer service.lock(ctx)()
+	element, ok := service.lists[newList.Id]
+	if !ok {
+		return models.List{}, &NotFoundError{Resource: "list", Id: newList.Id}
+	}
+	existing := element.Value.(models.List)
+	err = checkListVersion(existing, expectedVersion)
+	if err != nil {
+		return models.List{}, err
+	}
+	now := service.options.now()
+	newList = stampListRevised(existing, newList, now)
+	changes := newChangeSet(ctx, now)
+	changes.lists = append(changes.lists, newList)
+	err = service.commit(changes)
+	if err != nil {
+		return models.List{}, err
+	}
+	return newList, nil
+}
+
+// DeleteList removes the list with an id matching the id passed as a parameter, deciding what happens to the Todo items
+// within it according to mode. If no such list exists a NotFoundError is returned
+func (service *TodoServiceImpl) DeleteList(ctx context.Context, id string, mode ListDeleteMode, expectedVersion *int64) error {
+	defer service.lock(ctx)()
+	element, ok := service.lists[id]
+	if !ok {
+		return &NotFoundError{Resource: "list", Id: id}
+	}
+	existing := element.Value.(models.List)
+	err := checkListVersion(existing, expectedVersion)
+	if err != nil {
+		return err
+	}
+	var contained []*list.Element
+	for todo := service.order.Front(); todo != nil; todo = todo.Next() {
+		if todo.Value.(models.Todo).ListId == id {
+			contained = append(contained, todo)
+		}
+	}
+	if len(contained) > 0 && mode != ListDeleteCascade {
+		return &ListNotEmptyError{Id: id, Todos: len(contained)}
+	}
+	now := service.options.now()
+	changes := newChangeSet(ctx, now)
+	removed := make(map[string]bool, len(contained))
+	for _, todo := range contained {
+		removed[todo.Value.(models.Todo).Id] = true
+		changes.trash(ctx, todo.Value.(models.Todo))
+	}
+	for todo := service.order.Front(); todo != nil; todo = todo.Next() {
+		kept := todo.Value.(models.Todo)
+		if removed[kept.Id] {
+			continue
+		}
+		if detached, changed := detachTodo(kept, removed, now); changed {
+			changes.record(ctx, models.ActionUpdate, &kept, &detached)
+		}
+	}
+	changes.deletedLists = append(changes.deletedLists, existing)
+	return service.commit(changes)
+}
+
+// MoveTodo moves the Todo item with an id matching the id passed as a parameter into the list with an id matching
+// listId, or out of any list when listId is empty. A NotFoundError is returned if either the Todo item or the list does
+// not exist. Moving a Todo item into the list it already belongs to leaves it unchanged
+func (service *TodoServiceImpl) MoveTodo(ctx context.Context, id string, listId string, expectedVersion *int64) (models.Todo, error) {
+	defer service.lock(ctx)()
+	if _, ok := service.lists[listId]; listId != "" && !ok {
+		return models.Todo{}, &NotFoundError{Resource: "list", Id: listId}
+	}
+	element, ok := service.todos[id]
+	if !ok {
+		return models.Todo{}, &NotFoundError{Resource: "todo", Id: id}
+	}
+	existing := element.Value.(models.Todo)
+	err := checkVersion(existing, expectedVersion)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	now := service.options.now()
+	moved := moveTodo(existing, listId, now)
+	changes := newChangeSet(ctx, now)
+	if moved.Version != existing.Version {
+		changes.record(ctx, models.ActionUpdate, &existing, &moved)
+	}
+	err = service.commit(changes)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return moved, nil
+}
+
+// checkListId confirms the list a Todo item belongs to exists, returning a ValidationError if it does not. The caller
+// must hold the lock
+func (service *TodoServiceImpl) checkListId(todo models.Todo) error {
+	if _, ok := service.lists[todo.ListId]; todo.ListId != "" && !ok {
+		return unknownListError()
+	}
+	return nil
+}
+
+// validateList applies validation rules against a List object to confirm it is valid. If any rules are broken then a
+// ValidationError listing every invalid field is returned
+func validateList(todoList models.List) error {
+	var fields []FieldError
+	if todoList.Id == "" {
+		fields = append(fields, FieldError{Field: "Id", Message: "cannot be null"})
+	}
+	if todoList.Name == "" {
+		fields = append(fields, FieldError{Field: "Name", Message: "cannot be empty"})
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Resource: "list", Fields: fields}
+	}
+	return nil
+}
+
+// unknownListError is returned when a Todo item is saved belonging to a list which does not exist
+func unknownListError() error {
+	return &ValidationError{Resource: "todo", Fields: []FieldError{{Field: "ListId", Message: "does not reference an existing list"}}}
+}
+
+// stampListCreated sets the fields of a new list which are managed by the service, giving it its first version and
+// timestamping it with now
+func stampListCreated(todoList models.List, now time.Time) models.List {
+	todoList.Version = 1
+	todoList.CreatedAt = now
+	todoList.UpdatedAt = now
+	return todoList
+}
+
+// stampListRevised sets the fields of todoList which are managed by the service, where todoList is replacing existing
+func stampListRevised(existing models.List, todoList models.List, now time.Time) models.List {
+	todoList.Version = existing.Version + 1
+	todoList.CreatedAt = existing.CreatedAt
+	todoList.UpdatedAt = now
+	return todoList
+}
+
+// moveTodo returns todo moved into the list with an id of listId, giving it a new version unless it already belonged to
+// that list
+func moveTodo(todo models.Todo, listId string, now time.Time) models.Todo {
+	if todo.ListId == listId {
+		return todo
+	}
+	moved := todo
+	moved.ListId = listId
+	return stampRevised(todo, moved, now)
+}
+
+// detachTodo returns todo with any reference to a removed Todo item, as its parent or a prerequisite, dropped and a new
+// version. Used when Todo items are deleted alongside a list which todo does not belong to. The boolean returned is true
+// if todo was altered
+func detachTodo(todo models.Todo, removed map[string]bool, now time.Time) (models.Todo, bool) {
+	detached := todo
+	if removed[todo.ParentId] {
+		detached.ParentId = ""
+	}
+	detached.DependsOn = slices.DeleteFunc(slices.Clone(todo.DependsOn), func(id string) bool { return removed[id] })
+	if detached.ParentId == todo.ParentId && len(detached.DependsOn) == len(todo.DependsOn) {
+		return todo, false
+	}
+	detached.DependsOn = normaliseDependencies(detached.DependsOn)
+	return stampRevised(todo, detached, now), true
+}
+
+// checkListVersion confirms that existing is at the expected version, returning a VersionMismatchError if it is not. A
+// nil expected version matches every version
+func checkListVersion(existing models.List, expectedVersion *int64) error {
+	if expectedVersion != nil && *expectedVersion != existing.Version {
+		return &VersionMismatchError{Resource: "list", Id: existing.Id, Expected: *expectedVersion, Actual: existing.Version}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// listColumns lists the columns of the lists table read into a models.List by scanList, in the order it expects them
+const listColumns = "id, name, description, version, created_at, updated_at"
+
+// ReturnAllLists returns all lists currently persisted within the DB, in the order they were created
+func (service *SqliteTodoService) ReturnAllLists(ctx context.Context) ([]models.List, error) {
+	rows, err := service.db.QueryContext(ctx, "SELECT "+listColumns+" FROM lists ORDER BY seq")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []models.List{}
+	for rows.Next() {
+		todoList, err := scanList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, todoList)
+	}
+	return lists, rows.Err()
+}
+
+// ReturnSingleList returns a single list, identified via the id param. If no list is found with a matching id then a
+// NotFoundError is returned
+func (service *SqliteTodoService) ReturnSingleList(ctx context.Context, id string) (models.List, error) {
+	todoList, err := scanList(service.db.QueryRowContext(ctx, "SELECT "+listColumns+" FROM lists WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.List{}, &NotFoundError{Resource: "list", Id: id}
+	}
+	return todoList, err
+}
+
+// CreateNewList persists a new list in the DB, generating its id if none was supplied. If an existing list with a
+// matching id is found then a ConflictError is returned
+func (service *SqliteTodoService) CreateNewList(ctx context.Context, newList models.List) (models.List, error) {
+	var err error
+	newList.Id, err = service.options.assignId("list", newList.Id)
+	if err != nil {
+		return models.List{}, err
+	}
+	err = validateList(newList)
+	if err != nil {
+		return models.List{}, err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.List{}, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM lists WHERE id = ?)", newList.Id).Scan(&exists)
+	if err != nil {
+		return models.List{}, err
+	}
+	if exists {
+		return models.List{}, &ConflictError{Resource: "list", Id: newList.Id}
+	}
+	newList = stampListCreated(newList, service.options.now())
+	_, err = tx.ExecContext(ctx, "INSERT INTO lists ("+listColumns+") VALUES (?, ?, ?, ?, ?, ?)", newList.Id,
+		newList.Name, newList.Desc, newList.Version, formatTime(&newList.CreatedAt), formatTime(&newList.UpdatedAt))
+	if err != nil {
+		return models.List{}, err
+	}
+	return newList, service.commitTx(ctx, tx)
+}
+
+// UpdateList updates the list with an id matching that of the list passed as a parameter. If no such list exists a
+// NotFoundError is returned
+func (service *SqliteTodoService) UpdateList(ctx context.Context, newList models.List, expectedVersion *int64) (models.List, error) {
+	err := validateList(newList)
+	if err != nil {
+		return models.List{}, err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.List{}, err
+	}
+	defer tx.Rollback()
+
+	existing, err := selectList(ctx, tx, newList.Id)
+	if err != nil {
+		return models.List{}, err
+	}
+	err = checkListVersion(existing, expectedVersion)
+	if err != nil {
+		return models.List{}, err
+	}
+	newList = stampListRevised(existing, newList, service.options.now())
+	_, err = tx.ExecContext(ctx, "UPDATE lists SET name = ?, description = ?, version = ?, updated_at = ? WHERE id = ?",
+		newList.Name, newList.Desc, newList.Version, formatTime(&newList.UpdatedAt), newList.Id)
+	if err != nil {
+		return models.List{}, err
+	}
+	return newList, service.commitTx(ctx, tx)
+}
+
+// DeleteList removes the list with an id matching the id passed as a parameter, deciding what happens to the Todo items
+// within it according to mode. The list and any Todo items are removed within a single transaction. If no such list
+// exists a NotFoundError is returned
+func (service *SqliteTodoService) DeleteList(ctx context.Context, id string, mode ListDeleteMode, expectedVersion *int64) error {
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	existing, err := selectList(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	err = checkListVersion(existing, expectedVersion)
+	if err != nil {
+		return err
+	}
+	var contained int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM todos WHERE list_id = ?", id).Scan(&contained)
+	if err != nil {
+		return err
+	}
+	if contained > 0 && mode != ListDeleteCascade {
+		return &ListNotEmptyError{Id: id, Todos: contained}
+	}
+	removed, err := selectTodos(ctx, tx, "SELECT "+todoColumns+" FROM todos WHERE list_id = ?", id)
+	if err != nil {
+		return err
+	}
+	removedIds := make(map[string]bool, len(removed))
+	for _, todo := range removed {
+		removedIds[todo.Id] = true
+	}
+	remaining, err := selectTodos(ctx, tx, "SELECT "+todoColumns+" FROM todos WHERE list_id != ? ORDER BY seq", id)
+	if err != nil {
+		return err
+	}
+	now := service.options.now()
+	for _, todo := range remaining {
+		detached, changed := detachTodo(todo, removedIds, now)
+		if !changed {
+			continue
+		}
+		err = updateTodo(ctx, tx, detached)
+		if err != nil {
+			return err
+		}
+		err = recordRevision(ctx, tx, models.ActionUpdate, &todo, &detached, now)
+		if err != nil {
+			return err
+		}
+	}
+	for _, todo := range removed {
+		err = moveToTrash(ctx, tx, todo, now)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM lists WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return service.commitTx(ctx, tx)
+}
+
+// MoveTodo moves the Todo item with an id matching the id passed as a parameter into the list with an id matching
+// listId, or out of any list when listId is empty. A NotFoundError is returned if either the Todo item or the list does
+// not exist. Moving a Todo item into the list it already belongs to leaves it unchanged
+func (service *SqliteTodoService) MoveTodo(ctx context.Context, id string, listId string, expectedVersion *int64) (models.Todo, error) {
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	defer tx.Rollback()
+
+	if listId != "" {
+		_, err = selectList(ctx, tx, listId)
+		if err != nil {
+			return models.Todo{}, err
+		}
+	}
+	existing, err := selectTodo(ctx, tx, id)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkVersion(existing, expectedVersion)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	now := service.options.now()
+	moved := moveTodo(existing, listId, now)
+	if moved.Version == existing.Version {
+		return moved, nil
+	}
+	err = updateTodo(ctx, tx, moved)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = recordRevision(ctx, tx, models.ActionUpdate, &existing, &moved, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return moved, service.commitTx(ctx, tx)
+}
+
+// selectList reads the list with a matching id within tx, returning a NotFoundError if it does not exist
+func selectList(ctx context.Context, tx *sql.Tx, id string) (models.List, error) {
+	todoList, err := scanList(tx.QueryRowContext(ctx, "SELECT "+listColumns+" FROM lists WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.List{}, &NotFoundError{Resource: "list", Id: id}
+	}
+	return todoList, err
+}
+
+// checkListExists confirms the list a Todo item belongs to exists within tx, returning a ValidationError if it does not
+func checkListExists(ctx context.Context, tx *sql.Tx, todo models.Todo) error {
+	if todo.ListId == "" {
+		return nil
+	}
+	var exists bool
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM lists WHERE id = ?)", todo.ListId).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return unknownListError()
+	}
+	return nil
+}
+
+// scanList reads a single row containing the listColumns into a models.List
+func scanList(row interface{ Scan(dest ...any) error }) (models.List, error) {
+	var todoList models.List
+	var createdAt, updatedAt string
+	err := row.Scan(&todoList.Id, &todoList.Name, &todoList.Desc, &todoList.Version, &createdAt, &updatedAt)
+	if err != nil {
+		return models.List{}, err
+	}
+	todoList.CreatedAt, err = parseTime(createdAt)
+	if err == nil {
+		todoList.UpdatedAt, err = parseTime(updatedAt)
+	}
+	return todoList, err
+}
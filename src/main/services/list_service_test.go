@@ -0,0 +1,118 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestListsAcrossBackends(t *testing.T) {
+	for name, service := range setupBackends(t, []models.Todo{{Id: "1", Title: "Unlisted"}}) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := service.CreateNewList(ctx, models.List{Id: "sprint", Desc: "No name"})
+			if !errors.Is(err, ErrValidation) || err.Error() != "list Name cannot be empty" {
+				t.Fatalf("Validation error expected but was [%v]", err)
+			}
+			created, err := service.CreateNewList(ctx, models.List{Id: "sprint", Name: "Sprint 1"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff(models.List{Id: "sprint", Name: "Sprint 1", Version: 1, CreatedAt: testTime, UpdatedAt: testTime}, created)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			_, err = service.CreateNewList(ctx, models.List{Id: "sprint", Name: "Sprint 1"})
+			if !errors.Is(err, ErrConflict) {
+				t.Fatalf("Conflict error expected but was [%v]", err)
+			}
+			stale := int64(0)
+			_, err = service.UpdateList(ctx, models.List{Id: "sprint", Name: "Sprint 2"}, &stale)
+			if !errors.Is(err, ErrPreconditionFailed) {
+				t.Fatalf("Precondition failed error expected but was [%v]", err)
+			}
+			updated, err := service.UpdateList(ctx, models.List{Id: "sprint", Name: "Sprint 2"}, &created.Version)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if updated.Version != 2 || updated.Name != "Sprint 2" {
+				t.Fatalf("Unexpected list after update [%+v]", updated)
+			}
+
+			_, err = service.CreateNewTodo(ctx, models.Todo{Id: "2", Title: "Lost", ListId: "backlog"})
+			if !errors.Is(err, ErrValidation) || err.Error() != "todo ListId does not reference an existing list" {
+				t.Fatalf("Validation error expected but was [%v]", err)
+			}
+			_, err = service.CreateNewTodo(ctx, models.Todo{Id: "2", Title: "Listed", ListId: "sprint"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = service.MoveTodo(ctx, "1", "backlog", nil)
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+			moved, err := service.MoveTodo(ctx, "1", "sprint", nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if moved.ListId != "sprint" || moved.Version != 2 {
+				t.Fatalf("Unexpected todo after move [%+v]", moved)
+			}
+			listId := "sprint"
+			page, err := service.QueryTodos(ctx, TodoQuery{ListId: &listId})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff([]string{"1", "2"}, ids(page.Todos))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+
+			err = service.DeleteList(ctx, "sprint", ListDeleteDeny, nil)
+			if !errors.Is(err, ErrConflict) || err.Error() != "list with id [sprint] still contains [2] todo items" {
+				t.Fatalf("Conflict error expected but was [%v]", err)
+			}
+			err = service.DeleteList(ctx, "sprint", ListDeleteCascade, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			counts, err := service.CountTodos(ctx)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			lists, err := service.ReturnAllLists(ctx)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if counts.Total != 0 || len(lists) != 0 {
+				t.Fatalf("Expected the list and its todos to be deleted but found [%d] todos and lists %v", counts.Total, lists)
+			}
+			_, err = service.ReturnSingleList(ctx, "sprint")
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+		})
+	}
+}
+
+func TestDeleteEmptyListAcrossBackends(t *testing.T) {
+	for name, service := range setupBackends(t, []models.Todo{}) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := service.CreateNewList(ctx, models.List{Id: "empty", Name: "Empty"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = service.DeleteList(ctx, "empty", ListDeleteDeny, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = service.DeleteList(ctx, "empty", ListDeleteDeny, nil)
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+		})
+	}
+}
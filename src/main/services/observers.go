@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// observers holds the functions a Store calls once each change it makes has been committed, see Store.Observe. It is
+// embedded by every backend, so that the feed and the webhooks are notified of changes in the same way whichever
+// backend persists them
+type observers struct {
+	mu        sync.Mutex
+	observers []func(ctx context.Context)
+}
+
+// Observe registers observer to be called once each change to Todo items or lists has been committed, after the lock
+// or transaction making it has been released so that observer can read the revisions the change recorded. observer is
+// called by the goroutine which made the change, with the context it was made with
+func (observers *observers) Observe(observer func(ctx context.Context)) {
+	observers.mu.Lock()
+	defer observers.mu.Unlock()
+	observers.observers = append(observers.observers, observer)
+}
+
+// notify calls every observer, in the order they were registered
+func (observers *observers) notify(ctx context.Context) {
+	observers.mu.Lock()
+	registered := observers.observers
+	observers.mu.Unlock()
+	for _, observer := range registered {
+		observer(ctx)
+	}
+}
+
+// lock takes the write lock of the TodoServiceImpl for a change, returning the function which releases it. Once the lock
+// has been released the observers are notified if the change was committed. It is deferred by every method which can
+// change a Todo item or list
+func (service *TodoServiceImpl) lock(ctx context.Context) (unlock func()) {
+	service.mu.Lock()
+	return func() {
+		committed := service.committed
+		service.committed = false
+		service.mu.Unlock()
+		if committed {
+			service.notify(ctx)
+		}
+	}
+}
+
+// commitTx commits tx, which made a change to the DB, then notifies the observers of the SqliteTodoService
+func (service *SqliteTodoService) commitTx(ctx context.Context, tx *sql.Tx) error {
+	err := tx.Commit()
+	if err != nil {
+		return err
+	}
+	service.notify(ctx)
+	return nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestObserve(t *testing.T) {
+	for backend, store := range setupBackends(t, []models.Todo{{Id: "1", Title: "Bake cake"}}) {
+		t.Run(backend, func(t *testing.T) {
+			ctx := context.Background()
+			latest, err := LatestRevision(ctx, store)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			var actual []string
+			// Observers read the revisions recorded by the change, so are only called once it has been released
+			store.Observe(func(ctx context.Context) {
+				latest, err = ReadRevisions(ctx, store, latest, func(revisions []models.Revision) error {
+					for _, revision := range revisions {
+						actual = append(actual, string(revision.Action)+" "+revision.TodoId)
+					}
+					return nil
+				})
+				if err != nil {
+					t.Errorf("Error occured when none expected: [%v]", err)
+				}
+			})
+
+			_, err = store.CreateNewTodo(ctx, models.Todo{Id: "2", Title: "Buy flour"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			// A change which fails commits nothing, so the observers are not called
+			_, err = store.UpdateTodo(ctx, models.Todo{Id: "9", Title: "Walk dog"}, nil)
+			if err == nil {
+				t.Fatal("Error expected when none occured")
+			}
+			err = store.DeleteTodo(ctx, "1", nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+
+			diff := cmp.Diff([]string{"create 2", "delete 1"}, actual)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestNotifiedAction(t *testing.T) {
+	tests := map[string]struct {
+		action   models.RevisionAction
+		expected models.RevisionAction
+		notified bool
+	}{
+		"Create":  {action: models.ActionCreate, expected: models.ActionCreate, notified: true},
+		"Update":  {action: models.ActionUpdate, expected: models.ActionUpdate, notified: true},
+		"Delete":  {action: models.ActionDelete, expected: models.ActionDelete, notified: true},
+		"Restore": {action: models.ActionRestore, expected: models.ActionCreate, notified: true},
+		"Purge":   {action: models.ActionPurge},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			action, notified := NotifiedAction(models.Revision{Action: tt.action})
+			if action != tt.expected || notified != tt.notified {
+				t.Fatalf("unexpected action, expected [%v %v] but recieved [%v %v]", tt.expected, tt.notified, action, notified)
+			}
+		})
+	}
+}
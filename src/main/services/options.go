@@ -0,0 +1,50 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Options holds the behavioural settings shared by every TodoService backend. Composed of the following fields:
+//
+// AllowClientIds: Whether clients may choose the id of a new Todo item. When false any id supplied by the client is
+// rejected and one is always generated by the service
+//
+// Clock: Returns the current time, used to timestamp Todo items and decide which are overdue. Defaults to time.Now
+//
+// CompletionRule: What completing a Todo item with subtasks means. Defaults to CompletionIndependent
+type Options struct {
+	AllowClientIds bool
+	Clock          func() time.Time
+	CompletionRule CompletionRule
+}
+
+// now returns the current time according to the clock of the options, in UTC
+func (options Options) now() time.Time {
+	if options.Clock == nil {
+		return time.Now().UTC()
+	}
+	return options.Clock().UTC()
+}
+
+// assignId returns id if it was supplied by the client, or a generated id if it was not. Generated ids are UUIDv7
+// values, which sort in the order they were generated. If the client supplied an id and this is not permitted a
+// ValidationError for the resource is returned
+func (options Options) assignId(resource string, id string) (string, error) {
+	if id != "" {
+		if !options.AllowClientIds {
+			return "", &ValidationError{Resource: resource, Fields: []FieldError{{Field: "Id", Message: "cannot be set by the client"}}}
+		}
+		return id, nil
+	}
+	return options.generateId()
+}
+
+// generateId returns a new UUIDv7 id, used for resources created by the service itself
+func (options Options) generateId() (string, error) {
+	generated, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return generated.String(), nil
+}
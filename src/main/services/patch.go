@@ -0,0 +1,95 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"time"
+)
+
+// The media types of the patch documents which can be applied to Todo items
+const (
+	MergePatchType = "application/merge-patch+json"
+	JsonPatchType  = "application/json-patch+json"
+)
+
+// ErrInvalidPatch is matched by errors returned when a patch document cannot be applied to a Todo item
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// A TodoPatch is a patch document describing changes to a Todo item. Composed of the following fields:
+//
+// Type: The media type of the document, either MergePatchType (RFC 7396) or JsonPatchType (RFC 6902)
+//
+// Document: The raw JSON patch document
+type TodoPatch struct {
+	Type     string
+	Document []byte
+}
+
+// A PatchError is returned when a patch document is malformed or cannot be applied. It matches ErrInvalidPatch, unless
+// it was caused by a JSON Patch "test" operation failing, in which case it matches ErrConflict as the Todo item is not
+// in the state the client expected
+type PatchError struct {
+	Reason     string
+	TestFailed bool
+}
+
+func (err *PatchError) Error() string {
+	return fmt.Sprintf("patch could not be applied: %s", err.Reason)
+}
+
+func (err *PatchError) Is(target error) bool {
+	if err.TestFailed {
+		return target == ErrConflict
+	}
+	return target == ErrInvalidPatch
+}
+
+// applyPatch applies patch to existing, returning the patched Todo item, at the next version and updated at now, once it
+// has passed validation. It is shared by every TodoService backend, which are responsible for making the read, patch and
+// write atomic
+func applyPatch(existing models.Todo, patch TodoPatch, now time.Time) (models.Todo, error) {
+	original, err := json.Marshal(existing)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	var patched []byte
+	switch patch.Type {
+	case MergePatchType:
+		patched, err = jsonpatch.MergePatch(original, patch.Document)
+	case JsonPatchType:
+		var operations jsonpatch.Patch
+		operations, err = jsonpatch.DecodePatch(patch.Document)
+		if err == nil {
+			patched, err = operations.Apply(original)
+		}
+	default:
+		return models.Todo{}, &PatchError{Reason: fmt.Sprintf("unsupported patch type [%s]", patch.Type)}
+	}
+	if err != nil {
+		return models.Todo{}, &PatchError{Reason: err.Error(), TestFailed: errors.Is(err, jsonpatch.ErrTestFailed)}
+	}
+
+	var result models.Todo
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&result)
+	if err != nil {
+		return models.Todo{}, &PatchError{Reason: err.Error()}
+	}
+	if result.Id != existing.Id {
+		return models.Todo{}, &ValidationError{Resource: "todo", Fields: []FieldError{{Field: "Id", Message: "cannot be changed"}}}
+	}
+	// The version and timestamps are managed by the service, so any change made to them by the patch is discarded
+	result = stampRevised(existing, result, now)
+	result = normaliseTodo(result)
+	err = validateTodo(result)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return result, nil
+}
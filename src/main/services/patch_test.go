@@ -0,0 +1,118 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	existing := models.Todo{Id: "1", Title: "Example Title", Desc: "Example Description", Completed: false, CreatedAt: testTime}
+	tests := map[string]struct {
+		patch         TodoPatch
+		expected      models.Todo
+		expectedError error
+	}{
+		"Merge Patch Toggles Completed": {
+			patch:    TodoPatch{Type: MergePatchType, Document: []byte(`{"Completed": true}`)},
+			expected: stamped(models.Todo{Id: "1", Title: "Example Title", Desc: "Example Description", Completed: true, Version: 1}),
+		},
+		"Merge Patch Null Resets Field": {
+			patch:    TodoPatch{Type: MergePatchType, Document: []byte(`{"Desc": null, "Title": "Updated Title"}`)},
+			expected: stamped(models.Todo{Id: "1", Title: "Updated Title", Completed: false, Version: 1}),
+		},
+		"Json Patch Replaces Fields": {
+			patch: TodoPatch{Type: JsonPatchType, Document: []byte(
+				`[{"op": "replace", "path": "/Title", "value": "Updated Title"}, {"op": "replace", "path": "/Completed", "value": true}]`)},
+			expected: stamped(models.Todo{Id: "1", Title: "Updated Title", Desc: "Example Description", Completed: true, Version: 1}),
+		},
+		"Json Patch Passing Test Is Applied": {
+			patch: TodoPatch{Type: JsonPatchType, Document: []byte(
+				`[{"op": "test", "path": "/Completed", "value": false}, {"op": "replace", "path": "/Completed", "value": true}]`)},
+			expected: stamped(models.Todo{Id: "1", Title: "Example Title", Desc: "Example Description", Completed: true, Version: 1}),
+		},
+		"Json Patch Failing Test Is A Conflict": {
+			patch: TodoPatch{Type: JsonPatchType, Document: []byte(
+				`[{"op": "test", "path": "/Completed", "value": true}, {"op": "replace", "path": "/Title", "value": "Updated"}]`)},
+			expectedError: ErrConflict,
+		},
+		"Malformed Json Patch": {
+			patch:         TodoPatch{Type: JsonPatchType, Document: []byte(`{"op": "replace"}`)},
+			expectedError: ErrInvalidPatch,
+		},
+		"Malformed Merge Patch": {
+			patch:         TodoPatch{Type: MergePatchType, Document: []byte(`{"Title":`)},
+			expectedError: ErrInvalidPatch,
+		},
+		"Unknown Field": {
+			patch:         TodoPatch{Type: MergePatchType, Document: []byte(`{"Colour": "red"}`)},
+			expectedError: ErrInvalidPatch,
+		},
+		"Field With Wrong Type": {
+			patch:         TodoPatch{Type: MergePatchType, Document: []byte(`{"Completed": "yes"}`)},
+			expectedError: ErrInvalidPatch,
+		},
+		"Unsupported Patch Type": {
+			patch:         TodoPatch{Type: "application/json", Document: []byte(`{"Completed": true}`)},
+			expectedError: ErrInvalidPatch,
+		},
+		"Id Cannot Be Changed": {
+			patch:         TodoPatch{Type: MergePatchType, Document: []byte(`{"Id": "2"}`)},
+			expectedError: ErrValidation,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := applyPatch(existing, tt.patch, testTime)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Fatalf("Error not as expected, expected [%v] but was [%v]", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff(tt.expected, actual)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestPatchTodoAcrossBackends(t *testing.T) {
+	prerequisite := []models.Todo{{Id: "1", Title: "Example Title", Desc: "Example Description"}}
+	backends := setupBackends(t, prerequisite)
+
+	for name, service := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := service.PatchTodo(ctx, "2", TodoPatch{Type: MergePatchType, Document: []byte(`{"Completed": true}`)}, nil)
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+			_, err = service.PatchTodo(ctx, "1", TodoPatch{Type: MergePatchType, Document: []byte(`{"Id": ""}`)}, nil)
+			if !errors.Is(err, ErrValidation) {
+				t.Fatalf("Validation error expected but was [%v]", err)
+			}
+
+			patched, err := service.PatchTodo(ctx, "1", TodoPatch{Type: MergePatchType, Document: []byte(`{"Completed": true}`)}, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			persisted, err := service.ReturnSingleTodo(ctx, "1")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			expected := stamped(models.Todo{Id: "1", Title: "Example Title", Desc: "Example Description", Completed: true, Version: 2})
+			diff := cmp.Diff(expected, patched) + cmp.Diff(expected, persisted)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPageLimit is the number of Todo items returned in a page when no limit is requested
+	DefaultPageLimit = 50
+	// MaxPageLimit is the largest number of Todo items which can be returned in a single page
+	MaxPageLimit = 500
+)
+
+// The fields Todo items can be sorted by. Todo items are always sorted by their id as a tie-breaker, and as generated ids
+// sort in the order they were created, SortById returns Todo items in creation order
+const (
+	SortById        = "id"
+	SortByTitle     = "title"
+	SortByCompleted = "completed"
+	SortByCreated   = "created"
+	SortByUpdated   = "updated"
+	SortByDue       = "due"
+	SortByPriority  = "priority"
+)
+
+// sortableTime is the layout timestamps are formatted with, once converted to UTC, to produce keys which sort in
+// chronological order
+const sortableTime = "2006-01-02T15:04:05.000000000"
+
+// noDueDate is the key given to Todo items without a due date when sorting by it, sorting them after every Todo item
+// which has one
+const noDueDate = "~"
+
+// sortKeys contains, for each field Todo items can be sorted by, a function returning a key for the Todo item which
+// sorts lexicographically in the same order as the field. Keys rather than comparators are used so that the position of
+// the last Todo item in a page can be recorded within the cursor for the next page
+var sortKeys = map[string]func(todo models.Todo) string{
+	SortById:    func(todo models.Todo) string { return "" },
+	SortByTitle: func(todo models.Todo) string { return strings.ToLower(todo.Title) },
+	SortByCompleted: func(todo models.Todo) string {
+		if todo.Completed {
+			return "1"
+		}
+		return "0"
+	},
+	SortByCreated: func(todo models.Todo) string { return todo.CreatedAt.UTC().Format(sortableTime) },
+	SortByUpdated: func(todo models.Todo) string { return todo.UpdatedAt.UTC().Format(sortableTime) },
+	SortByDue: func(todo models.Todo) string {
+		if todo.DueAt == nil {
+			return noDueDate
+		}
+		return todo.DueAt.UTC().Format(sortableTime)
+	},
+	SortByPriority: func(todo models.Todo) string {
+		return strconv.Itoa(slices.Index(models.Priorities, todo.Priority))
+	},
+}
+
+// A TodoQuery describes which Todo items should be returned by QueryTodos, and in what order. Composed of the
+// following fields:
+//
+// Completed: When set, only Todo items with a matching Completed value are returned
+//
+// Search: When set, only Todo items whose title or description contain it, ignoring case, are returned
+//
+// ListId: When set, only Todo items which belong to the list with this id are returned
+//
+// Priority: When set, only Todo items with a matching Priority are returned
+//
+// Tags: When set, only Todo items with every one of these tags are returned, or any one of them if AnyTag is set
+//
+// AnyTag: Whether Todo items need only have one of the Tags, rather than all of them
+//
+// DueBefore: When set, only Todo items due before it are returned
+//
+// DueAfter: When set, only Todo items due after it are returned
+//
+// Overdue: When set, only Todo items whose Overdue value, at the current time, matches are returned
+//
+// Ready: When set, only Todo items which are, or are not, ready to be worked on are returned. A Todo item is ready when
+// it is open and not Blocked
+//
+// SortBy: The field to sort Todo items by, defaults to SortById
+//
+// Descending: Whether Todo items are sorted in descending rather than ascending order
+//
+// Limit: The maximum number of Todo items to return, defaults to DefaultPageLimit
+//
+// Cursor: The Next value of the previous page, used to continue from where it ended
+type TodoQuery struct {
+	Completed  *bool
+	Search     string
+	ListId     *string
+	Priority   *models.Priority
+	Tags       []string
+	AnyTag     bool
+	DueBefore  *time.Time
+	DueAfter   *time.Time
+	Overdue    *bool
+	Ready      *bool
+	SortBy     string
+	Descending bool
+	Limit      int
+	Cursor     string
+}
+
+// A TodoPage is a single page of Todo items returned by QueryTodos. Next is an opaque cursor which can be passed back in
+// a TodoQuery to fetch the following page, it is empty when there are no further Todo items
+type TodoPage struct {
+	Todos []models.Todo
+	Limit int
+	Next  string
+}
+
+// pageCursor is the decoded form of the cursor returned within a TodoPage. It records the sort order in use and the
+// position of the last Todo item returned, so the next page starts after it even if Todo items are added or removed
+type pageCursor struct {
+	SortBy     string `json:"s"`
+	Descending bool   `json:"d"`
+	Key        string `json:"k"`
+	Id         string `json:"i"`
+}
+
+// applyQuery filters, sorts and paginates todos according to query, deciding which are overdue against now. todos must
+// contain every Todo item, so that which of them are Blocked can be computed. It is shared by the TodoService backends
+// holding every Todo item in memory, while SqliteTodoService runs the equivalent statement built by todoQueryStatement.
+// If the query is invalid a ValidationError is returned
+func applyQuery(todos []models.Todo, query TodoQuery, now time.Time) (TodoPage, error) {
+	query, after, err := normaliseQuery(query)
+	if err != nil {
+		return TodoPage{}, err
+	}
+	todos = markBlocked(todos)
+	sortKey := sortKeys[query.SortBy]
+	// less reports whether a Todo item with key a and id aId belongs before one with key b and id bId
+	less := func(a string, aId string, b string, bId string) bool {
+		if a != b {
+			return (a < b) != query.Descending
+		}
+		if aId != bId {
+			return (aId < bId) != query.Descending
+		}
+		return false
+	}
+
+	matches := make([]models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		if !matchesQuery(todo, query, now) {
+			continue
+		}
+		if after != nil && !less(after.Key, after.Id, sortKey(todo), todo.Id) {
+			continue
+		}
+		matches = append(matches, todo)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return less(sortKey(matches[i]), matches[i].Id, sortKey(matches[j]), matches[j].Id)
+	})
+
+	return pageOf(matches, query), nil
+}
+
+// pageOf returns the page of a normalised query made up of the first of matches, which are sorted as it requests. The
+// page includes a cursor for the following page when there are more matches than its limit
+func pageOf(matches []models.Todo, query TodoQuery) TodoPage {
+	page := TodoPage{Todos: matches, Limit: query.Limit}
+	if len(matches) > query.Limit {
+		page.Todos = matches[:query.Limit]
+		last := page.Todos[query.Limit-1]
+		page.Next = encodeCursor(pageCursor{query.SortBy, query.Descending, sortKeys[query.SortBy](last), last.Id})
+	}
+	return page
+}
+
+// normaliseQuery applies defaults to query and validates it, decoding its cursor if one was supplied
+func normaliseQuery(query TodoQuery) (TodoQuery, *pageCursor, error) {
+	var fields []FieldError
+	if query.SortBy == "" {
+		query.SortBy = SortById
+	}
+	if _, ok := sortKeys[query.SortBy]; !ok {
+		fields = append(fields, FieldError{Field: "sort", Message: "is not a sortable field"})
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultPageLimit
+	}
+	if query.Limit < 0 || query.Limit > MaxPageLimit {
+		fields = append(fields, FieldError{Field: "limit", Message: "must be between 1 and 500"})
+	}
+	if query.Priority != nil && !slices.Contains(models.Priorities, *query.Priority) {
+		fields = append(fields, FieldError{Field: "priority", Message: "must be one of low, medium or high"})
+	}
+	query.Tags = normaliseTags(query.Tags)
+
+	var after *pageCursor
+	if query.Cursor != "" {
+		cursor, err := decodeCursor(query.Cursor)
+		if err != nil {
+			fields = append(fields, FieldError{Field: "cursor", Message: "is not a valid cursor"})
+		} else if cursor.SortBy != query.SortBy || cursor.Descending != query.Descending {
+			fields = append(fields, FieldError{Field: "cursor", Message: "does not match the requested sort order"})
+		} else {
+			after = &cursor
+		}
+	}
+
+	if len(fields) > 0 {
+		return TodoQuery{}, nil, &ValidationError{Resource: "query", Fields: fields}
+	}
+	return query, after, nil
+}
+
+// matchesQuery reports whether todo satisfies the filters of query at the time now
+func matchesQuery(todo models.Todo, query TodoQuery, now time.Time) bool {
+	if query.Completed != nil && todo.Completed != *query.Completed {
+		return false
+	}
+	if query.ListId != nil && todo.ListId != *query.ListId {
+		return false
+	}
+	if query.Priority != nil && todo.Priority != *query.Priority {
+		return false
+	}
+	if len(query.Tags) > 0 {
+		hasTag := func(tag string) bool { return slices.Contains(todo.Tags, tag) }
+		if query.AnyTag && !slices.ContainsFunc(query.Tags, hasTag) {
+			return false
+		}
+		if !query.AnyTag && slices.ContainsFunc(query.Tags, func(tag string) bool { return !hasTag(tag) }) {
+			return false
+		}
+	}
+	if query.DueBefore != nil && (todo.DueAt == nil || !todo.DueAt.Before(*query.DueBefore)) {
+		return false
+	}
+	if query.DueAfter != nil && (todo.DueAt == nil || !todo.DueAt.After(*query.DueAfter)) {
+		return false
+	}
+	if query.Overdue != nil && todo.Overdue(now) != *query.Overdue {
+		return false
+	}
+	if query.Ready != nil && (!todo.Completed && !todo.Blocked) != *query.Ready {
+		return false
+	}
+	if query.Search != "" {
+		search := strings.ToLower(query.Search)
+		if !strings.Contains(strings.ToLower(todo.Title), search) && !strings.Contains(strings.ToLower(todo.Desc), search) {
+			return false
+		}
+	}
+	return true
+}
+
+func encodeCursor(cursor pageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string) (pageCursor, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return pageCursor{}, err
+	}
+	err = json.Unmarshal(data, &cursor)
+	return cursor, err
+}
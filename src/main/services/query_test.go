@@ -0,0 +1,315 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+	"time"
+)
+
+var queryTodos = []models.Todo{
+	{Id: "1", Title: "Walk dog", Desc: "Walk the dog around the town", Completed: false, Tags: []string{"pets"}},
+	{Id: "2", Title: "bake cake", Desc: "Bake a carrot cake", Completed: true, Tags: []string{"baking", "kitchen"}},
+	{Id: "3", Title: "Iron shirts", Desc: "Iron shirts that are in the dryer", Completed: false},
+	{Id: "4", Title: "Buy carrots", Desc: "For the cake", Completed: true, Tags: []string{"baking", "shopping"}},
+}
+
+func ids(todos []models.Todo) []string {
+	result := make([]string, 0, len(todos))
+	for _, todo := range todos {
+		result = append(result, todo.Id)
+	}
+	return result
+}
+
+func TestApplyQuery(t *testing.T) {
+	completed := true
+	open := false
+	tests := map[string]struct {
+		query                TodoQuery
+		expectedIds          []string
+		expectNext           bool
+		errorExpected        bool
+		expectedErrorMessage string
+	}{
+		"Defaults To Id Order": {
+			query:       TodoQuery{},
+			expectedIds: []string{"1", "2", "3", "4"},
+		},
+		"Filter Completed": {
+			query:       TodoQuery{Completed: &completed},
+			expectedIds: []string{"2", "4"},
+		},
+		"Filter Not Completed": {
+			query:       TodoQuery{Completed: &open},
+			expectedIds: []string{"1", "3"},
+		},
+		"Search Title And Description Ignoring Case": {
+			query:       TodoQuery{Search: "CAKE"},
+			expectedIds: []string{"2", "4"},
+		},
+		"Filter All Tags": {
+			query:       TodoQuery{Tags: []string{"Baking", "kitchen"}},
+			expectedIds: []string{"2"},
+		},
+		"Filter Any Tag": {
+			query:       TodoQuery{Tags: []string{"kitchen", "shopping", "pets"}, AnyTag: true},
+			expectedIds: []string{"1", "2", "4"},
+		},
+		"Sort By Title Ignoring Case": {
+			query:       TodoQuery{SortBy: SortByTitle},
+			expectedIds: []string{"2", "4", "3", "1"},
+		},
+		"Sort By Completed Descending Breaks Ties By Id": {
+			query:       TodoQuery{SortBy: SortByCompleted, Descending: true},
+			expectedIds: []string{"4", "2", "3", "1"},
+		},
+		"Limit Returns Cursor": {
+			query:       TodoQuery{Limit: 3},
+			expectedIds: []string{"1", "2", "3"},
+			expectNext:  true,
+		},
+		"Limit Matching Result Size Returns No Cursor": {
+			query:       TodoQuery{Limit: 4},
+			expectedIds: []string{"1", "2", "3", "4"},
+		},
+		"Unknown Sort Field": {
+			query:                TodoQuery{SortBy: "colour"},
+			errorExpected:        true,
+			expectedErrorMessage: "query sort is not a sortable field",
+		},
+		"Limit Too Large": {
+			query:                TodoQuery{Limit: MaxPageLimit + 1},
+			errorExpected:        true,
+			expectedErrorMessage: "query limit must be between 1 and 500",
+		},
+		"Invalid Cursor": {
+			query:                TodoQuery{Cursor: "not a cursor"},
+			errorExpected:        true,
+			expectedErrorMessage: "query cursor is not a valid cursor",
+		},
+		"Cursor From Different Sort Order": {
+			query:                TodoQuery{Cursor: encodeCursor(pageCursor{SortBy: SortByTitle, Id: "1"})},
+			errorExpected:        true,
+			expectedErrorMessage: "query cursor does not match the requested sort order",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			page, err := applyQuery(queryTodos, tt.query, testTime)
+			if tt.errorExpected {
+				if !errors.Is(err, ErrValidation) || err.Error() != tt.expectedErrorMessage {
+					t.Fatalf("Error message not as expected, expected [%v] but was [%v]", tt.expectedErrorMessage, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff(tt.expectedIds, ids(page.Todos))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			if tt.expectNext != (page.Next != "") {
+				t.Fatalf("Unexpected next cursor [%v]", page.Next)
+			}
+		})
+	}
+}
+
+func TestApplyQueryPagesThroughAllTodos(t *testing.T) {
+	tests := map[string]TodoQuery{
+		"Id Order":                 {Limit: 1},
+		"Title Order":              {Limit: 2, SortBy: SortByTitle},
+		"Completed Order Reversed": {Limit: 3, SortBy: SortByCompleted, Descending: true},
+	}
+
+	for name, query := range tests {
+		t.Run(name, func(t *testing.T) {
+			expected, err := applyQuery(queryTodos, TodoQuery{SortBy: query.SortBy, Descending: query.Descending}, testTime)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			var actual []models.Todo
+			for {
+				page, err := applyQuery(queryTodos, query, testTime)
+				if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+				actual = append(actual, page.Todos...)
+				if page.Next == "" {
+					break
+				}
+				query.Cursor = page.Next
+			}
+			diff := cmp.Diff(ids(expected.Todos), ids(actual))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestQueryTodosAcrossBackends(t *testing.T) {
+	backends := setupBackends(t, queryTodos)
+	completed := true
+
+	for name, service := range backends {
+		t.Run(name, func(t *testing.T) {
+			page, err := service.QueryTodos(context.Background(), TodoQuery{Completed: &completed, SortBy: SortByTitle, Limit: 1})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff([]string{"2"}, ids(page.Todos))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			page, err = service.QueryTodos(context.Background(), TodoQuery{Completed: &completed, SortBy: SortByTitle, Limit: 1, Cursor: page.Next})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff([]string{"4"}, ids(page.Todos))
+			if diff != "" || page.Next != "" {
+				t.Fatalf("Unexpected final page %v with next cursor [%v]", ids(page.Todos), page.Next)
+			}
+		})
+	}
+}
+
+func TestApplyQueryDueDatesAndPriorities(t *testing.T) {
+	yesterday := testTime.AddDate(0, 0, -1)
+	tomorrow := testTime.AddDate(0, 0, 1)
+	high := models.PriorityHigh
+	unknown := models.Priority("urgent")
+	overdue := true
+	todos := []models.Todo{
+		{Id: "1", Title: "No deadline", Priority: models.PriorityLow},
+		{Id: "2", Title: "Late", DueAt: &yesterday, Priority: models.PriorityHigh},
+		{Id: "3", Title: "Done late", DueAt: &yesterday, Completed: true},
+		{Id: "4", Title: "Upcoming", DueAt: &tomorrow, Priority: models.PriorityMedium},
+	}
+	tests := map[string]struct {
+		query                TodoQuery
+		expectedIds          []string
+		errorExpected        bool
+		expectedErrorMessage string
+	}{
+		"Filter Overdue": {
+			query:       TodoQuery{Overdue: &overdue},
+			expectedIds: []string{"2"},
+		},
+		"Filter Priority": {
+			query:       TodoQuery{Priority: &high},
+			expectedIds: []string{"2"},
+		},
+		"Filter Due Before": {
+			query:       TodoQuery{DueBefore: &testTime},
+			expectedIds: []string{"2", "3"},
+		},
+		"Filter Due After": {
+			query:       TodoQuery{DueAfter: &testTime},
+			expectedIds: []string{"4"},
+		},
+		"Sort By Due Date Puts Todos Without One Last": {
+			query:       TodoQuery{SortBy: SortByDue},
+			expectedIds: []string{"2", "3", "4", "1"},
+		},
+		"Sort By Priority Descending": {
+			query:       TodoQuery{SortBy: SortByPriority, Descending: true},
+			expectedIds: []string{"2", "4", "1", "3"},
+		},
+		"Unknown Priority": {
+			query:                TodoQuery{Priority: &unknown},
+			errorExpected:        true,
+			expectedErrorMessage: "query priority must be one of low, medium or high",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			page, err := applyQuery(todos, tt.query, testTime)
+			if tt.errorExpected {
+				if !errors.Is(err, ErrValidation) || err.Error() != tt.expectedErrorMessage {
+					t.Fatalf("Error message not as expected, expected [%v] but was [%v]", tt.expectedErrorMessage, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff(tt.expectedIds, ids(page.Todos))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestQueryTodosPagesAlikeAcrossBackends(t *testing.T) {
+	yesterday := testTime.AddDate(0, 0, -1).In(time.FixedZone("Pacific", -8*60*60))
+	tomorrow := testTime.AddDate(0, 0, 1).In(time.FixedZone("Tokyo", 9*60*60))
+	backends := setupBackends(t, []models.Todo{
+		{Id: "1", Title: "Éclairs", Priority: models.PriorityLow, Tags: []string{"baking"}},
+		{Id: "2", Title: "eggs", DueAt: &yesterday, Priority: models.PriorityHigh, Tags: []string{"shopping"}},
+		{Id: "3", Title: "Flour", DueAt: &yesterday, Completed: true, DependsOn: []string{"1"}},
+		{Id: "4", Title: "Dough", DueAt: &tomorrow, Priority: models.PriorityMedium, DependsOn: []string{"3"},
+			Tags: []string{"baking", "shopping"}},
+		{Id: "5", Title: "Oven", DependsOn: []string{"2"}},
+	})
+	earlier := yesterday.Add(-time.Hour)
+	ready := true
+	overdue := false
+	queries := map[string]TodoQuery{
+		"Id Order":                    {Limit: 2},
+		"Title Order Ignoring Case":   {Limit: 2, SortBy: SortByTitle},
+		"Due Order Reversed":          {Limit: 2, SortBy: SortByDue, Descending: true},
+		"Priority Order":              {Limit: 3, SortBy: SortByPriority},
+		"Created Order":               {Limit: 1, SortBy: SortByCreated},
+		"Ready":                       {Limit: 1, Ready: &ready},
+		"Not Overdue Sorted By Title": {Limit: 2, Overdue: &overdue, SortBy: SortByTitle},
+		"Any Tag Searching":           {Limit: 1, Tags: []string{"baking", "shopping"}, AnyTag: true, Search: "É"},
+		"Due Between":                 {Limit: 1, DueAfter: &earlier, DueBefore: &testTime},
+	}
+
+	for name, query := range queries {
+		t.Run(name, func(t *testing.T) {
+			expected, err := backends["Memory"].QueryTodos(context.Background(),
+				TodoQuery{SortBy: query.SortBy, Descending: query.Descending, Completed: query.Completed,
+					Ready: query.Ready, Overdue: query.Overdue, Tags: query.Tags, AnyTag: query.AnyTag,
+					Search: query.Search, DueAfter: query.DueAfter, DueBefore: query.DueBefore})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			for backend, service := range backends {
+				var actual []models.Todo
+				query := query
+				for {
+					page, err := service.QueryTodos(context.Background(), query)
+					if err != nil {
+						t.Fatalf("Error occured when none expected: [%v]", err)
+					}
+					actual = append(actual, page.Todos...)
+					if page.Next == "" {
+						break
+					}
+					query.Cursor = page.Next
+				}
+				// The Blocked value of each Todo item is computed by the query, so is compared alongside its id
+				blocked := func(todos []models.Todo) map[string]bool {
+					result := make(map[string]bool, len(todos))
+					for _, todo := range todos {
+						result[todo.Id] = todo.Blocked
+					}
+					return result
+				}
+				diff := cmp.Diff(ids(expected.Todos), ids(actual)) + cmp.Diff(blocked(expected.Todos), blocked(actual))
+				if diff != "" {
+					t.Fatalf("%v: %v", backend, diff)
+				}
+			}
+		})
+	}
+}
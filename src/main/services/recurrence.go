@@ -0,0 +1,375 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The frequencies a recurrence rule can repeat at
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+// MaxPreviewOccurrences is the largest number of occurrences which can be previewed at once
+const MaxPreviewOccurrences = 100
+
+// maxRecurrenceSteps bounds the number of periods searched for the next occurrence, so that a rule which can never
+// produce another occurrence, such as the 5th Monday of every 12th month, cannot loop forever
+const maxRecurrenceSteps = 1000
+
+// weekdays maps the two letter day codes of iCalendar onto weekdays
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday, "FR": time.Friday,
+	"SA": time.Saturday, "SU": time.Sunday,
+}
+
+// A weekdayNum is a single entry of the BYDAY part of a recurrence rule. Ordinal selects a single occurrence of the
+// weekday within a month, counting from the end when negative, while zero selects every occurrence
+type weekdayNum struct {
+	Ordinal int
+	Day     time.Weekday
+}
+
+func (day weekdayNum) String() string {
+	code := strings.ToUpper(day.Day.String()[:2])
+	if day.Ordinal == 0 {
+		return code
+	}
+	return strconv.Itoa(day.Ordinal) + code
+}
+
+// A recurrenceRule is a parsed iCalendar RRULE (RFC 5545), supporting the FREQ, INTERVAL, BYDAY, COUNT and UNTIL parts.
+// Ordinals within BYDAY, such as "-1FR" for the last Friday, are only supported by MONTHLY rules. A date-only UNTIL
+// includes the whole of that day
+type recurrenceRule struct {
+	Freq      string
+	Interval  int
+	Count     int
+	Until     *time.Time
+	UntilDate bool
+	ByDay     []weekdayNum
+}
+
+// parseRecurrence parses the RRULE value, with or without an "RRULE:" prefix, returning an error describing the first
+// problem found with it
+func parseRecurrence(value string) (recurrenceRule, error) {
+	rule := recurrenceRule{Interval: 1}
+	value = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "RRULE:")
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return recurrenceRule{}, fmt.Errorf("part [%s] must be of the form NAME=VALUE", part)
+		}
+		if seen[key] {
+			return recurrenceRule{}, fmt.Errorf("part [%s] cannot be repeated", key)
+		}
+		seen[key] = true
+		var err error
+		switch key {
+		case "FREQ":
+			if val != FreqDaily && val != FreqWeekly && val != FreqMonthly && val != FreqYearly {
+				return recurrenceRule{}, fmt.Errorf("FREQ must be one of DAILY, WEEKLY, MONTHLY or YEARLY")
+			}
+			rule.Freq = val
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err != nil || rule.Interval < 1 {
+				return recurrenceRule{}, fmt.Errorf("INTERVAL must be a positive whole number")
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if err != nil || rule.Count < 1 {
+				return recurrenceRule{}, fmt.Errorf("COUNT must be a positive whole number")
+			}
+		case "UNTIL":
+			rule.Until, rule.UntilDate, err = parseUntil(val)
+			if err != nil {
+				return recurrenceRule{}, fmt.Errorf("UNTIL must be a date such as 20240301 or a UTC time such as 20240301T120000Z")
+			}
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+			if err != nil {
+				return recurrenceRule{}, err
+			}
+		default:
+			return recurrenceRule{}, fmt.Errorf("part [%s] is not supported", key)
+		}
+	}
+	switch {
+	case rule.Freq == "":
+		return recurrenceRule{}, fmt.Errorf("must include FREQ")
+	case rule.Count > 0 && rule.Until != nil:
+		return recurrenceRule{}, fmt.Errorf("cannot include both COUNT and UNTIL")
+	case rule.Freq == FreqYearly && len(rule.ByDay) > 0:
+		return recurrenceRule{}, fmt.Errorf("BYDAY is not supported by YEARLY rules")
+	case rule.Freq != FreqMonthly && slices.ContainsFunc(rule.ByDay, func(day weekdayNum) bool { return day.Ordinal != 0 }):
+		return recurrenceRule{}, fmt.Errorf("BYDAY can only include ordinals in MONTHLY rules")
+	}
+	return rule, nil
+}
+
+// parseUntil parses the UNTIL part of a rule, which is either a date or a UTC date and time. The boolean returned is
+// true for a date
+func parseUntil(value string) (*time.Time, bool, error) {
+	if until, err := time.Parse("20060102", value); err == nil {
+		return &until, true, nil
+	}
+	until, err := time.Parse("20060102T150405Z", value)
+	if err != nil {
+		return nil, false, err
+	}
+	return &until, false, nil
+}
+
+// parseByDay parses the comma separated weekdays of the BYDAY part of a rule
+func parseByDay(value string) ([]weekdayNum, error) {
+	var days []weekdayNum
+	for _, entry := range strings.Split(value, ",") {
+		if len(entry) < 2 {
+			return nil, fmt.Errorf("BYDAY entry [%s] is not a weekday", entry)
+		}
+		day, ok := weekdays[entry[len(entry)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("BYDAY entry [%s] is not a weekday", entry)
+		}
+		parsed := weekdayNum{Day: day}
+		if prefix := entry[:len(entry)-2]; prefix != "" {
+			ordinal, err := strconv.Atoi(prefix)
+			if err != nil || ordinal == 0 || ordinal < -5 || ordinal > 5 {
+				return nil, fmt.Errorf("BYDAY entry [%s] must have an ordinal between -5 and 5", entry)
+			}
+			parsed.Ordinal = ordinal
+		}
+		if !slices.Contains(days, parsed) {
+			days = append(days, parsed)
+		}
+	}
+	return days, nil
+}
+
+// String formats the rule in its canonical form, with its parts in a fixed order and defaults omitted
+func (rule recurrenceRule) String() string {
+	parts := []string{"FREQ=" + rule.Freq}
+	if rule.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(rule.Interval))
+	}
+	if len(rule.ByDay) > 0 {
+		days := make([]string, 0, len(rule.ByDay))
+		for _, day := range rule.ByDay {
+			days = append(days, day.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if rule.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(rule.Count))
+	}
+	if rule.Until != nil && rule.UntilDate {
+		parts = append(parts, "UNTIL="+rule.Until.Format("20060102"))
+	} else if rule.Until != nil {
+		parts = append(parts, "UNTIL="+rule.Until.Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// normaliseRecurrence converts a valid rule into its canonical form, leaving invalid rules unchanged so that validation
+// can report why they are invalid
+func normaliseRecurrence(value string) string {
+	if value == "" {
+		return ""
+	}
+	rule, err := parseRecurrence(value)
+	if err != nil {
+		return value
+	}
+	return rule.String()
+}
+
+// validateRecurrence returns a FieldError describing the problem with the recurrence rule of a Todo item, or nil if it
+// is valid. Every rule repeats from the DueAt of the Todo item, so one must be set
+func validateRecurrence(todo models.Todo) *FieldError {
+	if todo.Recurrence == "" {
+		return nil
+	}
+	if _, err := parseRecurrence(todo.Recurrence); err != nil {
+		return &FieldError{Field: "Recurrence", Message: err.Error()}
+	}
+	if todo.DueAt == nil {
+		return &FieldError{Field: "Recurrence", Message: "requires DueAt to be set"}
+	}
+	return nil
+}
+
+// occurrences returns up to limit occurrences of the rule following from, which is occurrence number occurrence of the
+// rule. Fewer are returned once COUNT or UNTIL end the rule
+func (rule recurrenceRule) occurrences(from time.Time, occurrence int, limit int) []time.Time {
+	var result []time.Time
+	for len(result) < limit {
+		if rule.Count > 0 && occurrence >= rule.Count {
+			break
+		}
+		next, ok := rule.next(from)
+		if !ok || !rule.before(next) {
+			break
+		}
+		result = append(result, next)
+		from = next
+		occurrence++
+	}
+	return result
+}
+
+// before reports whether the occurrence at t is allowed by the UNTIL part of the rule
+func (rule recurrenceRule) before(t time.Time) bool {
+	if rule.Until == nil {
+		return true
+	}
+	if rule.UntilDate {
+		year, month, day := t.Date()
+		return !time.Date(year, month, day, 0, 0, 0, 0, time.UTC).After(*rule.Until)
+	}
+	return !t.After(*rule.Until)
+}
+
+// next returns the first occurrence of the rule after from, ignoring COUNT and UNTIL. from is treated as an occurrence
+// of the rule, so that its time of day and timezone are kept and INTERVAL is counted from it. The boolean returned is
+// false if no occurrence could be found
+func (rule recurrenceRule) next(from time.Time) (time.Time, bool) {
+	year, month, day := from.Date()
+	// at returns the date year, month and day at the time of day of from, normalising dates which overflow the month
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+	}
+	for step := 1; step <= maxRecurrenceSteps; step++ {
+		switch rule.Freq {
+		case FreqDaily:
+			candidate := at(year, month, day+step*rule.Interval)
+			if rule.onDay(candidate) {
+				return candidate, true
+			}
+		case FreqWeekly:
+			if len(rule.ByDay) == 0 {
+				return at(year, month, day+7*step*rule.Interval), true
+			}
+			// The weeks of the rule start on Monday, the week containing from being the first searched
+			monday := day - (int(from.Weekday())+6)%7 + 7*(step-1)*rule.Interval
+			for offset := 0; offset < 7; offset++ {
+				candidate := at(year, month, monday+offset)
+				if candidate.After(from) && rule.onDay(candidate) {
+					return candidate, true
+				}
+			}
+		case FreqMonthly:
+			if len(rule.ByDay) == 0 {
+				target := time.Date(year, month+time.Month(step*rule.Interval), 1, 0, 0, 0, 0, time.UTC)
+				if day <= daysIn(target.Year(), target.Month()) {
+					return at(target.Year(), target.Month(), day), true
+				}
+				continue
+			}
+			// The month containing from is searched first, as later days within it may match
+			target := time.Date(year, month+time.Month((step-1)*rule.Interval), 1, 0, 0, 0, 0, time.UTC)
+			for _, candidateDay := range rule.monthDays(target.Year(), target.Month()) {
+				candidate := at(target.Year(), target.Month(), candidateDay)
+				if candidate.After(from) {
+					return candidate, true
+				}
+			}
+		case FreqYearly:
+			target := year + step*rule.Interval
+			if day <= daysIn(target, month) {
+				return at(target, month, day), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// onDay reports whether t falls on one of the weekdays of BYDAY, always true when the rule has no BYDAY part
+func (rule recurrenceRule) onDay(t time.Time) bool {
+	if len(rule.ByDay) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(rule.ByDay, func(day weekdayNum) bool { return day.Day == t.Weekday() })
+}
+
+// monthDays returns the days of month matching the BYDAY part of the rule, in ascending order
+func (rule recurrenceRule) monthDays(year int, month time.Month) []int {
+	length := daysIn(year, month)
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	var days []int
+	for _, byDay := range rule.ByDay {
+		// matches lists every day of the month falling on the weekday
+		var matches []int
+		for day := 1 + (int(byDay.Day)-int(first)+7)%7; day <= length; day += 7 {
+			matches = append(matches, day)
+		}
+		switch {
+		case byDay.Ordinal == 0:
+			days = append(days, matches...)
+		case byDay.Ordinal > 0 && byDay.Ordinal <= len(matches):
+			days = append(days, matches[byDay.Ordinal-1])
+		case byDay.Ordinal < 0 && -byDay.Ordinal <= len(matches):
+			days = append(days, matches[len(matches)+byDay.Ordinal])
+		}
+	}
+	slices.Sort(days)
+	return slices.Compact(days)
+}
+
+// daysIn returns the number of days in month
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// nextOccurrence returns the Todo item which follows todo within its recurrence, when todo, which is replacing existing,
+// has just been completed. The next Todo item is a copy of todo, due at the next occurrence of its rule, and given the
+// id newId. The boolean returned is false when todo does not recur, has already been followed by another Todo item, or
+// its rule has ended
+func nextOccurrence(existing models.Todo, todo models.Todo, newId func() (string, error), now time.Time) (models.Todo, bool, error) {
+	if !completing(existing, todo) || todo.Recurrence == "" || todo.NextId != "" || todo.DueAt == nil {
+		return models.Todo{}, false, nil
+	}
+	rule, err := parseRecurrence(todo.Recurrence)
+	if err != nil {
+		return models.Todo{}, false, err
+	}
+	occurrences := rule.occurrences(*todo.DueAt, todo.Occurrence, 1)
+	if len(occurrences) == 0 {
+		return models.Todo{}, false, nil
+	}
+	next := todo
+	next.Id, err = newId()
+	if err != nil {
+		return models.Todo{}, false, err
+	}
+	next.Completed = false
+	next.DueAt = &occurrences[0]
+	next = stampCreated(next, now)
+	next.Occurrence = todo.Occurrence + 1
+	return next, true, nil
+}
+
+// previewOccurrences returns when up to limit further occurrences of todo will be due, following its own DueAt. A
+// ValidationError is returned if limit is not between 1 and MaxPreviewOccurrences
+func previewOccurrences(todo models.Todo, limit int) ([]time.Time, error) {
+	if limit < 1 || limit > MaxPreviewOccurrences {
+		return nil, &ValidationError{Resource: "query", Fields: []FieldError{{Field: "count", Message: "must be between 1 and 100"}}}
+	}
+	occurrences := []time.Time{}
+	if todo.Recurrence == "" || todo.DueAt == nil {
+		return occurrences, nil
+	}
+	rule, err := parseRecurrence(todo.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	return append(occurrences, rule.occurrences(*todo.DueAt, todo.Occurrence, limit)...), nil
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := map[string]struct {
+		rule      string
+		expected  string
+		expectErr string
+	}{
+		"Canonical Form": {
+			rule:     "rrule:byday=we,mo;freq=weekly;interval=1",
+			expected: "FREQ=WEEKLY;BYDAY=WE,MO",
+		},
+		"Every Part": {
+			rule:     "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR,2TU;UNTIL=20241231",
+			expected: "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR,2TU;UNTIL=20241231",
+		},
+		"Until Time": {
+			rule:     "FREQ=DAILY;UNTIL=20240301T120000Z",
+			expected: "FREQ=DAILY;UNTIL=20240301T120000Z",
+		},
+		"Missing Frequency": {
+			rule:      "INTERVAL=2",
+			expectErr: "must include FREQ",
+		},
+		"Unknown Frequency": {
+			rule:      "FREQ=HOURLY",
+			expectErr: "FREQ must be one of DAILY, WEEKLY, MONTHLY or YEARLY",
+		},
+		"Unsupported Part": {
+			rule:      "FREQ=DAILY;BYHOUR=9",
+			expectErr: "part [BYHOUR] is not supported",
+		},
+		"Repeated Part": {
+			rule:      "FREQ=DAILY;FREQ=WEEKLY",
+			expectErr: "part [FREQ] cannot be repeated",
+		},
+		"Malformed Part": {
+			rule:      "FREQ=DAILY;COUNT",
+			expectErr: "part [COUNT] must be of the form NAME=VALUE",
+		},
+		"Invalid Interval": {
+			rule:      "FREQ=DAILY;INTERVAL=0",
+			expectErr: "INTERVAL must be a positive whole number",
+		},
+		"Count And Until": {
+			rule:      "FREQ=DAILY;COUNT=3;UNTIL=20240301",
+			expectErr: "cannot include both COUNT and UNTIL",
+		},
+		"Invalid Weekday": {
+			rule:      "FREQ=WEEKLY;BYDAY=MO,XX",
+			expectErr: "BYDAY entry [XX] is not a weekday",
+		},
+		"Ordinal Out Of Range": {
+			rule:      "FREQ=MONTHLY;BYDAY=6MO",
+			expectErr: "BYDAY entry [6MO] must have an ordinal between -5 and 5",
+		},
+		"Ordinal Outside Monthly Rule": {
+			rule:      "FREQ=WEEKLY;BYDAY=1MO",
+			expectErr: "BYDAY can only include ordinals in MONTHLY rules",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rule, err := parseRecurrence(test.rule)
+			if test.expectErr != "" {
+				if err == nil || err.Error() != test.expectErr {
+					t.Fatalf("Error [%s] expected but was [%v]", test.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff(test.expected, rule.String())
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	london, _ := time.LoadLocation("Europe/London")
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+	}
+	tests := map[string]struct {
+		rule       string
+		from       time.Time
+		occurrence int
+		expected   []time.Time
+	}{
+		"Daily": {
+			rule:     "FREQ=DAILY;INTERVAL=2",
+			from:     date(2024, time.February, 27),
+			expected: []time.Time{date(2024, time.February, 29), date(2024, time.March, 2), date(2024, time.March, 4)},
+		},
+		"Daily On Weekdays": {
+			rule:     "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			from:     date(2024, time.March, 7),
+			expected: []time.Time{date(2024, time.March, 8), date(2024, time.March, 11), date(2024, time.March, 12)},
+		},
+		"Weekly": {
+			rule:     "FREQ=WEEKLY",
+			from:     date(2024, time.March, 4),
+			expected: []time.Time{date(2024, time.March, 11), date(2024, time.March, 18), date(2024, time.March, 25)},
+		},
+		"Fortnightly On Several Days": {
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+			from:     date(2024, time.March, 4),
+			expected: []time.Time{date(2024, time.March, 8), date(2024, time.March, 18), date(2024, time.March, 22)},
+		},
+		"Monthly Skips Short Months": {
+			rule:     "FREQ=MONTHLY",
+			from:     date(2024, time.January, 31),
+			expected: []time.Time{date(2024, time.March, 31), date(2024, time.May, 31), date(2024, time.July, 31)},
+		},
+		"Monthly On Last Friday": {
+			rule:     "FREQ=MONTHLY;BYDAY=-1FR",
+			from:     date(2024, time.January, 26),
+			expected: []time.Time{date(2024, time.February, 23), date(2024, time.March, 29), date(2024, time.April, 26)},
+		},
+		"Monthly On First And Third Monday": {
+			rule:     "FREQ=MONTHLY;BYDAY=1MO,3MO",
+			from:     date(2024, time.March, 4),
+			expected: []time.Time{date(2024, time.March, 18), date(2024, time.April, 1), date(2024, time.April, 15)},
+		},
+		"Yearly On Leap Day": {
+			rule:     "FREQ=YEARLY",
+			from:     date(2024, time.February, 29),
+			expected: []time.Time{date(2028, time.February, 29), date(2032, time.February, 29), date(2036, time.February, 29)},
+		},
+		"Count Ends Rule": {
+			rule:       "FREQ=DAILY;COUNT=3",
+			from:       date(2024, time.March, 1),
+			occurrence: 2,
+			expected:   []time.Time{date(2024, time.March, 2)},
+		},
+		"Until Date Includes Whole Day": {
+			rule:     "FREQ=DAILY;UNTIL=20240303",
+			from:     date(2024, time.March, 1),
+			expected: []time.Time{date(2024, time.March, 2), date(2024, time.March, 3)},
+		},
+		"Until Time": {
+			rule:     "FREQ=DAILY;UNTIL=20240303T090000Z",
+			from:     date(2024, time.March, 1),
+			expected: []time.Time{date(2024, time.March, 2)},
+		},
+		"Keeps Wall Clock Time Across Daylight Saving": {
+			rule: "FREQ=DAILY",
+			from: time.Date(2024, time.March, 30, 9, 0, 0, 0, london),
+			expected: []time.Time{
+				time.Date(2024, time.March, 31, 9, 0, 0, 0, london),
+				time.Date(2024, time.April, 1, 9, 0, 0, 0, london),
+				time.Date(2024, time.April, 2, 9, 0, 0, 0, london),
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rule, err := parseRecurrence(test.rule)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			occurrence := test.occurrence
+			if occurrence == 0 {
+				occurrence = 1
+			}
+			diff := cmp.Diff(test.expected, rule.occurrences(test.from, occurrence, 3))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestRecurringTodosAcrossBackends(t *testing.T) {
+	due := time.Date(2024, time.March, 4, 18, 0, 0, 0, time.UTC)
+	prerequisite := []models.Todo{
+		{Id: "bins", Title: "Put the bins out", DueAt: &due, Recurrence: "freq=weekly;count=3", Tags: []string{"home"}},
+	}
+	for name, service := range setupBackends(t, prerequisite) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := service.CreateNewTodo(ctx, models.Todo{Id: "invalid", Title: "Invalid", Recurrence: "FREQ=DAILY"})
+			if !errors.Is(err, ErrValidation) || err.Error() != "todo Recurrence requires DueAt to be set" {
+				t.Fatalf("Validation error expected but was [%v]", err)
+			}
+
+			preview, err := service.PreviewOccurrences(ctx, "bins", 5)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff([]time.Time{due.AddDate(0, 0, 7), due.AddDate(0, 0, 14)}, preview)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			_, err = service.PreviewOccurrences(ctx, "bins", 101)
+			if !errors.Is(err, ErrValidation) {
+				t.Fatalf("Validation error expected but was [%v]", err)
+			}
+
+			completed, err := service.UpdateTodo(ctx, models.Todo{
+				Id: "bins", Title: "Put the bins out", DueAt: &due, Recurrence: "FREQ=WEEKLY;COUNT=3", Completed: true,
+			}, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if completed.NextId == "" || completed.Occurrence != 1 || completed.Recurrence != "FREQ=WEEKLY;COUNT=3" {
+				t.Fatalf("Unexpected todo after completion [%+v]", completed)
+			}
+			second, err := service.ReturnSingleTodo(ctx, completed.NextId)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			nextDue := due.AddDate(0, 0, 7)
+			diff = cmp.Diff(models.Todo{
+				Id: completed.NextId, Title: "Put the bins out", DueAt: &nextDue, Recurrence: "FREQ=WEEKLY;COUNT=3",
+				Occurrence: 2, Version: 1, CreatedAt: testTime, UpdatedAt: testTime,
+			}, second)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+
+			// Reopening and completing a todo item again does not create another occurrence
+			reopen := TodoPatch{Type: MergePatchType, Document: []byte(`{"Completed":false}`)}
+			complete := TodoPatch{Type: MergePatchType, Document: []byte(`{"Completed":true}`)}
+			_, err = service.PatchTodo(ctx, "bins", reopen, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			again, err := service.PatchTodo(ctx, "bins", complete, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if again.NextId != completed.NextId {
+				t.Fatalf("Expected NextId to remain [%s] but was [%s]", completed.NextId, again.NextId)
+			}
+
+			third, err := service.PatchTodo(ctx, second.Id, complete, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			last, err := service.PatchTodo(ctx, third.NextId, complete, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if last.Occurrence != 3 || last.NextId != "" || !last.DueAt.Equal(due.AddDate(0, 0, 14)) {
+				t.Fatalf("Expected the final occurrence to end the rule but was [%+v]", last)
+			}
+			counts, err := service.CountTodos(ctx)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if counts.Total != 3 {
+				t.Fatalf("Expected [3] todos but found [%d]", counts.Total)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"time"
+)
+
+// A CompletionRule decides what completing a Todo item which has subtasks means
+type CompletionRule string
+
+// The rules which can be applied when a Todo item with subtasks is completed
+const (
+	// CompletionIndependent lets a Todo item be completed regardless of its subtasks
+	CompletionIndependent CompletionRule = "independent"
+	// CompletionCascade completes every subtask of a Todo item, at any depth, when it is completed
+	CompletionCascade CompletionRule = "cascade"
+	// CompletionRequire returns a ValidationError when a Todo item is completed before all of its subtasks
+	CompletionRequire CompletionRule = "require"
+)
+
+// A TodoTree is a Todo item alongside its subtasks, each of which is itself a TodoTree, and the progress made on them
+type TodoTree struct {
+	models.Todo
+	Progress Progress   `json:"Progress"`
+	Children []TodoTree `json:"Children"`
+}
+
+// Progress describes how many of the subtasks of a Todo item, at any depth, have been completed. Composed of the
+// following fields:
+//
+// Total: The number of subtasks
+//
+// Completed: The number of subtasks which have been completed
+//
+// Percent: The percentage of subtasks which have been completed, rounded down. A Todo item without subtasks is 100
+// percent complete when it has been completed and 0 percent complete otherwise
+type Progress struct {
+	Total     int `json:"Total"`
+	Completed int `json:"Completed"`
+	Percent   int `json:"Percent"`
+}
+
+// buildTree builds the TodoTree rooted at root from todos, which must contain every descendant of root. Subtasks are
+// ordered as they appear within todos
+func buildTree(root models.Todo, todos []models.Todo) TodoTree {
+	children := make(map[string][]models.Todo)
+	for _, todo := range todos {
+		children[todo.ParentId] = append(children[todo.ParentId], todo)
+	}
+	var build func(todo models.Todo, seen map[string]bool) TodoTree
+	build = func(todo models.Todo, seen map[string]bool) TodoTree {
+		seen[todo.Id] = true
+		tree := TodoTree{Todo: todo, Children: []TodoTree{}}
+		for _, child := range children[todo.Id] {
+			// Cycles are rejected when Todo items are saved, this only guards against data written by older versions
+			if seen[child.Id] {
+				continue
+			}
+			subtree := build(child, seen)
+			tree.Children = append(tree.Children, subtree)
+			tree.Progress.Total += subtree.Progress.Total + 1
+			tree.Progress.Completed += subtree.Progress.Completed
+			if child.Completed {
+				tree.Progress.Completed++
+			}
+		}
+		tree.Progress.Percent = percent(tree.Progress, todo.Completed)
+		return tree
+	}
+	return build(root, make(map[string]bool))
+}
+
+// percent calculates the percentage of subtasks completed according to progress
+func percent(progress Progress, completed bool) int {
+	if progress.Total == 0 {
+		if completed {
+			return 100
+		}
+		return 0
+	}
+	return progress.Completed * 100 / progress.Total
+}
+
+// descendantsOf returns every Todo item within todos which is a subtask of the Todo item with id, at any depth
+func descendantsOf(id string, todos []models.Todo) []models.Todo {
+	children := make(map[string][]models.Todo)
+	for _, todo := range todos {
+		children[todo.ParentId] = append(children[todo.ParentId], todo)
+	}
+	var descendants []models.Todo
+	seen := map[string]bool{id: true}
+	pending := []string{id}
+	for len(pending) > 0 {
+		parent := pending[0]
+		pending = pending[1:]
+		for _, child := range children[parent] {
+			if !seen[child.Id] {
+				seen[child.Id] = true
+				descendants = append(descendants, child)
+				pending = append(pending, child.Id)
+			}
+		}
+	}
+	return descendants
+}
+
+// checkParent confirms that the parent of todo exists and that making it the parent would not create a cycle, where
+// todo would become a subtask of itself. find looks up a Todo item by id, returning false if it does not exist. A
+// ValidationError is returned if the parent is invalid
+func checkParent(todo models.Todo, find func(id string) (models.Todo, bool, error)) error {
+	if todo.ParentId == "" {
+		return nil
+	}
+	invalid := func(message string) error {
+		return &ValidationError{Resource: "todo", Fields: []FieldError{{Field: "ParentId", Message: message}}}
+	}
+	if todo.ParentId == todo.Id {
+		return invalid("cannot be the todo item itself")
+	}
+	seen := make(map[string]bool)
+	for id := todo.ParentId; id != "" && !seen[id]; {
+		seen[id] = true
+		ancestor, ok, err := find(id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if id == todo.ParentId {
+				return invalid("does not reference an existing todo item")
+			}
+			return nil
+		}
+		if ancestor.ParentId == todo.Id {
+			return invalid("cannot be one of the todo item's own subtasks")
+		}
+		id = ancestor.ParentId
+	}
+	return nil
+}
+
+// completing reports whether todo, which is replacing existing, has just been completed
+func completing(existing models.Todo, todo models.Todo) bool {
+	return todo.Completed && !existing.Completed
+}
+
+// applyCompletionRule applies the rule when a Todo item with the descendants passed as a parameter has just been
+// completed. Under CompletionCascade the descendants which must also be completed are returned, with new versions, so
+// they can be saved alongside the Todo item. Under CompletionRequire a ValidationError is returned if any descendant
+// has not been completed
+func applyCompletionRule(rule CompletionRule, descendants []models.Todo, now time.Time) ([]models.Todo, error) {
+	var changed []models.Todo
+	for _, descendant := range descendants {
+		if descendant.Completed {
+			continue
+		}
+		switch rule {
+		case CompletionRequire:
+			return nil, &ValidationError{Resource: "todo", Fields: []FieldError{{Field: "Completed", Message: "cannot be set until every subtask has been completed"}}}
+		case CompletionCascade:
+			completed := descendant
+			completed.Completed = true
+			changed = append(changed, stampRevised(descendant, completed, now))
+		}
+	}
+	return changed, nil
+}
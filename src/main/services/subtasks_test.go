@@ -0,0 +1,281 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+// withCompletionRule changes the CompletionRule used by a backend created by setupBackends
+func withCompletionRule(service Store, rule CompletionRule) {
+	switch backend := service.(type) {
+	case *TodoServiceImpl:
+		backend.options.CompletionRule = rule
+	case *SqliteTodoService:
+		backend.options.CompletionRule = rule
+	case *FileTodoService:
+		backend.options.CompletionRule = rule
+	case *EventTodoService:
+		backend.options.CompletionRule = rule
+	}
+}
+
+func subtasksPrerequisite() []models.Todo {
+	return []models.Todo{
+		{Id: "release", Title: "Release"},
+		{Id: "build", Title: "Build", ParentId: "release"},
+		{Id: "test", Title: "Test", ParentId: "release", Completed: true},
+		{Id: "unit", Title: "Unit tests", ParentId: "test", Completed: true},
+		{Id: "e2e", Title: "End to end tests", ParentId: "test"},
+		{Id: "other", Title: "Unrelated"},
+	}
+}
+
+func TestSubtasksAcrossBackends(t *testing.T) {
+	for name, service := range setupBackends(t, subtasksPrerequisite()) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			children, err := service.ReturnChildren(ctx, "release")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff([]string{"build", "test"}, ids(children))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			children, err = service.ReturnChildren(ctx, "other")
+			if err != nil || len(children) != 0 {
+				t.Fatalf("Expected no children but found %v with error [%v]", children, err)
+			}
+			_, err = service.ReturnChildren(ctx, "missing")
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+
+			tree, err := service.ReturnTodoTree(ctx, "release")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff(Progress{Total: 4, Completed: 2, Percent: 50}, tree.Progress)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			if len(tree.Children) != 2 || tree.Children[0].Id != "build" || tree.Children[1].Id != "test" {
+				t.Fatalf("Unexpected children within tree %+v", tree.Children)
+			}
+			diff = cmp.Diff(Progress{Total: 2, Completed: 1, Percent: 50}, tree.Children[1].Progress)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			diff = cmp.Diff([]string{"unit", "e2e"}, []string{tree.Children[1].Children[0].Id, tree.Children[1].Children[1].Id})
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			_, err = service.ReturnTodoTree(ctx, "missing")
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+
+			err = service.DeleteTodo(ctx, "test", nil)
+			if !errors.Is(err, ErrConflict) || err.Error() != "todo with id [test] still has [2] subtasks" {
+				t.Fatalf("Conflict error expected but was [%v]", err)
+			}
+			err = service.DeleteTodo(ctx, "unit", nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+		})
+	}
+}
+
+func TestParentValidationAcrossBackends(t *testing.T) {
+	tests := map[string]struct {
+		todo     models.Todo
+		expected string
+	}{
+		"Parent Is Itself": {
+			todo:     models.Todo{Id: "test", Title: "Test", ParentId: "test"},
+			expected: "todo ParentId cannot be the todo item itself",
+		},
+		"Parent Does Not Exist": {
+			todo:     models.Todo{Id: "test", Title: "Test", ParentId: "missing"},
+			expected: "todo ParentId does not reference an existing todo item",
+		},
+		"Parent Is A Direct Subtask": {
+			todo:     models.Todo{Id: "test", Title: "Test", ParentId: "e2e"},
+			expected: "todo ParentId cannot be one of the todo item's own subtasks",
+		},
+		"Parent Is A Nested Subtask": {
+			todo:     models.Todo{Id: "release", Title: "Release", ParentId: "unit"},
+			expected: "todo ParentId cannot be one of the todo item's own subtasks",
+		},
+	}
+	for name, test := range tests {
+		for backend, service := range setupBackends(t, subtasksPrerequisite()) {
+			t.Run(name+"/"+backend, func(t *testing.T) {
+				_, err := service.UpdateTodo(context.Background(), test.todo, nil)
+				if !errors.Is(err, ErrValidation) || err.Error() != test.expected {
+					t.Fatalf("Validation error [%s] expected but was [%v]", test.expected, err)
+				}
+			})
+		}
+	}
+}
+
+func TestCompletionRulesAcrossBackends(t *testing.T) {
+	tests := map[string]struct {
+		rule      CompletionRule
+		expectErr bool
+		completed []string
+	}{
+		"Independent Completes Only The Parent": {
+			rule:      CompletionIndependent,
+			completed: []string{"other", "test", "unit"},
+		},
+		"Cascade Completes Every Subtask": {
+			rule:      CompletionCascade,
+			completed: []string{"e2e", "other", "test", "unit"},
+		},
+		"Require Rejects Incomplete Subtasks": {
+			rule:      CompletionRequire,
+			expectErr: true,
+			completed: []string{"other", "unit"},
+		},
+	}
+	for name, test := range tests {
+		prerequisite := subtasksPrerequisite()
+		prerequisite[2].Completed = false
+		prerequisite[5].Completed = true
+		for backend, service := range setupBackends(t, prerequisite) {
+			t.Run(name+"/"+backend, func(t *testing.T) {
+				ctx := context.Background()
+				withCompletionRule(service, test.rule)
+				_, err := service.PatchTodo(ctx, "test", TodoPatch{Type: MergePatchType, Document: []byte(`{"Completed":true}`)}, nil)
+				if test.expectErr {
+					if !errors.Is(err, ErrValidation) || err.Error() != "todo Completed cannot be set until every subtask has been completed" {
+						t.Fatalf("Validation error expected but was [%v]", err)
+					}
+				} else if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+				completed := true
+				page, err := service.QueryTodos(ctx, TodoQuery{Completed: &completed})
+				if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+				diff := cmp.Diff(test.completed, ids(page.Todos))
+				if diff != "" {
+					t.Fatal(diff)
+				}
+			})
+		}
+	}
+}
+
+func TestCascadeCompletionVersionsAcrossBackends(t *testing.T) {
+	for name, service := range setupBackends(t, subtasksPrerequisite()) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			withCompletionRule(service, CompletionCascade)
+			_, err := service.UpdateTodo(ctx, models.Todo{Id: "release", Title: "Release", Completed: true}, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			for id, version := range map[string]int64{"release": 2, "build": 2, "test": 1, "unit": 1, "e2e": 2} {
+				todo, err := service.ReturnSingleTodo(ctx, id)
+				if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+				if !todo.Completed || todo.Version != version || todo.CompletedAt == nil {
+					t.Fatalf("Expected [%s] to be completed at version [%d] but was [%+v]", id, version, todo)
+				}
+			}
+			// Saving a todo item which was already completed applies no rule, even with an open subtask
+			withCompletionRule(service, CompletionRequire)
+			_, err = service.PatchTodo(ctx, "e2e", TodoPatch{Type: MergePatchType, Document: []byte(`{"Completed":false}`)}, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = service.UpdateTodo(ctx, models.Todo{Id: "test", Title: "Tests", Completed: true}, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+		})
+	}
+}
+
+func TestDeleteListDetachesSubtasksAcrossBackends(t *testing.T) {
+	for name, service := range setupBackends(t, []models.Todo{}) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := service.CreateNewList(ctx, models.List{Id: "sprint", Name: "Sprint"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			for _, todo := range []models.Todo{
+				{Id: "parent", Title: "Parent", ListId: "sprint"},
+				{Id: "inside", Title: "Inside", ListId: "sprint", ParentId: "parent"},
+				{Id: "outside", Title: "Outside", ParentId: "parent"},
+			} {
+				_, err = service.CreateNewTodo(ctx, todo)
+				if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+			}
+			err = service.DeleteList(ctx, "sprint", ListDeleteCascade, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			todos, err := service.ReturnAllTodos(ctx)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if len(todos) != 1 || todos[0].Id != "outside" || todos[0].ParentId != "" || todos[0].Version != 2 {
+				t.Fatalf("Expected only a detached todo to remain but found %+v", todos)
+			}
+		})
+	}
+}
+
+func TestBuildTreeProgress(t *testing.T) {
+	tests := map[string]struct {
+		root        models.Todo
+		descendants []models.Todo
+		expected    Progress
+	}{
+		"Open Without Subtasks": {
+			root:     models.Todo{Id: "1"},
+			expected: Progress{},
+		},
+		"Completed Without Subtasks": {
+			root:     models.Todo{Id: "1", Completed: true},
+			expected: Progress{Percent: 100},
+		},
+		"Rounds Down": {
+			root: models.Todo{Id: "1"},
+			descendants: []models.Todo{
+				{Id: "2", ParentId: "1", Completed: true},
+				{Id: "3", ParentId: "1"},
+				{Id: "4", ParentId: "1"},
+			},
+			expected: Progress{Total: 3, Completed: 1, Percent: 33},
+		},
+		"Ignores Cycles": {
+			root: models.Todo{Id: "1", ParentId: "2"},
+			descendants: []models.Todo{
+				{Id: "2", ParentId: "1", Completed: true},
+			},
+			expected: Progress{Total: 1, Completed: 1, Percent: 100},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			diff := cmp.Diff(test.expected, buildTree(test.root, test.descendants).Progress)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits placed upon the tags of a Todo item
+const (
+	MaxTags      = 20
+	MaxTagLength = 32
+)
+
+// A TagCount describes a tag and the number of Todo items it is attached to
+type TagCount struct {
+	Name  string `json:"Name"`
+	Count int    `json:"Count"`
+}
+
+// normaliseTag converts tag into its canonical form, lower cased with surrounding whitespace removed and any runs of
+// whitespace within it replaced by a single "-"
+func normaliseTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), "-"))
+}
+
+// normaliseTags converts every tag into its canonical form, dropping any which are empty or duplicated, and sorts them.
+// Nil is returned when no tags remain
+func normaliseTags(tags []string) []string {
+	var normalised []string
+	for _, tag := range tags {
+		tag = normaliseTag(tag)
+		if tag != "" && !slices.Contains(normalised, tag) {
+			normalised = append(normalised, tag)
+		}
+	}
+	slices.Sort(normalised)
+	return normalised
+}
+
+// validateTag returns a message describing why a normalised tag is invalid, or an empty string if it is valid
+func validateTag(tag string) string {
+	if tag == "" {
+		return "cannot be empty"
+	}
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		return "cannot be longer than 32 characters"
+	}
+	for _, char := range tag {
+		if !unicode.IsLetter(char) && !unicode.IsDigit(char) && !strings.ContainsRune("-_.", char) {
+			return "can only contain letters, digits, '-', '_' or '.'"
+		}
+	}
+	return ""
+}
+
+// validateTags returns a FieldError for the first problem found with the normalised tags of a Todo item, or nil if they
+// are all valid
+func validateTags(tags []string) *FieldError {
+	if len(tags) > MaxTags {
+		return &FieldError{Field: "Tags", Message: "cannot contain more than 20 tags"}
+	}
+	for _, tag := range tags {
+		if message := validateTag(tag); message != "" {
+			return &FieldError{Field: "Tags", Message: "tag [" + tag + "] " + message}
+		}
+	}
+	return nil
+}
+
+// countTags returns the number of todos each tag is attached to, sorted by tag
+func countTags(todos []models.Todo) []TagCount {
+	counts := make(map[string]int)
+	for _, todo := range todos {
+		for _, tag := range todo.Tags {
+			counts[tag]++
+		}
+	}
+	result := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		result = append(result, TagCount{Name: tag, Count: count})
+	}
+	slices.SortFunc(result, func(a, b TagCount) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+// A tagChange replaces every one of its source tags with its target tag, across all Todo items. It is shared by the
+// backends so that renaming and merging tags behaves identically regardless of where Todo items are persisted
+type tagChange struct {
+	sources []string
+	target  string
+	rename  bool
+}
+
+// newTagRename creates a tagChange renaming tag to name, returning a ValidationError if name is not a valid tag
+func newTagRename(tag string, name string) (tagChange, error) {
+	change := tagChange{sources: []string{normaliseTag(tag)}, target: normaliseTag(name), rename: true}
+	if message := validateTag(change.target); message != "" {
+		return tagChange{}, &ValidationError{Resource: "tag", Fields: []FieldError{{Field: "Name", Message: message}}}
+	}
+	return change, nil
+}
+
+// newTagMerge creates a tagChange merging each of the sources into target, returning a ValidationError if no sources
+// were given or target is not a valid tag
+func newTagMerge(sources []string, target string) (tagChange, error) {
+	change := tagChange{sources: normaliseTags(sources), target: normaliseTag(target)}
+	var fields []FieldError
+	if len(change.sources) == 0 {
+		fields = append(fields, FieldError{Field: "Sources", Message: "cannot be empty"})
+	}
+	if message := validateTag(change.target); message != "" {
+		fields = append(fields, FieldError{Field: "Target", Message: message})
+	}
+	if len(fields) > 0 {
+		return tagChange{}, &ValidationError{Resource: "tag", Fields: fields}
+	}
+	return change, nil
+}
+
+// check confirms the change can be made given the current tag counts. Every source must be attached to at least one
+// Todo item, otherwise a NotFoundError is returned, and a rename cannot reuse the name of a tag already in use, which
+// instead returns a ConflictError
+func (change tagChange) check(counts []TagCount) error {
+	inUse := func(tag string) bool {
+		return slices.ContainsFunc(counts, func(count TagCount) bool { return count.Name == tag })
+	}
+	for _, source := range change.sources {
+		if !inUse(source) {
+			return &NotFoundError{Resource: "tag", Id: source}
+		}
+	}
+	if change.rename && change.sources[0] != change.target && inUse(change.target) {
+		return &ConflictError{Resource: "tag", Id: change.target}
+	}
+	return nil
+}
+
+// apply returns todo with the change made to its tags, and a new version if they were altered. The boolean returned
+// is true if todo was altered
+func (change tagChange) apply(todo models.Todo, now time.Time) (models.Todo, bool) {
+	tags := slices.DeleteFunc(slices.Clone(todo.Tags), func(tag string) bool {
+		return slices.Contains(change.sources, tag)
+	})
+	if len(tags) == len(todo.Tags) {
+		return todo, false
+	}
+	revised := todo
+	revised.Tags = normaliseTags(append(tags, change.target))
+	if slices.Equal(revised.Tags, todo.Tags) {
+		return todo, false
+	}
+	return stampRevised(todo, revised, now), true
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"strings"
+	"testing"
+)
+
+func TestNormaliseTags(t *testing.T) {
+	tests := map[string]struct {
+		input    []string
+		expected []string
+	}{
+		"No Tags": {
+			input:    nil,
+			expected: nil,
+		},
+		"Lower Cased And Sorted": {
+			input:    []string{"Work", "home"},
+			expected: []string{"home", "work"},
+		},
+		"Whitespace Replaced": {
+			input:    []string{"  Weekly   Shop "},
+			expected: []string{"weekly-shop"},
+		},
+		"Empty And Duplicate Tags Dropped": {
+			input:    []string{"home", " ", "HOME", ""},
+			expected: []string{"home"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			diff := cmp.Diff(tt.expected, normaliseTags(tt.input))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	tooMany := make([]string, MaxTags+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("a", i+1)
+	}
+	tests := map[string]struct {
+		tags                 []string
+		expectedErrorMessage string
+	}{
+		"Too Many Tags": {
+			tags:                 tooMany,
+			expectedErrorMessage: "todo Tags cannot contain more than 20 tags",
+		},
+		"Tag Too Long": {
+			tags:                 []string{strings.Repeat("a", MaxTagLength+1)},
+			expectedErrorMessage: "todo Tags tag [" + strings.Repeat("a", MaxTagLength+1) + "] cannot be longer than 32 characters",
+		},
+		"Invalid Characters": {
+			tags:                 []string{"home/garden"},
+			expectedErrorMessage: "todo Tags tag [home/garden] can only contain letters, digits, '-', '_' or '.'",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validateTodo(models.Todo{Id: "1", Tags: tt.tags})
+			if !errors.Is(err, ErrValidation) || err.Error() != tt.expectedErrorMessage {
+				t.Fatalf("Error message not as expected, expected [%v] but was [%v]", tt.expectedErrorMessage, err)
+			}
+		})
+	}
+}
+
+func TestTagsAcrossBackends(t *testing.T) {
+	prerequisite := []models.Todo{
+		{Id: "1", Title: "Walk dog", Tags: []string{"Home", "pets"}},
+		{Id: "2", Title: "Mow lawn", Tags: []string{"garden", "house"}},
+		{Id: "3", Title: "Write report", Tags: []string{"work"}},
+	}
+	for name, service := range setupBackends(t, prerequisite) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			tags, err := service.ListTags(ctx)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff([]TagCount{
+				{Name: "garden", Count: 1},
+				{Name: "home", Count: 1},
+				{Name: "house", Count: 1},
+				{Name: "pets", Count: 1},
+				{Name: "work", Count: 1},
+			}, tags)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+
+			_, err = service.RenameTag(ctx, "home", "work")
+			if !errors.Is(err, ErrConflict) {
+				t.Fatalf("Conflict error expected but was [%v]", err)
+			}
+			_, err = service.RenameTag(ctx, "office", "work")
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+			_, err = service.MergeTags(ctx, []string{"house", "office"}, "home")
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+
+			renamed, err := service.RenameTag(ctx, "pets", "Animals")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff(TagCount{Name: "animals", Count: 1}, renamed)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			merged, err := service.MergeTags(ctx, []string{"house", "garden"}, "home")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff(TagCount{Name: "home", Count: 2}, merged)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+
+			todos, err := service.ReturnAllTodos(ctx)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			expected := []models.Todo{
+				{Id: "1", Title: "Walk dog", Tags: []string{"animals", "home"}, Version: 2},
+				{Id: "2", Title: "Mow lawn", Tags: []string{"home"}, Version: 2},
+				{Id: "3", Title: "Write report", Tags: []string{"work"}, Version: 1},
+			}
+			for i := range expected {
+				expected[i] = stamped(expected[i])
+			}
+			diff = cmp.Diff(expected, todos)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -2,100 +2,816 @@ package services
 
 import (
 	"TodoApp/src/main/models"
-	"errors"
-	"fmt"
+	"container/list"
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
 )
 
+// TodoService is implemented by each of the backends able to persist Todo items. All implementations are expected to
+// behave identically, so callers should not need to know which backend is in use
+//
+// Todo items can be made subtasks of another Todo item through their ParentId. The parent must exist and a Todo item can
+// never become a subtask of itself, at any depth. A Todo item cannot be deleted while it has subtasks, and completing
+// one is governed by the CompletionRule of the service's Options
+//
+// Todo items can depend on others through DependsOn, which must reference existing Todo items without forming a cycle.
+// A Todo item is Blocked while any of those prerequisites is open, and cannot be deleted while others depend on it
+//
+// A Todo item with a Recurrence repeats from its DueAt. Completing it creates the Todo item for its next occurrence, in
+// the same atomic operation, and links to it through NextId
+//
+// Deleting a Todo item moves it to the trash, from where it can be restored or permanently removed. Todo items in the
+// trash are not returned by any other method, and their ids cannot be reused until they have been removed from it
+//
+// Every change made to a Todo item, including those made alongside another change, is recorded as a Revision along
+// with the actor carried by the context, see WithActor. Revisions are kept after the Todo item has been purged, and a
+// Todo item can be reverted to how it was after any revision which did not remove it
+//
+// Tags are normalised whenever a Todo item is saved. Renaming or merging tags changes every Todo item they are attached
+// to in a single atomic operation, giving each of those Todo items a new version
+//
+// Every Todo item carries a version which is set to 1 when it is created and incremented each time it is changed. The
+// methods which change or remove a Todo item accept an expected version, when it is not nil the change is only made if
+// the Todo item is still at that version, otherwise a VersionMismatchError is returned
 type TodoService interface {
-	ReturnAllTodos() []models.Todo
-	ReturnSingleTodo(id string) (models.Todo, error)
-	CreateNewTodo(newTodo models.Todo) (models.Todo, error)
-	DeleteTodo(id string)
-	UpdateTodo(newTodo models.Todo) (models.Todo, error)
+	ReturnAllTodos(ctx context.Context) ([]models.Todo, error)
+	QueryTodos(ctx context.Context, query TodoQuery) (TodoPage, error)
+	CountTodos(ctx context.Context) (TodoCounts, error)
+	CheckHealth(ctx context.Context) []ComponentHealth
+	ReturnSingleTodo(ctx context.Context, id string) (models.Todo, error)
+	CreateNewTodo(ctx context.Context, newTodo models.Todo) (models.Todo, error)
+	DeleteTodo(ctx context.Context, id string, expectedVersion *int64) error
+	UpdateTodo(ctx context.Context, newTodo models.Todo, expectedVersion *int64) (models.Todo, error)
+	PatchTodo(ctx context.Context, id string, patch TodoPatch, expectedVersion *int64) (models.Todo, error)
+	ListTags(ctx context.Context) ([]TagCount, error)
+	RenameTag(ctx context.Context, tag string, name string) (TagCount, error)
+	MergeTags(ctx context.Context, sources []string, target string) (TagCount, error)
+	ReturnChildren(ctx context.Context, id string) ([]models.Todo, error)
+	ReturnTodoTree(ctx context.Context, id string) (TodoTree, error)
+	PlanTodos(ctx context.Context) ([]models.Todo, error)
+	PreviewOccurrences(ctx context.Context, id string, count int) ([]time.Time, error)
+	ReturnTrash(ctx context.Context) ([]models.Todo, error)
+	RestoreTodo(ctx context.Context, id string) (models.Todo, error)
+	PurgeTodo(ctx context.Context, id string) error
+	PurgeTrash(ctx context.Context, olderThan time.Duration) (int, error)
+	ReturnHistory(ctx context.Context, id string) ([]models.Revision, error)
+	QueryAudit(ctx context.Context, query AuditQuery) (AuditPage, error)
+	RevertTodo(ctx context.Context, id string, revision int64, expectedVersion *int64) (models.Todo, error)
 }
 
-// A TodoServiceImpl represents a Service class responsible for functionality relating to Todo items
+// TodoCounts describes the number of Todo items persisted within the DB. Composed of the following fields:
+//
+// Total: The number of Todo items
 //
-// Contains an array Todos which acts as a in-memory DB for persisting Todo items
+// Completed: The number of Todo items which have been completed
+type TodoCounts struct {
+	Total     int
+	Completed int
+}
+
+// Open returns the number of Todo items which have not yet been completed
+func (counts TodoCounts) Open() int {
+	return counts.Total - counts.Completed
+}
+
+// A TodoServiceImpl represents a Service class responsible for functionality relating to Todo items and the lists they
+// belong to
+//
+// Todo items are held in-memory within a map keyed by their id, giving constant time lookups, alongside a linked list
+// which preserves the order they were created in. Lists, and the Todo items within the trash, are held in the same way.
+// Revisions are appended to a slice, so each is found at the index one less than its id. All of them are guarded by a
+// single read/write lock so that the service can be safely used from concurrent HTTP handlers
+//
+// Each change is first decided against the current state without modifying it, collecting everything it changes into a
+// changeSet which is then committed in one step, see commit
 type TodoServiceImpl struct {
-	Todos []models.Todo
+	mu         sync.RWMutex
+	todos      map[string]*list.Element
+	order      *list.List
+	lists      map[string]*list.Element
+	listOrder  *list.List
+	trash      map[string]*list.Element
+	trashOrder *list.List
+	history    []models.Revision
+	options    Options
+	// publish, when set, is handed every changeSet to commit in place of it being applied directly. It must apply the
+	// changes itself, and is called while the write lock is held
+	publish func(changes changeSet) error
+	// committed records that the change holding the write lock has been committed, so the observers are notified once
+	// it is released, see lock
+	committed bool
+	observers
 }
 
-// NewTodoServiceImpl creates a new TodoServiceImpl object. This is used by Wire when starting the API to perform the
-// necessary dependency injection
-func NewTodoServiceImpl(todos []models.Todo) *TodoServiceImpl {
-	var b = TodoServiceImpl{todos}
+// NewTodoServiceImpl creates a new TodoServiceImpl object, seeded with the Todo items passed as a parameter. This is used
+// by Wire when starting the API to perform the necessary dependency injection
+func NewTodoServiceImpl(todos []models.Todo, options Options) *TodoServiceImpl {
+	var b = TodoServiceImpl{
+		todos:      make(map[string]*list.Element, len(todos)),
+		order:      list.New(),
+		lists:      make(map[string]*list.Element),
+		listOrder:  list.New(),
+		trash:      make(map[string]*list.Element),
+		trashOrder: list.New(),
+		options:    options,
+	}
+	for _, todo := range todos {
+		b.insert(todo)
+	}
 	return &b
 }
 
-// ReturnAllTodos returns all Todo items currently persisted within the DB
-func (service *TodoServiceImpl) ReturnAllTodos() []models.Todo {
-	return service.Todos
+// ReturnAllTodos returns all Todo items currently persisted within the DB, in the order they were created
+func (service *TodoServiceImpl) ReturnAllTodos(_ context.Context) ([]models.Todo, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	return service.all(), nil
 }
 
-// ReturnSingleTodo returns a single Todo item, identified via the id param. If no Todo item is found with a matching
-// Id then an error is returned
-func (service *TodoServiceImpl) ReturnSingleTodo(id string) (models.Todo, error) {
-	for _, todo := range service.Todos {
-		if todo.Id == id {
-			return todo, nil
+// QueryTodos returns a single page of the Todo items persisted within the DB which match the filters of the query,
+// sorted as requested. If the query is invalid a ValidationError is returned
+func (service *TodoServiceImpl) QueryTodos(ctx context.Context, query TodoQuery) (TodoPage, error) {
+	todos, err := service.ReturnAllTodos(ctx)
+	if err != nil {
+		return TodoPage{}, err
+	}
+	return applyQuery(todos, query, service.options.now())
+}
+
+// CountTodos returns the number of Todo items currently persisted within the DB
+func (service *TodoServiceImpl) CountTodos(_ context.Context) (TodoCounts, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	counts := TodoCounts{Total: service.order.Len()}
+	for element := service.order.Front(); element != nil; element = element.Next() {
+		if element.Value.(models.Todo).Completed {
+			counts.Completed++
 		}
 	}
-	return models.Todo{}, errors.New(fmt.Sprintf("could not find todo with id [%s]", id))
+	return counts, nil
+}
+
+// CheckHealth reports the health of the in-memory store, which is always healthy as it has no external dependencies
+func (service *TodoServiceImpl) CheckHealth(_ context.Context) []ComponentHealth {
+	return []ComponentHealth{{Name: "memory", Status: HealthUp}}
+}
+
+// ReturnSingleTodo returns a single Todo item, identified via the id param, with its Blocked value computed. If no Todo
+// item is found with a matching Id then an error is returned
+func (service *TodoServiceImpl) ReturnSingleTodo(_ context.Context, id string) (models.Todo, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	element, ok := service.todos[id]
+	if !ok {
+		return models.Todo{}, &NotFoundError{Resource: "todo", Id: id}
+	}
+	todo := element.Value.(models.Todo)
+	todo.Blocked, _ = blocked(todo, service.find)
+	return todo, nil
 }
 
 // CreateNewTodo persists a new Todo item in the DB. If a existing Todo item with an id matching that of the new Todo item
-// is found within the DB then an error will be returned
-// The Todo item passed as a parameter must include an id
-func (service *TodoServiceImpl) CreateNewTodo(newTodo models.Todo) (models.Todo, error) {
-	err := validateTodo(newTodo)
+// is found within the DB, or within the trash, then an error will be returned
+// If the Todo item passed as a parameter does not include an id then one is generated
+func (service *TodoServiceImpl) CreateNewTodo(ctx context.Context, newTodo models.Todo) (models.Todo, error) {
+	var err error
+	newTodo.Id, err = service.options.assignId("todo", newTodo.Id)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	newTodo = normaliseTodo(newTodo)
+	err = validateTodo(newTodo)
 	if err != nil {
 		return models.Todo{}, err
 	}
 
-	for _, todo := range service.Todos {
-		if todo.Id == newTodo.Id {
-			return models.Todo{}, errors.New(fmt.Sprintf("todo with id [%s] already exists", newTodo.Id))
-		}
+	defer service.lock(ctx)()
+	if _, exists := service.todos[newTodo.Id]; exists {
+		return models.Todo{}, &ConflictError{Resource: "todo", Id: newTodo.Id}
+	}
+	if _, trashed := service.trash[newTodo.Id]; trashed {
+		return models.Todo{}, &ConflictError{Resource: "todo", Id: newTodo.Id}
+	}
+	err = service.checkListId(newTodo)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkParent(newTodo, service.find)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkDependencies(newTodo, service.find)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	now := service.options.now()
+	newTodo = stampCreated(newTodo, now)
+	changes := newChangeSet(ctx, now)
+	changes.record(ctx, models.ActionCreate, nil, &newTodo)
+	err = service.commit(changes)
+	if err != nil {
+		return models.Todo{}, err
 	}
-	service.Todos = append(service.Todos, newTodo)
 	return newTodo, nil
 }
 
-// DeleteTodo removes a Todo item from the DB with an id matching that of the id provided as a parameter
-func (service *TodoServiceImpl) DeleteTodo(id string) {
-	for i, todo := range service.Todos {
-		if todo.Id == id {
-			//Todos equals all values before index (remember slices don't include value at the max index specified)
-			//Plus all the values one index after the found index (remember slices do include the value at the min index)
-			//the ... will pass the slice to the variadic function
-			service.Todos = append(service.Todos[:i], service.Todos[i+1:]...)
-		}
+// DeleteTodo moves a Todo item with an id matching that of the id provided as a parameter to the trash. If no Todo item
+// with a matching id is found then a NotFoundError is returned, if it still has subtasks a HasSubtasksError, and if other
+// Todo items still depend on it a HasDependantsError
+func (service *TodoServiceImpl) DeleteTodo(ctx context.Context, id string, expectedVersion *int64) error {
+	defer service.lock(ctx)()
+	element, ok := service.todos[id]
+	if !ok {
+		return &NotFoundError{Resource: "todo", Id: id}
+	}
+	err := checkVersion(element.Value.(models.Todo), expectedVersion)
+	if err != nil {
+		return err
+	}
+	if children := service.children(id); len(children) > 0 {
+		return &HasSubtasksError{Id: id, Subtasks: len(children)}
 	}
+	if dependants := service.dependants(id); dependants > 0 {
+		return &HasDependantsError{Id: id, Dependants: dependants}
+	}
+	changes := newChangeSet(ctx, service.options.now())
+	changes.trash(ctx, element.Value.(models.Todo))
+	return service.commit(changes)
 }
 
 // UpdateTodo updates a Todo item with an id matching that of the Todo item pass as a parameter. If a Todo item with
 // an id matching that of the Todo item passed as a parameter cannot be found then an error will be returned.
 //
 // The Todo item passed as a parameter must include an id
-func (service TodoServiceImpl) UpdateTodo(newTodo models.Todo) (models.Todo, error) {
+func (service *TodoServiceImpl) UpdateTodo(ctx context.Context, newTodo models.Todo, expectedVersion *int64) (models.Todo, error) {
+	newTodo = normaliseTodo(newTodo)
 	err := validateTodo(newTodo)
 	if err != nil {
 		return models.Todo{}, err
 	}
-	for i, todo := range service.Todos {
-		if todo.Id == newTodo.Id {
-			service.Todos[i] = newTodo
-			return newTodo, nil
+
+	defer service.lock(ctx)()
+	element, ok := service.todos[newTodo.Id]
+	if !ok {
+		return models.Todo{}, &NotFoundError{Resource: "todo", Id: newTodo.Id}
+	}
+	existing := element.Value.(models.Todo)
+	err = checkVersion(existing, expectedVersion)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = service.checkListId(newTodo)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkParent(newTodo, service.find)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkDependencies(newTodo, service.find)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	now := service.options.now()
+	return service.revise(ctx, element, stampRevised(existing, newTodo, now), now)
+}
+
+// PatchTodo applies a patch document to the Todo item with an id matching the id passed as a parameter. The patch is
+// applied while holding the write lock, so no other change to the Todo item can be made between it being read and the
+// patched Todo item being persisted. If no Todo item with a matching id exists a NotFoundError is returned
+func (service *TodoServiceImpl) PatchTodo(ctx context.Context, id string, patch TodoPatch, expectedVersion *int64) (models.Todo, error) {
+	defer service.lock(ctx)()
+	element, ok := service.todos[id]
+	if !ok {
+		return models.Todo{}, &NotFoundError{Resource: "todo", Id: id}
+	}
+	existing := element.Value.(models.Todo)
+	err := checkVersion(existing, expectedVersion)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	now := service.options.now()
+	patched, err := applyPatch(existing, patch, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = service.checkListId(patched)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkParent(patched, service.find)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkDependencies(patched, service.find)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return service.revise(ctx, element, patched, now)
+}
+
+// ListTags returns every tag attached to a Todo item, with the number of Todo items it is attached to, sorted by tag
+func (service *TodoServiceImpl) ListTags(ctx context.Context) ([]TagCount, error) {
+	todos, err := service.ReturnAllTodos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return countTags(todos), nil
+}
+
+// RenameTag renames tag to name on every Todo item it is attached to. If tag is not attached to any Todo item a
+// NotFoundError is returned, and if name is already in use a ConflictError is returned, MergeTags should be used instead
+func (service *TodoServiceImpl) RenameTag(ctx context.Context, tag string, name string) (TagCount, error) {
+	change, err := newTagRename(tag, name)
+	if err != nil {
+		return TagCount{}, err
+	}
+	return service.changeTags(ctx, change)
+}
+
+// MergeTags replaces each of the sources with target on every Todo item they are attached to. If any of the sources is
+// not attached to a Todo item a NotFoundError is returned and no Todo items are changed
+func (service *TodoServiceImpl) MergeTags(ctx context.Context, sources []string, target string) (TagCount, error) {
+	change, err := newTagMerge(sources, target)
+	if err != nil {
+		return TagCount{}, err
+	}
+	return service.changeTags(ctx, change)
+}
+
+// changeTags makes change to every Todo item while holding the write lock, so it is applied to all of them at once,
+// returning the target tag with the number of Todo items it is now attached to
+func (service *TodoServiceImpl) changeTags(ctx context.Context, change tagChange) (TagCount, error) {
+	defer service.lock(ctx)()
+	err := change.check(countTags(service.all()))
+	if err != nil {
+		return TagCount{}, err
+	}
+
+	now := service.options.now()
+	changes := newChangeSet(ctx, now)
+	result := TagCount{Name: change.target}
+	for element := service.order.Front(); element != nil; element = element.Next() {
+		existing := element.Value.(models.Todo)
+		todo, changed := change.apply(existing, now)
+		if changed {
+			changes.record(ctx, models.ActionUpdate, &existing, &todo)
 		}
+		if slices.Contains(todo.Tags, change.target) {
+			result.Count++
+		}
+	}
+	err = service.commit(changes)
+	if err != nil {
+		return TagCount{}, err
+	}
+	return result, nil
+}
+
+// ReturnChildren returns the Todo items which are direct subtasks of the Todo item with an id matching the id passed as
+// a parameter, in the order they were created. If no such Todo item exists a NotFoundError is returned
+func (service *TodoServiceImpl) ReturnChildren(_ context.Context, id string) ([]models.Todo, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	if _, ok := service.todos[id]; !ok {
+		return nil, &NotFoundError{Resource: "todo", Id: id}
+	}
+	return service.children(id), nil
+}
+
+// ReturnTodoTree returns the Todo item with an id matching the id passed as a parameter alongside all of its subtasks,
+// at any depth, and the progress made on them. If no such Todo item exists a NotFoundError is returned
+func (service *TodoServiceImpl) ReturnTodoTree(_ context.Context, id string) (TodoTree, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	element, ok := service.todos[id]
+	if !ok {
+		return TodoTree{}, &NotFoundError{Resource: "todo", Id: id}
+	}
+	todos := service.all()
+	return buildTree(element.Value.(models.Todo), descendantsOf(id, todos)), nil
+}
+
+// PlanTodos returns every open Todo item ordered so that each comes after all of its open prerequisites, otherwise
+// keeping the order they were created in
+func (service *TodoServiceImpl) PlanTodos(ctx context.Context) ([]models.Todo, error) {
+	todos, err := service.ReturnAllTodos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return planTodos(todos), nil
+}
+
+// PreviewOccurrences returns when up to count further occurrences of the Todo item with an id matching the id passed as
+// a parameter will be due. If no such Todo item exists a NotFoundError is returned
+func (service *TodoServiceImpl) PreviewOccurrences(ctx context.Context, id string, count int) ([]time.Time, error) {
+	todo, err := service.ReturnSingleTodo(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	return models.Todo{}, errors.New(fmt.Sprintf("could not find todo with id [%s]", newTodo.Id))
+	return previewOccurrences(todo, count)
 }
 
-// validateTodo applies validation rules against a Todo object to confirm it is valid
+// ReturnTrash returns every Todo item within the trash, in the order they were deleted
+func (service *TodoServiceImpl) ReturnTrash(_ context.Context) ([]models.Todo, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	todos := make([]models.Todo, 0, service.trashOrder.Len())
+	for element := service.trashOrder.Front(); element != nil; element = element.Next() {
+		todos = append(todos, element.Value.(models.Todo))
+	}
+	return todos, nil
+}
+
+// RestoreTodo moves the Todo item with an id matching the id passed as a parameter out of the trash, returning it with a
+// new version. Its references to any list or Todo items which no longer exist are dropped. If no such Todo item is within
+// the trash a NotFoundError is returned
+func (service *TodoServiceImpl) RestoreTodo(ctx context.Context, id string) (models.Todo, error) {
+	defer service.lock(ctx)()
+	element, ok := service.trash[id]
+	if !ok {
+		return models.Todo{}, &NotFoundError{Resource: "trashed todo", Id: id}
+	}
+	todo := element.Value.(models.Todo)
+	_, listExists := service.lists[todo.ListId]
+	now := service.options.now()
+	restored, err := restoreTodo(todo, listExists, service.find, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	changes := newChangeSet(ctx, now)
+	changes.record(ctx, models.ActionRestore, &todo, &restored)
+	err = service.commit(changes)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return restored, nil
+}
+
+// PurgeTodo permanently removes the Todo item with an id matching the id passed as a parameter from the trash. If no
+// such Todo item is within the trash a NotFoundError is returned
+func (service *TodoServiceImpl) PurgeTodo(ctx context.Context, id string) error {
+	defer service.lock(ctx)()
+	element, ok := service.trash[id]
+	if !ok {
+		return &NotFoundError{Resource: "trashed todo", Id: id}
+	}
+	todo := element.Value.(models.Todo)
+	changes := newChangeSet(ctx, service.options.now())
+	changes.record(ctx, models.ActionPurge, &todo, nil)
+	return service.commit(changes)
+}
+
+// PurgeTrash permanently removes every Todo item which has been within the trash for at least olderThan, returning the
+// number removed
+func (service *TodoServiceImpl) PurgeTrash(ctx context.Context, olderThan time.Duration) (int, error) {
+	defer service.lock(ctx)()
+	now := service.options.now()
+	cutoff := now.Add(-olderThan)
+	changes := newChangeSet(ctx, now)
+	for element := service.trashOrder.Front(); element != nil; element = element.Next() {
+		if todo := element.Value.(models.Todo); expired(todo, cutoff) {
+			changes.record(ctx, models.ActionPurge, &todo, nil)
+		}
+	}
+	err := service.commit(changes)
+	if err != nil {
+		return 0, err
+	}
+	return len(changes.revisions), nil
+}
+
+// ReturnHistory returns every Revision of the Todo item with an id matching the id passed as a parameter, in the order
+// they were recorded. If no such Todo item exists, and none ever did, a NotFoundError is returned
+func (service *TodoServiceImpl) ReturnHistory(_ context.Context, id string) ([]models.Revision, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	revisions := []models.Revision{}
+	for _, revision := range service.history {
+		if revision.TodoId == id {
+			revisions = append(revisions, revision)
+		}
+	}
+	_, live := service.todos[id]
+	_, trashed := service.trash[id]
+	if len(revisions) == 0 && !live && !trashed {
+		return nil, &NotFoundError{Resource: "todo", Id: id}
+	}
+	return revisions, nil
+}
+
+// QueryAudit returns a single page of the revisions of every Todo item which match the filters of the query, in the
+// order they were recorded. If the query is invalid a ValidationError is returned
+func (service *TodoServiceImpl) QueryAudit(_ context.Context, query AuditQuery) (AuditPage, error) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	return applyAuditQuery(service.history, query)
+}
+
+// RevertTodo returns the Todo item with an id matching the id passed as a parameter to how it was after the revision
+// with an id of revision, saving it as a new version. The revert is itself recorded as an update. If the revision does
+// not exist, or belongs to a different Todo item, a NotFoundError is returned
+func (service *TodoServiceImpl) RevertTodo(ctx context.Context, id string, revision int64, expectedVersion *int64) (models.Todo, error) {
+	// Revisions never change once recorded, so the lock only needs to be held while the revision is found. UpdateTodo
+	// takes the write lock itself
+	service.mu.RLock()
+	recorded := revision >= 1 && revision <= int64(len(service.history))
+	var found models.Revision
+	if recorded {
+		found = service.history[revision-1]
+	}
+	service.mu.RUnlock()
+	if !recorded {
+		return models.Todo{}, &NotFoundError{Resource: "revision", Id: strconv.FormatInt(revision, 10)}
+	}
+	reverted, err := revertedTodo(found, id)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return service.UpdateTodo(ctx, reverted, expectedVersion)
+}
+
+// A changeSet holds everything changed by a single command made to a TodoServiceImpl. All of it is decided before any
+// of it is committed, so each command is all-or-nothing. Composed of the following fields:
+//
+// actor: The actor who made the change, carried by the context of the command
+//
+// at: When the change was made
+//
+// revisions: The revisions recording each change made to a Todo item, in the order they were made
+//
+// lists: The lists saved by the change, as they were left
+//
+// deletedLists: The lists deleted by the change, as they were before being deleted
+type changeSet struct {
+	actor        string
+	at           time.Time
+	revisions    []models.Revision
+	lists        []models.List
+	deletedLists []models.List
+}
+
+// newChangeSet creates an empty changeSet for a command made by the actor carried by ctx at now
+func newChangeSet(ctx context.Context, now time.Time) changeSet {
+	return changeSet{actor: ActorFrom(ctx), at: now}
+}
+
+// record adds the Revision recording action, made by the actor carried by ctx, which changed before into after
+func (changes *changeSet) record(ctx context.Context, action models.RevisionAction, before *models.Todo, after *models.Todo) {
+	changes.revisions = append(changes.revisions, newRevision(ctx, action, before, after, changes.at))
+}
+
+// trash adds the Revision recording todo being moved into the trash
+func (changes *changeSet) trash(ctx context.Context, todo models.Todo) {
+	trashed := trashTodo(todo, changes.at)
+	changes.record(ctx, models.ActionDelete, &todo, &trashed)
+}
+
+// empty reports whether the command changed nothing
+func (changes changeSet) empty() bool {
+	return len(changes.revisions) == 0 && len(changes.lists) == 0 && len(changes.deletedLists) == 0
+}
+
+// commit makes every change within changes, which were decided against the current state by a single command, giving
+// each revision its id. When the service has a publish function the changes are handed to it, otherwise they are
+// applied directly. Nothing is committed when changes is empty. The caller must hold the write lock, taken by lock so
+// that the observers are notified once it is released
+func (service *TodoServiceImpl) commit(changes changeSet) error {
+	if changes.empty() {
+		return nil
+	}
+	for i := range changes.revisions {
+		changes.revisions[i].Id = int64(len(service.history) + i + 1)
+	}
+	if service.publish != nil {
+		err := service.publish(changes)
+		if err != nil {
+			return err
+		}
+	} else {
+		service.apply(changes)
+	}
+	service.committed = true
+	return nil
+}
+
+// apply makes every change within changes, whose revisions have already been given their ids. Each revision leaves its
+// Todo item as it was after the change, so the Todo items are replaced by those the revisions hold. The caller must hold
+// the write lock, or the service must not yet be in use
+func (service *TodoServiceImpl) apply(changes changeSet) {
+	for _, todoList := range changes.lists {
+		if element, exists := service.lists[todoList.Id]; exists {
+			element.Value = todoList
+		} else {
+			service.lists[todoList.Id] = service.listOrder.PushBack(todoList)
+		}
+	}
+	for _, revision := range changes.revisions {
+		id := revision.TodoId
+		switch revision.Action {
+		case models.ActionCreate, models.ActionUpdate, models.ActionRestore:
+			if element, trashed := service.trash[id]; trashed {
+				service.trashOrder.Remove(element)
+				delete(service.trash, id)
+			}
+			service.insert(revision.Todo)
+		case models.ActionDelete:
+			if element, exists := service.todos[id]; exists {
+				service.order.Remove(element)
+				delete(service.todos, id)
+			}
+			if element, trashed := service.trash[id]; trashed {
+				service.trashOrder.Remove(element)
+			}
+			service.trash[id] = service.trashOrder.PushBack(revision.Todo)
+		case models.ActionPurge:
+			if element, trashed := service.trash[id]; trashed {
+				service.trashOrder.Remove(element)
+				delete(service.trash, id)
+			}
+		}
+		service.history = append(service.history, revision)
+	}
+	for _, todoList := range changes.deletedLists {
+		if element, exists := service.lists[todoList.Id]; exists {
+			service.listOrder.Remove(element)
+			delete(service.lists, todoList.Id)
+		}
+	}
+}
+
+// revise replaces the Todo item held by element with todo, a validated revision of it. Any subtasks completed alongside
+// it and the Todo item for its next occurrence are worked out first, and nothing is committed unless all of them can
+// be, so a failure leaves the service as it was. The caller must hold the write lock
+func (service *TodoServiceImpl) revise(ctx context.Context, element *list.Element, todo models.Todo, now time.Time) (models.Todo, error) {
+	existing := element.Value.(models.Todo)
+	var subtasks []models.Todo
+	if completing(existing, todo) {
+		var err error
+		subtasks, err = applyCompletionRule(service.options.CompletionRule, descendantsOf(todo.Id, service.all()), now)
+		if err != nil {
+			return models.Todo{}, err
+		}
+	}
+	next, spawn, err := nextOccurrence(existing, todo, service.options.generateId, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	changes := newChangeSet(ctx, now)
+	for _, subtask := range subtasks {
+		before := service.todos[subtask.Id].Value.(models.Todo)
+		changes.record(ctx, models.ActionUpdate, &before, &subtask)
+	}
+	if spawn {
+		changes.record(ctx, models.ActionCreate, nil, &next)
+		todo.NextId = next.Id
+	}
+	changes.record(ctx, models.ActionUpdate, &existing, &todo)
+	err = service.commit(changes)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return todo, nil
+}
+
+// find returns the Todo item with an id matching the id passed as a parameter, and whether it exists. The caller must
+// hold the lock
+func (service *TodoServiceImpl) find(id string) (models.Todo, bool, error) {
+	element, ok := service.todos[id]
+	if !ok {
+		return models.Todo{}, false, nil
+	}
+	return element.Value.(models.Todo), true, nil
+}
+
+// children returns the direct subtasks of the Todo item with an id matching the id passed as a parameter, in the order
+// they were created. The caller must hold the lock
+func (service *TodoServiceImpl) children(id string) []models.Todo {
+	children := []models.Todo{}
+	for element := service.order.Front(); element != nil; element = element.Next() {
+		if todo := element.Value.(models.Todo); todo.ParentId == id {
+			children = append(children, todo)
+		}
+	}
+	return children
+}
+
+// dependants returns the number of Todo items which depend on the Todo item with an id matching the id passed as a
+// parameter. The caller must hold the lock
+func (service *TodoServiceImpl) dependants(id string) int {
+	count := 0
+	for element := service.order.Front(); element != nil; element = element.Next() {
+		if slices.Contains(element.Value.(models.Todo).DependsOn, id) {
+			count++
+		}
+	}
+	return count
+}
+
+// all returns every Todo item, in the order they were created. The caller must hold the lock
+func (service *TodoServiceImpl) all() []models.Todo {
+	todos := make([]models.Todo, 0, service.order.Len())
+	for element := service.order.Front(); element != nil; element = element.Next() {
+		todos = append(todos, element.Value.(models.Todo))
+	}
+	return todos
+}
+
+// insert adds a Todo item to the end of the creation order, replacing any existing Todo item with the same id in place.
+// The caller must hold the write lock
+func (service *TodoServiceImpl) insert(todo models.Todo) {
+	if element, exists := service.todos[todo.Id]; exists {
+		element.Value = todo
+		return
+	}
+	service.todos[todo.Id] = service.order.PushBack(todo)
+}
+
+// normaliseTodo converts the fields of todo which have a canonical form, its tags, prerequisites and recurrence rule,
+// into that form
+func normaliseTodo(todo models.Todo) models.Todo {
+	todo.Tags = normaliseTags(todo.Tags)
+	todo.DependsOn = normaliseDependencies(todo.DependsOn)
+	todo.Recurrence = normaliseRecurrence(todo.Recurrence)
+	return todo
+}
+
+// validateTodo applies validation rules against a Todo object to confirm it is valid. If any rules are broken then a
+// ValidationError listing every invalid field is returned
 func validateTodo(todo models.Todo) error {
+	var fields []FieldError
 	if todo.Id == "" {
-		return errors.New("todo Id cannot be null")
+		fields = append(fields, FieldError{Field: "Id", Message: "cannot be null"})
+	}
+	if !slices.Contains(models.Priorities, todo.Priority) {
+		fields = append(fields, FieldError{Field: "Priority", Message: "must be one of low, medium or high"})
+	}
+	if field := validateTags(todo.Tags); field != nil {
+		fields = append(fields, *field)
+	}
+	if field := validateRecurrence(todo); field != nil {
+		fields = append(fields, *field)
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Resource: "todo", Fields: fields}
+	}
+	return nil
+}
+
+// stampCreated sets the fields of a new Todo item which are managed by the service, giving it its first version and
+// timestamping it with now. A recurring Todo item is the first occurrence of its rule
+func stampCreated(todo models.Todo, now time.Time) models.Todo {
+	todo.Version = 1
+	todo.Blocked = false
+	todo.Occurrence = 0
+	if todo.Recurrence != "" {
+		todo.Occurrence = 1
+	}
+	todo.NextId = ""
+	todo.DeletedAt = nil
+	todo.CreatedAt = now
+	todo.UpdatedAt = now
+	todo.CompletedAt = nil
+	if todo.Completed {
+		todo.CompletedAt = &now
+	}
+	return todo
+}
+
+// stampRevised sets the fields of todo which are managed by the service, where todo is replacing existing. The version
+// is incremented, the creation time kept and the completion time only changed if todo has been completed or reopened.
+// The position of todo within its recurrence is kept, starting from the first occurrence if it has only just been given
+// a recurrence rule
+func stampRevised(existing models.Todo, todo models.Todo, now time.Time) models.Todo {
+	todo.Version = existing.Version + 1
+	todo.Blocked = false
+	todo.Occurrence = existing.Occurrence
+	if todo.Recurrence != "" && todo.Occurrence == 0 {
+		todo.Occurrence = 1
+	}
+	todo.NextId = existing.NextId
+	todo.DeletedAt = nil
+	todo.CreatedAt = existing.CreatedAt
+	todo.UpdatedAt = now
+	todo.CompletedAt = nil
+	if todo.Completed {
+		todo.CompletedAt = existing.CompletedAt
+		if !existing.Completed || existing.CompletedAt == nil {
+			todo.CompletedAt = &now
+		}
+	}
+	return todo
+}
+
+// checkVersion confirms that existing is at the expected version, returning a VersionMismatchError if it is not. A nil
+// expected version matches every version
+func checkVersion(existing models.Todo, expectedVersion *int64) error {
+	if expectedVersion != nil && *expectedVersion != existing.Version {
+		return &VersionMismatchError{Resource: "todo", Id: existing.Id, Expected: *expectedVersion, Actual: existing.Version}
 	}
 	return nil
 }
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// An EventTodoService represents a Service class responsible for functionality relating to Todo items, recording every
+// change as an event within an append-only stream from which the current state of each Todo item is derived
+//
+// The state is held by the projection, the embedded TodoServiceImpl, which answers every read. Each change is validated
+// against the projection and decided without modifying it. The revisions it decides are recorded as events, see
+// models.Event, which are appended to the stream and only then applied to the projection. The projection is never
+// changed in any other way, so discarding it and replaying the stream from its start, see RebuildProjection, derives
+// exactly the same state
+//
+// The stream is written to a JSON lines log, one event per line, and synced to disk before the events are applied. On
+// startup the projection is derived by replaying the log. A final event which was only partly written when the API
+// stopped is discarded, as the change it belongs to was never acknowledged. Without a log the stream is held in-memory,
+// so is lost when the API is stopped
+type EventTodoService struct {
+	*TodoServiceImpl
+	log      *os.File
+	size     int64
+	eventsMu sync.RWMutex
+	events   []models.Event
+}
+
+// NewEventTodoService creates a new EventTodoService object recording its event stream within the log found at path,
+// creating it if it does not already exist, or in-memory when path is empty. The events within the log are replayed to
+// derive the projection. This is used by Wire when starting the API to perform the necessary dependency injection
+func NewEventTodoService(path string, options Options) (*EventTodoService, error) {
+	service := &EventTodoService{TodoServiceImpl: NewTodoServiceImpl([]models.Todo{}, options), events: []models.Event{}}
+	service.publish = service.append
+	if path == "" {
+		return service, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	service.size, err = ReadLog(data, func(line []byte) error {
+		var event models.Event
+		err := json.Unmarshal(line, &event)
+		if err == nil {
+			service.events = append(service.events, event)
+		}
+		return err
+	})
+	if err == nil {
+		err = projectEvents(service.TodoServiceImpl, service.events)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load event log [%s]: %w", path, err)
+	}
+	// The log is truncated to the last complete event, discarding any event which was only partly written
+	err = os.Truncate(path, service.size)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	service.log, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	err = syncDir(path)
+	if err != nil {
+		service.log.Close()
+		return nil, err
+	}
+	return service, nil
+}
+
+// Close closes the log, if there is one. Every event has already been synced to disk, so nothing is lost. The
+// EventTodoService cannot be used once it has been closed
+func (service *EventTodoService) Close() error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	if service.log == nil {
+		return nil
+	}
+	return service.log.Close()
+}
+
+// CheckHealth reports the health of the log, checking that it is still open. Without a log the stream is held
+// in-memory, which is always healthy
+func (service *EventTodoService) CheckHealth(ctx context.Context) []ComponentHealth {
+	if service.log == nil {
+		return service.TodoServiceImpl.CheckHealth(ctx)
+	}
+	return []ComponentHealth{checkComponent("events.log", func() error {
+		_, err := service.log.Stat()
+		return err
+	})}
+}
+
+// ReturnEvents returns a page of the events within the stream, in the order they were recorded, starting after the
+// event whose Seq is query.After. If the query is invalid a ValidationError is returned
+func (service *EventTodoService) ReturnEvents(_ context.Context, query EventQuery) (EventPage, error) {
+	service.eventsMu.RLock()
+	defer service.eventsMu.RUnlock()
+	return applyEventQuery(service.events, query)
+}
+
+// RebuildProjection discards the projection and derives it again by replaying every event within the stream from its
+// start, returning the number of events replayed. Reads are answered by the previous projection while the stream is
+// replayed, then the events appended in the meantime are replayed while changes wait, before the previous projection is
+// replaced
+func (service *EventTodoService) RebuildProjection(_ context.Context) (int, error) {
+	service.eventsMu.RLock()
+	events := service.events
+	service.eventsMu.RUnlock()
+	projection := NewTodoServiceImpl([]models.Todo{}, service.options)
+	err := projectEvents(projection, events)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rebuild projection: %w", err)
+	}
+
+	// Events are only appended while the write lock is held, so none can be missed once it has been taken
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	service.eventsMu.RLock()
+	all := service.events
+	service.eventsMu.RUnlock()
+	err = projectEvents(projection, all[len(events):])
+	if err != nil {
+		return 0, fmt.Errorf("failed to rebuild projection: %w", err)
+	}
+	service.replaceWith(projection)
+	return len(all), nil
+}
+
+// append records changes, decided by a single command, as events. The events are written to the log and appended to
+// the stream, then applied to the projection. If they cannot be written the log is truncated back to the end of the
+// previous event and nothing is changed. It is called by commit, so the write lock is already held
+func (service *EventTodoService) append(changes changeSet) error {
+	events := changeEvents(changes)
+	// Only changes append to the stream, and they hold the write lock, so it cannot grow while the events are written
+	next := int64(len(service.events)) + 1
+	for i := range events {
+		events[i].Seq = next + int64(i)
+	}
+	err := service.write(events)
+	if err != nil {
+		return err
+	}
+
+	service.eventsMu.Lock()
+	service.events = append(service.events, events...)
+	service.eventsMu.Unlock()
+	err = projectEvents(service.TodoServiceImpl, events)
+	if err != nil {
+		return fmt.Errorf("failed to project events: %w", err)
+	}
+	return nil
+}
+
+// write writes events to the end of the log, one per line, and syncs it to disk. If they cannot be written the log is
+// truncated back to the end of the previous event. Nothing is written when there is no log. The caller must hold the
+// write lock
+func (service *EventTodoService) write(events []models.Event) error {
+	if service.log == nil {
+		return nil
+	}
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	for _, event := range events {
+		err := encoder.Encode(event)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := service.log.Write(data.Bytes())
+	if err == nil {
+		err = service.log.Sync()
+	}
+	if err == nil {
+		service.size += int64(data.Len())
+		return nil
+	}
+
+	err = fmt.Errorf("failed to write event log: %w", err)
+	truncateErr := service.log.Truncate(service.size)
+	if truncateErr != nil {
+		return errors.Join(err, truncateErr)
+	}
+	return err
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"github.com/google/go-cmp/cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupEventTest(t *testing.T, prerequisite []models.Todo) *EventTodoService {
+	return setupEventLogTest(t, filepath.Join(t.TempDir(), "events.jsonl"), prerequisite)
+}
+
+func setupEventLogTest(t *testing.T, path string, prerequisite []models.Todo) *EventTodoService {
+	service, err := NewEventTodoService(path, Options{AllowClientIds: true, Clock: testClock})
+	if err != nil {
+		t.Fatalf("Failed to create event todo service: [%v]", err)
+	}
+	t.Cleanup(func() { service.Close() })
+	for _, todo := range prerequisite {
+		_, err = service.CreateNewTodo(context.Background(), todo)
+		if err != nil {
+			t.Fatalf("Failed to persist prerequisite todo: [%v]", err)
+		}
+	}
+	return service
+}
+
+func TestEventTodoServiceProjection(t *testing.T) {
+	ctx := context.Background()
+	dueAt := testTime
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	service := setupEventLogTest(t, path, []models.Todo{
+		{Id: "1", Title: "Bake cake", Tags: []string{"home"}},
+		{Id: "2", Title: "Buy flour", ParentId: "1"},
+		{Id: "3", Title: "Standup", DueAt: &dueAt, Recurrence: "FREQ=DAILY"},
+	})
+	service.options.CompletionRule = CompletionCascade
+	_, err := service.CreateNewList(ctx, models.List{Id: "chores", Name: "Chores"})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	_, err = service.UpdateTodo(WithActor(ctx, "alice"), models.Todo{Id: "1", Title: "Bake bread", Tags: []string{"home"}, Completed: true}, nil)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	_, err = service.PatchTodo(ctx, "3", TodoPatch{Type: MergePatchType, Document: []byte(`{"Completed":true}`)}, nil)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	_, err = service.MoveTodo(ctx, "3", "chores", nil)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	err = service.DeleteList(ctx, "chores", ListDeleteCascade, nil)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	_, err = service.RestoreTodo(ctx, "3")
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	_, err = service.RenameTag(ctx, "home", "house")
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	_, err = service.RevertTodo(ctx, "1", 1, nil)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+
+	page, err := service.ReturnEvents(ctx, EventQuery{Limit: 500})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	diff := cmp.Diff([]models.EventType{
+		models.EventTodoCreated, models.EventTodoCreated, models.EventTodoCreated, models.EventListCreated,
+		// Completing 1 cascades to its subtask 2
+		models.EventTodoCompleted, models.EventTodoRenamed, models.EventTodoCompleted,
+		// Completing 3 creates its next occurrence
+		models.EventTodoCreated, models.EventTodoCompleted, models.EventTodoUpdated,
+		models.EventTodoUpdated, models.EventTodoDeleted, models.EventListDeleted,
+		models.EventTodoRestored,
+		models.EventTodoUpdated,
+		models.EventTodoRenamed, models.EventTodoReopened, models.EventTodoUpdated,
+	}, eventTypes(page.Events))
+	if diff != "" {
+		t.Fatal(diff)
+	}
+
+	// Rebuilding the projection from the stream, or reopening the log, derives exactly the same state
+	expected := fileContents(t, service)
+	if len(expected.Todos) != 4 || len(expected.History) != 12 {
+		t.Fatalf("unexpected projection [%+v]", expected)
+	}
+	replayed, err := service.RebuildProjection(ctx)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if replayed != len(page.Events) {
+		t.Fatalf("Expected [%d] events to be replayed but was [%d]", len(page.Events), replayed)
+	}
+	diff = cmp.Diff(expected, fileContents(t, service))
+	if diff != "" {
+		t.Fatal(diff)
+	}
+	service.Close()
+	reopened := setupEventLogTest(t, path, nil)
+	diff = cmp.Diff(expected, fileContents(t, reopened))
+	if diff != "" {
+		t.Fatal(diff)
+	}
+	reopenedPage, err := reopened.ReturnEvents(ctx, EventQuery{Limit: 500})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	diff = cmp.Diff(page.Events, reopenedPage.Events)
+	if diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestEventTodoServiceDamagedLog(t *testing.T) {
+	tests := map[string]struct {
+		damage        func(log string) string
+		expectedTodos []string
+		expectedError string
+	}{
+		"Torn Final Event": {
+			damage: func(log string) string {
+				return log[:len(log)-10]
+			},
+			expectedTodos: []string{"1"},
+		},
+		"Corrupt Earlier Event": {
+			damage: func(log string) string {
+				return "not json\n" + log
+			},
+			expectedError: "failed to load event log",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "events.jsonl")
+			service := setupEventLogTest(t, path, []models.Todo{{Id: "1", Title: "Bake cake"}, {Id: "2", Title: "Buy flour"}})
+			service.Close()
+			log, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = os.WriteFile(path, []byte(tt.damage(string(log))), 0o644)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+
+			reopened, err := NewEventTodoService(path, Options{AllowClientIds: true, Clock: testClock})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Error message not as expected, expected [%v] but was [%v]", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			defer reopened.Close()
+			todos, err := reopened.ReturnAllTodos(context.Background())
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff(tt.expectedTodos, ids(todos))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			// The discarded event is overwritten by the next one appended
+			_, err = reopened.CreateNewTodo(context.Background(), models.Todo{Id: "3", Title: "Buy eggs"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			page, err := reopened.ReturnEvents(context.Background(), EventQuery{})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if len(page.Events) != 2 || page.Events[1].Seq != 2 {
+				t.Errorf("unexpected events [%+v]", page.Events)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// snapshotSuffix is appended to the path of the log of a FileTodoService to name the file its snapshot is kept in
+const snapshotSuffix = ".snapshot"
+
+// A FileTodoService represents a Service class responsible for functionality relating to Todo items, persisting them
+// within an append-only JSON lines log so that they survive restarts of the API without needing a database
+//
+// Todo items are held in-memory by an embedded TodoServiceImpl, which answers every read. Each change is decided first,
+// then written to the log as a single record and synced to disk, and only then applied, in the same order as an
+// EventTodoService. A record holds the revisions the change
+// recorded, which contain the Todo items as they were left, alongside any lists which were saved or deleted. On startup
+// the latest snapshot is loaded and every record written since is replayed on top of it. A final record which was only
+// partly written when the API stopped is discarded, as the change it describes was never acknowledged
+//
+// Once the log holds compactAfter records it is compacted, writing every Todo item, list and revision into a new
+// snapshot and emptying the log. Each record carries a sequence number, and the snapshot the number of the last record
+// it includes, so records left behind by a compaction which was interrupted are skipped when they are replayed
+type FileTodoService struct {
+	*TodoServiceImpl
+	path         string
+	log          *os.File
+	size         int64
+	seq          int64
+	records      int
+	compactAfter int
+	compactErr   error
+}
+
+// fileSnapshot is the document a FileTodoService writes its snapshot as. Composed of the following fields:
+//
+// Seq: The sequence number of the last record included in the snapshot
+//
+// Todos: Every Todo item, in the order they were created
+//
+// Trash: Every Todo item within the trash, in the order they were deleted
+//
+// Lists: Every list, in the order they were created
+//
+// History: Every Revision, in the order they were recorded
+type fileSnapshot struct {
+	Seq     int64             `json:"Seq"`
+	Todos   []models.Todo     `json:"Todos"`
+	Trash   []models.Todo     `json:"Trash"`
+	Lists   []models.List     `json:"Lists"`
+	History []models.Revision `json:"History"`
+}
+
+// fileRecord is a single line of the log of a FileTodoService, describing one change. Composed of the following fields:
+//
+// Seq: The sequence number of the record, one higher than the record before it
+//
+// Lists: The lists saved by the change, as they were left
+//
+// Revisions: The revisions recorded by the change, in the order they were recorded
+//
+// DeletedLists: The ids of the lists deleted by the change
+type fileRecord struct {
+	Seq          int64             `json:"Seq"`
+	Lists        []models.List     `json:"Lists,omitempty"`
+	Revisions    []models.Revision `json:"Revisions,omitempty"`
+	DeletedLists []string          `json:"DeletedLists,omitempty"`
+}
+
+// NewFileTodoService creates a new FileTodoService object persisting Todo items within the log found at path, creating it
+// if it does not already exist, compacting the log once it holds compactAfter records. The snapshot and log are
+// replayed to restore the Todo items persisted before the API was last stopped. This is used by Wire when starting the
+// API to perform the necessary dependency injection
+func NewFileTodoService(path string, options Options, compactAfter int) (*FileTodoService, error) {
+	memory := NewTodoServiceImpl([]models.Todo{}, options)
+	seq, records, size, err := loadFile(path, memory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load todo log [%s]: %w", path, err)
+	}
+	// The log is truncated to the last complete record, discarding any record which was only partly written
+	err = os.Truncate(path, size)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	log, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	err = syncDir(path)
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+	service := &FileTodoService{TodoServiceImpl: memory, path: path, log: log, size: size, seq: seq, records: records,
+		compactAfter: compactAfter}
+	memory.publish = service.append
+	return service, nil
+}
+
+// Close closes the log. Every change has already been synced to disk, so nothing is lost. The FileTodoService cannot be
+// used once it has been closed
+func (service *FileTodoService) Close() error {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	return service.log.Close()
+}
+
+// CheckHealth reports the health of the log, checking that it is still open, and whether the last attempt to compact it
+// succeeded
+func (service *FileTodoService) CheckHealth(_ context.Context) []ComponentHealth {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	return []ComponentHealth{
+		checkComponent("file.log", func() error {
+			_, err := service.log.Stat()
+			return err
+		}),
+		checkComponent("file.compaction", func() error {
+			return service.compactErr
+		}),
+	}
+}
+
+// append writes the changes decided by a single command to the end of the log as one record and syncs it to disk, and
+// only then applies them to the Todo items held in-memory, so nothing is visible to reads before it has been persisted.
+// If the record cannot be written the log is truncated back to the end of the previous record and nothing is applied,
+// so the ids of the revisions it held are assigned again by the next change. The log is compacted once it is due. The
+// caller must hold the write lock
+func (service *FileTodoService) append(changes changeSet) error {
+	record := fileRecord{Seq: service.seq + 1, Lists: changes.lists, Revisions: changes.revisions}
+	for _, todoList := range changes.deletedLists {
+		record.DeletedLists = append(record.DeletedLists, todoList.Id)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = service.log.Write(append(data, '\n'))
+	if err == nil {
+		err = service.log.Sync()
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to write todo log: %w", err)
+		truncateErr := service.log.Truncate(service.size)
+		if truncateErr != nil {
+			return errors.Join(err, truncateErr)
+		}
+		return err
+	}
+
+	service.size += int64(len(data)) + 1
+	service.apply(changes)
+	service.seq = record.Seq
+	service.records++
+	if service.records >= service.compactAfter {
+		service.compactErr = service.compact()
+	}
+	return nil
+}
+
+// compact writes every Todo item, list and revision into a new snapshot, replacing the previous one, then empties the
+// log. The snapshot is written to a temporary file which is renamed over the previous snapshot once it has been synced,
+// so a complete snapshot is always on disk. The caller must hold the write lock
+func (service *FileTodoService) compact() error {
+	snapshot := service.snapshot(service.seq)
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	snapshotPath := service.path + snapshotSuffix
+	temporary := snapshotPath + ".tmp"
+	err = writeSynced(temporary, data)
+	if err != nil {
+		return fmt.Errorf("failed to write todo snapshot: %w", err)
+	}
+	err = os.Rename(temporary, snapshotPath)
+	if err == nil {
+		err = syncDir(snapshotPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to replace todo snapshot: %w", err)
+	}
+	// Every record in the log is now within the snapshot, so should the API stop before the log has been emptied they
+	// are skipped when it is next replayed
+	err = service.log.Truncate(0)
+	if err == nil {
+		err = service.log.Sync()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to empty todo log: %w", err)
+	}
+	service.size = 0
+	service.records = 0
+	return nil
+}
+
+// loadFile restores the Todo items persisted within the log found at path, and its snapshot, into memory, which must not
+// yet be in use. It returns the sequence number of the last record, the number of records replayed from the log and the
+// size of the log up to the end of the last complete record. A missing log or snapshot is treated as empty
+func loadFile(path string, memory *TodoServiceImpl) (int64, int, int64, error) {
+	var snapshot fileSnapshot
+	data, err := os.ReadFile(path + snapshotSuffix)
+	if err == nil {
+		err = json.Unmarshal(data, &snapshot)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("snapshot is corrupt: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, 0, err
+	}
+	restoreSnapshot(memory, snapshot)
+
+	data, err = os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return snapshot.Seq, 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	seq := snapshot.Seq
+	records := 0
+	size, err := ReadLog(data, func(line []byte) error {
+		var record fileRecord
+		err := json.Unmarshal(line, &record)
+		if err != nil {
+			return err
+		}
+		if record.Seq > seq {
+			applyRecord(memory, record)
+			seq = record.Seq
+		}
+		records++
+		return nil
+	})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return seq, records, size, nil
+}
+
+// readLog calls decode with each line of data, the contents of an append-only JSON lines log, in order. It returns the
+// size of the log up to the end of the last complete line. Only the final line can have been partly written when the
+// API stopped, so it is discarded if it is unterminated or cannot be decoded, while any earlier line which cannot be
+// decoded means the log has been damaged and an error is returned
+func ReadLog(data []byte, decode func(line []byte) error) (int64, error) {
+	var size int64
+	for line := 1; len(data) > 0; line++ {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			break
+		}
+		if decode(data[:end]) != nil {
+			if len(bytes.TrimSpace(data[end+1:])) == 0 {
+				break
+			}
+			return 0, fmt.Errorf("record on line %d is corrupt", line)
+		}
+		size += int64(end) + 1
+		data = data[end+1:]
+	}
+	return size, nil
+}
+
+// restoreSnapshot loads every Todo item, list and revision within snapshot into memory, which must not yet be in use
+func restoreSnapshot(memory *TodoServiceImpl, snapshot fileSnapshot) {
+	for _, todo := range snapshot.Todos {
+		memory.insert(todo)
+	}
+	for _, todo := range snapshot.Trash {
+		memory.trash[todo.Id] = memory.trashOrder.PushBack(todo)
+	}
+	for _, todoList := range snapshot.Lists {
+		memory.lists[todoList.Id] = memory.listOrder.PushBack(todoList)
+	}
+	memory.history = append(memory.history, snapshot.History...)
+}
+
+// applyRecord replays the change described by record onto memory, which must not yet be in use. Each revision leaves
+// its Todo item as it was after the change, so the change is replayed without being made again
+func applyRecord(memory *TodoServiceImpl, record fileRecord) {
+	changes := changeSet{revisions: record.Revisions, lists: record.Lists}
+	for _, id := range record.DeletedLists {
+		changes.deletedLists = append(changes.deletedLists, models.List{Id: id})
+	}
+	memory.apply(changes)
+}
+
+// snapshot returns every Todo item, list and revision held by the TodoServiceImpl, as included in a snapshot whose last
+// record has the sequence number seq. The caller must hold the lock
+func (service *TodoServiceImpl) snapshot(seq int64) fileSnapshot {
+	snapshot := fileSnapshot{Seq: seq, Todos: service.all(), Trash: values[models.Todo](service.trashOrder),
+		Lists: values[models.List](service.listOrder), History: service.history}
+	return snapshot
+}
+
+// replaceWith replaces every Todo item, list and revision held by the TodoServiceImpl with those held by other, which
+// must not be in use. The caller must hold the write lock
+func (service *TodoServiceImpl) replaceWith(other *TodoServiceImpl) {
+	service.todos, service.order = other.todos, other.order
+	service.lists, service.listOrder = other.lists, other.listOrder
+	service.trash, service.trashOrder = other.trash, other.trashOrder
+	service.history = other.history
+}
+
+// values returns every value held by elements, in order
+func values[T any](elements *list.List) []T {
+	result := make([]T, 0, elements.Len())
+	for element := elements.Front(); element != nil; element = element.Next() {
+		result = append(result, element.Value.(T))
+	}
+	return result
+}
+
+// writeSynced writes data to a new file at path, replacing any existing file, and syncs it to disk
+func writeSynced(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	return errors.Join(err, file.Close())
+}
+
+// syncDir syncs the directory containing path to disk, so that a file created or renamed within it survives a crash
+func syncDir(path string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	err = dir.Sync()
+	return errors.Join(err, dir.Close())
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupFileTest(t *testing.T, path string, compactAfter int, prerequisite []models.Todo) *FileTodoService {
+	service, err := NewFileTodoService(path, Options{AllowClientIds: true, Clock: testClock}, compactAfter)
+	if err != nil {
+		t.Fatalf("Failed to create file todo service: [%v]", err)
+	}
+	t.Cleanup(func() { service.Close() })
+	for _, todo := range prerequisite {
+		_, err = service.CreateNewTodo(context.Background(), todo)
+		if err != nil {
+			t.Fatalf("Failed to persist prerequisite todo: [%v]", err)
+		}
+	}
+	return service
+}
+
+// fileContents returns every Todo item, Todo item within the trash, list and revision held by service
+func fileContents(t *testing.T, service Store) fileSnapshot {
+	ctx := context.Background()
+	todos, err := service.ReturnAllTodos(ctx)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	trash, err := service.ReturnTrash(ctx)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	lists, err := service.ReturnAllLists(ctx)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	page, err := service.QueryAudit(ctx, AuditQuery{Limit: 500})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	return fileSnapshot{Todos: todos, Trash: trash, Lists: lists, History: page.Revisions}
+}
+
+func TestFileTodoServiceReplaysChanges(t *testing.T) {
+	tests := map[string]struct {
+		compactAfter int
+	}{
+		"Replayed From Log": {
+			compactAfter: 1000,
+		},
+		"Replayed From Snapshot And Log": {
+			compactAfter: 4,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "todos.jsonl")
+			service := setupFileTest(t, path, tt.compactAfter, []models.Todo{
+				{Id: "1", Title: "Bake cake", Tags: []string{"home"}},
+				{Id: "2", Title: "Buy flour", Tags: []string{"home"}},
+				{Id: "3", Title: "Walk dog"},
+			})
+			groceries, err := service.CreateNewList(ctx, models.List{Id: "groceries", Name: "Groceries"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = service.CreateNewList(ctx, models.List{Id: "chores", Name: "Chores"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			groceries.Name = "Shopping"
+			_, err = service.UpdateList(ctx, groceries, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = service.MoveTodo(ctx, "2", "groceries", nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = service.MoveTodo(ctx, "3", "chores", nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = service.DeleteList(ctx, "chores", ListDeleteCascade, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = service.RenameTag(ctx, "home", "house")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = service.DeleteTodo(WithActor(ctx, "alice"), "1", nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = service.PurgeTodo(ctx, "3")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			// Changes which fail are not written to the log
+			_, err = service.UpdateTodo(ctx, models.Todo{Id: "missing", Title: "Missing"}, nil)
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+			expected := fileContents(t, service)
+			service.Close()
+
+			reopened := setupFileTest(t, path, tt.compactAfter, nil)
+			diff := cmp.Diff(expected, fileContents(t, reopened))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			// Revisions carry on from those replayed
+			_, err = reopened.RestoreTodo(ctx, "1")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			history, err := reopened.ReturnHistory(ctx, "1")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			last := history[len(history)-1]
+			if last.Id != int64(len(expected.History)+1) || last.Action != models.ActionRestore {
+				t.Fatalf("Expected revision [%d] to restore the todo but was %+v", len(expected.History)+1, last)
+			}
+		})
+	}
+}
+
+func TestFileTodoServiceCompactsLog(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "todos.jsonl")
+	service := setupFileTest(t, path, 2, []models.Todo{{Id: "1", Title: "Bake cake"}, {Id: "2", Title: "Buy flour"}})
+	_, err := service.CreateNewTodo(ctx, models.Todo{Id: "3", Title: "Walk dog"})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	log, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if lines := strings.Count(string(log), "\n"); lines != 1 {
+		t.Fatalf("Expected [1] record to be left in the log after compacting but found [%d]", lines)
+	}
+	if _, err = os.Stat(path + snapshotSuffix); err != nil {
+		t.Fatalf("Expected a snapshot to be written but was [%v]", err)
+	}
+	for _, component := range service.CheckHealth(ctx) {
+		if component.Status != HealthUp {
+			t.Fatalf("Expected component [%s] to be up but was %+v", component.Name, component)
+		}
+	}
+}
+
+func TestFileTodoServiceSkipsRecordsWithinSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.jsonl")
+	service := setupFileTest(t, path, 1000, []models.Todo{{Id: "1", Title: "Bake cake"}})
+	expected := fileContents(t, service)
+	log, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	// The API stopping after a snapshot was written but before the log was emptied leaves records within both
+	service.mu.Lock()
+	err = service.compact()
+	service.mu.Unlock()
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	service.Close()
+	err = os.WriteFile(path, log, 0o644)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+
+	reopened := setupFileTest(t, path, 1000, nil)
+	diff := cmp.Diff(expected, fileContents(t, reopened))
+	if diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestFileTodoServiceDamagedLog(t *testing.T) {
+	tests := map[string]struct {
+		damage        func(log string) string
+		expectedTodos []string
+		expectedError string
+	}{
+		"Torn Final Record": {
+			damage: func(log string) string {
+				return log[:len(log)-10]
+			},
+			expectedTodos: []string{"1"},
+		},
+		"Unterminated Final Record": {
+			damage: func(log string) string {
+				return log[:len(log)-1]
+			},
+			expectedTodos: []string{"1"},
+		},
+		"Corrupt Final Record": {
+			damage: func(log string) string {
+				return log + "{\"Seq\":3,\x00\x00\n"
+			},
+			expectedTodos: []string{"1", "2"},
+		},
+		"Corrupt Earlier Record": {
+			damage: func(log string) string {
+				return "not json\n" + log
+			},
+			expectedError: "record on line 1 is corrupt",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			path := filepath.Join(t.TempDir(), "todos.jsonl")
+			service := setupFileTest(t, path, 1000, []models.Todo{{Id: "1", Title: "Bake cake"}, {Id: "2", Title: "Buy flour"}})
+			service.Close()
+			log, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = os.WriteFile(path, []byte(tt.damage(string(log))), 0o644)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+
+			reopened, err := NewFileTodoService(path, Options{AllowClientIds: true, Clock: testClock}, 1000)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Error [%s] expected but was [%v]", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			t.Cleanup(func() { reopened.Close() })
+			todos, err := reopened.ReturnAllTodos(ctx)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff(tt.expectedTodos, ids(todos))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			// The damaged record is discarded, so records written afterwards can still be replayed
+			_, err = reopened.CreateNewTodo(ctx, models.Todo{Id: "3", Title: "Walk dog"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			reopened.Close()
+			replayed := setupFileTest(t, path, 1000, nil)
+			todos, err = replayed.ReturnAllTodos(ctx)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff(append(tt.expectedTodos, "3"), ids(todos))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestFileTodoServiceFailedChangeWritesNothing(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "todos.jsonl")
+	service, err := NewFileTodoService(path, Options{AllowClientIds: true, Clock: testClock, CompletionRule: CompletionRequire}, 1000)
+	if err != nil {
+		t.Fatalf("Failed to create file todo service: [%v]", err)
+	}
+	t.Cleanup(func() { service.Close() })
+	for _, todo := range []models.Todo{{Id: "1", Title: "Bake cake"}, {Id: "2", Title: "Buy flour", ParentId: "1"}} {
+		_, err = service.CreateNewTodo(ctx, todo)
+		if err != nil {
+			t.Fatalf("Failed to persist prerequisite todo: [%v]", err)
+		}
+	}
+	expected := fileContents(t, service)
+	log, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+
+	stale := int64(5)
+	_, err = service.UpdateTodo(ctx, models.Todo{Id: "1", Title: "Bake bread"}, &stale)
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("unexpected error, expected a version mismatch but recieved [%v]", err)
+	}
+	_, err = service.PatchTodo(ctx, "1", TodoPatch{Type: MergePatchType, Document: []byte(`{"Completed": true}`)}, nil)
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("unexpected error, expected the open subtask to prevent completion but recieved [%v]", err)
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if string(after) != string(log) {
+		t.Errorf("unexpected log, expected [%s] but recieved [%s]", log, after)
+	}
+	diff := cmp.Diff(expected, fileContents(t, service))
+	if diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestFileTodoServiceFailedWriteAppliesNothing(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "todos.jsonl")
+	service := setupFileTest(t, path, 1000, []models.Todo{{Id: "1", Title: "Bake cake"}})
+	expected := fileContents(t, service)
+
+	// Closing the log makes the next record fail to be written
+	service.log.Close()
+	_, err := service.UpdateTodo(ctx, models.Todo{Id: "1", Title: "Bake bread"}, nil)
+	if err == nil {
+		t.Fatal("Error expected when none occured")
+	}
+	diff := cmp.Diff(expected, fileContents(t, service))
+	if diff != "" {
+		t.Fatal(diff)
+	}
+
+	service.log, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	_, err = service.UpdateTodo(ctx, models.Todo{Id: "1", Title: "Bake pie"}, nil)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	// The id of the revision which failed to be written is assigned to the next, so none are skipped
+	page, err := service.QueryAudit(ctx, AuditQuery{Limit: 500})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if len(page.Revisions) != 2 || page.Revisions[1].Id != 2 {
+		t.Fatalf("unexpected revisions, expected the update to be revision [2] but recieved [%+v]", page.Revisions)
+	}
+	service.Close()
+	diff = cmp.Diff(fileContents(t, service), fileContents(t, setupFileTest(t, path, 1000, nil)))
+	if diff != "" {
+		t.Fatal(diff)
+	}
+}
@@ -0,0 +1,1219 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// sqliteMigrations contains the statements used to build the SQLite schema. Each entry is applied exactly once, in
+// order, and the number of applied entries is tracked using the database's user_version pragma. Existing entries must
+// never be modified, new schema changes should be appended as a new entry instead
+var sqliteMigrations = []string{
+	`CREATE TABLE todos (
+		seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+		id          TEXT    NOT NULL UNIQUE,
+		title       TEXT    NOT NULL,
+		description TEXT    NOT NULL,
+		completed   INTEGER NOT NULL
+	)`,
+	`ALTER TABLE todos ADD COLUMN version INTEGER NOT NULL DEFAULT 1`,
+	`ALTER TABLE todos ADD COLUMN created_at TEXT NOT NULL DEFAULT '';
+	ALTER TABLE todos ADD COLUMN updated_at TEXT NOT NULL DEFAULT '';
+	ALTER TABLE todos ADD COLUMN completed_at TEXT;
+	ALTER TABLE todos ADD COLUMN due_at TEXT;
+	ALTER TABLE todos ADD COLUMN priority TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE todos ADD COLUMN tags TEXT NOT NULL DEFAULT '[]'`,
+	`CREATE TABLE lists (
+		seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+		id          TEXT    NOT NULL UNIQUE,
+		name        TEXT    NOT NULL,
+		description TEXT    NOT NULL,
+		version     INTEGER NOT NULL,
+		created_at  TEXT    NOT NULL,
+		updated_at  TEXT    NOT NULL
+	);
+	ALTER TABLE todos ADD COLUMN list_id TEXT NOT NULL DEFAULT '';
+	CREATE INDEX todos_list_id ON todos (list_id)`,
+	`ALTER TABLE todos ADD COLUMN parent_id TEXT NOT NULL DEFAULT '';
+	CREATE INDEX todos_parent_id ON todos (parent_id)`,
+	`ALTER TABLE todos ADD COLUMN depends_on TEXT NOT NULL DEFAULT '[]'`,
+	`ALTER TABLE todos ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
+	ALTER TABLE todos ADD COLUMN occurrence INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE todos ADD COLUMN next_id TEXT NOT NULL DEFAULT ''`,
+	`CREATE TABLE trash (
+		seq        INTEGER PRIMARY KEY AUTOINCREMENT,
+		id         TEXT    NOT NULL UNIQUE,
+		deleted_at TEXT    NOT NULL,
+		todo       TEXT    NOT NULL
+	)`,
+	`CREATE TABLE revisions (
+		seq     INTEGER PRIMARY KEY AUTOINCREMENT,
+		todo_id TEXT    NOT NULL,
+		version INTEGER NOT NULL,
+		action  TEXT    NOT NULL,
+		actor   TEXT    NOT NULL,
+		at      TEXT    NOT NULL,
+		changes TEXT    NOT NULL,
+		todo    TEXT    NOT NULL
+	);
+	CREATE INDEX revisions_todo_id ON revisions (todo_id)`,
+}
+
+// revisionColumns lists the columns of the revisions table read into a models.Revision by selectRevisions, in the order
+// it expects them
+const revisionColumns = "seq, todo_id, version, action, actor, at, changes, todo"
+
+// todoColumns lists the columns of the todos table read into a models.Todo by scanTodo, in the order it expects them
+const todoColumns = "id, title, description, completed, version, created_at, updated_at, completed_at, due_at, priority, " +
+	"tags, list_id, parent_id, depends_on, recurrence, occurrence, next_id"
+
+// A SqliteTodoService represents a Service class responsible for functionality relating to Todo items, persisting them
+// within an embedded SQLite database file so that they survive restarts of the API
+//
+// Todo items are returned in the order they were created, matching the behaviour of TodoServiceImpl. Deleted Todo items
+// are moved into a separate trash table, holding each as a JSON document so that it does not need to change whenever a
+// column is added to the todos table. Revisions are held in the same way within the revisions table, whose sequence
+// number is used as the id of each revision
+type SqliteTodoService struct {
+	db      *sql.DB
+	options Options
+	observers
+}
+
+// OpenSqliteDB opens the SQLite database file found at path, creating it if it does not already exist
+func OpenSqliteDB(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite only supports a single writer, limiting the pool to one connection avoids "database is locked" errors
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// NewSqliteTodoService creates a new SqliteTodoService object, creating the schema within db if it has not already been
+// created. This is used by Wire when starting the API to perform the necessary dependency injection
+func NewSqliteTodoService(db *sql.DB, options Options) (*SqliteTodoService, error) {
+	err := migrateSqlite(context.Background(), db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate sqlite database: %w", err)
+	}
+	return &SqliteTodoService{db: db, options: options}, nil
+}
+
+// Close flushes any outstanding writes to the SQLite database file and closes it. The SqliteTodoService cannot be used
+// once it has been closed
+func (service *SqliteTodoService) Close() error {
+	return service.db.Close()
+}
+
+// migrateSqlite applies any entries of sqliteMigrations which have not yet been applied to db
+func migrateSqlite(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var version int
+	err = tx.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version)
+	if err != nil {
+		return err
+	}
+	if version >= len(sqliteMigrations) {
+		return nil
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		_, err = tx.ExecContext(ctx, sqliteMigrations[i])
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+	// PRAGMA statements cannot be parameterised, so the version is formatted into the statement
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", len(sqliteMigrations)))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReturnAllTodos returns all Todo items currently persisted within the DB
+func (service *SqliteTodoService) ReturnAllTodos(ctx context.Context) ([]models.Todo, error) {
+	return selectTodos(ctx, service.db, "SELECT "+todoColumns+" FROM todos ORDER BY seq")
+}
+
+// QueryTodos returns a single page of the Todo items persisted within the DB which match the filters of the query,
+// sorted as requested. If the query is invalid a ValidationError is returned
+func (service *SqliteTodoService) QueryTodos(ctx context.Context, query TodoQuery) (TodoPage, error) {
+	query, after, err := normaliseQuery(query)
+	if err != nil {
+		return TodoPage{}, err
+	}
+	statement, args := todoQueryStatement(query, after, service.options.now())
+	rows, err := service.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return TodoPage{}, err
+	}
+	defer rows.Close()
+
+	matches := make([]models.Todo, 0, query.Limit+1)
+	for rows.Next() {
+		var blocked bool
+		todo, err := scanTodo(rows, &blocked)
+		if err != nil {
+			return TodoPage{}, err
+		}
+		todo.Blocked = blocked
+		matches = append(matches, todo)
+	}
+	if err = rows.Err(); err != nil {
+		return TodoPage{}, err
+	}
+	return pageOf(matches, query), nil
+}
+
+// CountTodos returns the number of Todo items currently persisted within the DB
+func (service *SqliteTodoService) CountTodos(ctx context.Context) (TodoCounts, error) {
+	var counts TodoCounts
+	err := service.db.QueryRowContext(ctx, "SELECT COUNT(*), COALESCE(SUM(completed), 0) FROM todos").
+		Scan(&counts.Total, &counts.Completed)
+	return counts, err
+}
+
+// CheckHealth reports the health of the SQLite database, checking that it can be reached, that every migration has been
+// applied and that the database file can be written to
+func (service *SqliteTodoService) CheckHealth(ctx context.Context) []ComponentHealth {
+	return []ComponentHealth{
+		checkComponent("sqlite.connection", func() error {
+			return service.db.PingContext(ctx)
+		}),
+		checkComponent("sqlite.migrations", func() error {
+			var version int
+			err := service.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version)
+			if err != nil {
+				return err
+			}
+			if version != len(sqliteMigrations) {
+				return fmt.Errorf("schema is at version [%d] not the expected version [%d]", version, len(sqliteMigrations))
+			}
+			return nil
+		}),
+		checkComponent("sqlite.writable", func() error {
+			// Rewriting the schema version within a transaction which is rolled back checks the database file can be
+			// written to, without changing it
+			tx, err := service.db.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+			_, err = tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", len(sqliteMigrations)))
+			return err
+		}),
+	}
+}
+
+// ReturnSingleTodo returns a single Todo item, identified via the id param, with its Blocked value computed. If no Todo
+// item is found with a matching Id then an error is returned
+func (service *SqliteTodoService) ReturnSingleTodo(ctx context.Context, id string) (models.Todo, error) {
+	tx, err := service.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return models.Todo{}, err
+	}
+	defer tx.Rollback()
+
+	todo, err := selectTodo(ctx, tx, id)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	todo.Blocked, err = blocked(todo, findTodo(ctx, tx))
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return todo, nil
+}
+
+// CreateNewTodo persists a new Todo item in the DB. If a existing Todo item with an id matching that of the new Todo item
+// is found within the DB, or within the trash, then an error will be returned
+// If the Todo item passed as a parameter does not include an id then one is generated
+func (service *SqliteTodoService) CreateNewTodo(ctx context.Context, newTodo models.Todo) (models.Todo, error) {
+	var err error
+	newTodo.Id, err = service.options.assignId("todo", newTodo.Id)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	newTodo = normaliseTodo(newTodo)
+	err = validateTodo(newTodo)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM todos WHERE id = ?) OR EXISTS(SELECT 1 FROM trash WHERE id = ?)",
+		newTodo.Id, newTodo.Id).Scan(&exists)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	if exists {
+		return models.Todo{}, &ConflictError{Resource: "todo", Id: newTodo.Id}
+	}
+	err = checkListExists(ctx, tx, newTodo)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkParent(newTodo, findTodo(ctx, tx))
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkDependencies(newTodo, findTodo(ctx, tx))
+	if err != nil {
+		return models.Todo{}, err
+	}
+	now := service.options.now()
+	newTodo = stampCreated(newTodo, now)
+	err = insertTodo(ctx, tx, newTodo)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = recordRevision(ctx, tx, models.ActionCreate, nil, &newTodo, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return newTodo, service.commitTx(ctx, tx)
+}
+
+// DeleteTodo moves a Todo item with an id matching that of the id provided as a parameter to the trash. If no Todo item
+// with a matching id is found then a NotFoundError is returned, if it still has subtasks a HasSubtasksError, and if other
+// Todo items still depend on it a HasDependantsError
+func (service *SqliteTodoService) DeleteTodo(ctx context.Context, id string, expectedVersion *int64) error {
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	existing, err := selectTodo(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	err = checkVersion(existing, expectedVersion)
+	if err != nil {
+		return err
+	}
+	var subtasks int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM todos WHERE parent_id = ?", id).Scan(&subtasks)
+	if err != nil {
+		return err
+	}
+	if subtasks > 0 {
+		return &HasSubtasksError{Id: id, Subtasks: subtasks}
+	}
+	var dependants int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM todos
+		WHERE EXISTS (SELECT 1 FROM json_each(todos.depends_on) WHERE value = ?)`, id).Scan(&dependants)
+	if err != nil {
+		return err
+	}
+	if dependants > 0 {
+		return &HasDependantsError{Id: id, Dependants: dependants}
+	}
+	err = moveToTrash(ctx, tx, existing, service.options.now())
+	if err != nil {
+		return err
+	}
+	return service.commitTx(ctx, tx)
+}
+
+// UpdateTodo updates a Todo item with an id matching that of the Todo item pass as a parameter. If a Todo item with
+// an id matching that of the Todo item passed as a parameter cannot be found then an error will be returned.
+//
+// The Todo item passed as a parameter must include an id
+func (service *SqliteTodoService) UpdateTodo(ctx context.Context, newTodo models.Todo, expectedVersion *int64) (models.Todo, error) {
+	newTodo = normaliseTodo(newTodo)
+	err := validateTodo(newTodo)
+	if err != nil {
+		return models.Todo{}, err
+	}
+
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	defer tx.Rollback()
+
+	existing, err := selectTodo(ctx, tx, newTodo.Id)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkVersion(existing, expectedVersion)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkListExists(ctx, tx, newTodo)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkParent(newTodo, findTodo(ctx, tx))
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkDependencies(newTodo, findTodo(ctx, tx))
+	if err != nil {
+		return models.Todo{}, err
+	}
+	now := service.options.now()
+	err = service.completeSubtasks(ctx, tx, existing, newTodo, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	newTodo = stampRevised(existing, newTodo, now)
+	newTodo, err = service.spawnOccurrence(ctx, tx, existing, newTodo, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = updateTodo(ctx, tx, newTodo)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = recordRevision(ctx, tx, models.ActionUpdate, &existing, &newTodo, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return newTodo, service.commitTx(ctx, tx)
+}
+
+// PatchTodo applies a patch document to the Todo item with an id matching the id passed as a parameter. The Todo item is
+// read, patched and written within a single transaction. If no Todo item with a matching id exists a NotFoundError is
+// returned
+func (service *SqliteTodoService) PatchTodo(ctx context.Context, id string, patch TodoPatch, expectedVersion *int64) (models.Todo, error) {
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	defer tx.Rollback()
+
+	existing, err := selectTodo(ctx, tx, id)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkVersion(existing, expectedVersion)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	now := service.options.now()
+	patched, err := applyPatch(existing, patch, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkListExists(ctx, tx, patched)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkParent(patched, findTodo(ctx, tx))
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = checkDependencies(patched, findTodo(ctx, tx))
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = service.completeSubtasks(ctx, tx, existing, patched, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	patched, err = service.spawnOccurrence(ctx, tx, existing, patched, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = updateTodo(ctx, tx, patched)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = recordRevision(ctx, tx, models.ActionUpdate, &existing, &patched, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return patched, service.commitTx(ctx, tx)
+}
+
+// ListTags returns every tag attached to a Todo item, with the number of Todo items it is attached to, sorted by tag
+func (service *SqliteTodoService) ListTags(ctx context.Context) ([]TagCount, error) {
+	return selectTagCounts(ctx, service.db)
+}
+
+// RenameTag renames tag to name on every Todo item it is attached to. If tag is not attached to any Todo item a
+// NotFoundError is returned, and if name is already in use a ConflictError is returned, MergeTags should be used instead
+func (service *SqliteTodoService) RenameTag(ctx context.Context, tag string, name string) (TagCount, error) {
+	change, err := newTagRename(tag, name)
+	if err != nil {
+		return TagCount{}, err
+	}
+	return service.changeTags(ctx, change)
+}
+
+// MergeTags replaces each of the sources with target on every Todo item they are attached to. If any of the sources is
+// not attached to a Todo item a NotFoundError is returned and no Todo items are changed
+func (service *SqliteTodoService) MergeTags(ctx context.Context, sources []string, target string) (TagCount, error) {
+	change, err := newTagMerge(sources, target)
+	if err != nil {
+		return TagCount{}, err
+	}
+	return service.changeTags(ctx, change)
+}
+
+// changeTags makes change to every Todo item within a single transaction, returning the target tag with the number of
+// Todo items it is now attached to
+func (service *SqliteTodoService) changeTags(ctx context.Context, change tagChange) (TagCount, error) {
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return TagCount{}, err
+	}
+	defer tx.Rollback()
+
+	counts, err := selectTagCounts(ctx, tx)
+	if err != nil {
+		return TagCount{}, err
+	}
+	err = change.check(counts)
+	if err != nil {
+		return TagCount{}, err
+	}
+	// Only the Todo items with one of the sources, or the target, need to be read to make the change and count the
+	// Todo items attached to the target
+	tags := append([]any{change.target}, anySlice(change.sources)...)
+	todos, err := selectTodos(ctx, tx, "SELECT "+todoColumns+` FROM todos
+		WHERE EXISTS (SELECT 1 FROM json_each(todos.tags) WHERE value IN (?`+strings.Repeat(", ?", len(tags)-1)+`))
+		ORDER BY seq`, tags...)
+	if err != nil {
+		return TagCount{}, err
+	}
+
+	now := service.options.now()
+	for _, todo := range todos {
+		revised, changed := change.apply(todo, now)
+		if !changed {
+			continue
+		}
+		err = updateTodo(ctx, tx, revised)
+		if err != nil {
+			return TagCount{}, err
+		}
+		err = recordRevision(ctx, tx, models.ActionUpdate, &todo, &revised, now)
+		if err != nil {
+			return TagCount{}, err
+		}
+	}
+	return TagCount{Name: change.target, Count: len(todos)}, service.commitTx(ctx, tx)
+}
+
+// ReturnChildren returns the Todo items which are direct subtasks of the Todo item with an id matching the id passed as
+// a parameter, in the order they were created. If no such Todo item exists a NotFoundError is returned
+func (service *SqliteTodoService) ReturnChildren(ctx context.Context, id string) ([]models.Todo, error) {
+	tx, err := service.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = selectTodo(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return selectTodos(ctx, tx, "SELECT "+todoColumns+" FROM todos WHERE parent_id = ? ORDER BY seq", id)
+}
+
+// ReturnTodoTree returns the Todo item with an id matching the id passed as a parameter alongside all of its subtasks,
+// at any depth, and the progress made on them. If no such Todo item exists a NotFoundError is returned
+func (service *SqliteTodoService) ReturnTodoTree(ctx context.Context, id string) (TodoTree, error) {
+	tx, err := service.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return TodoTree{}, err
+	}
+	defer tx.Rollback()
+
+	root, err := selectTodo(ctx, tx, id)
+	if err != nil {
+		return TodoTree{}, err
+	}
+	descendants, err := selectDescendants(ctx, tx, id)
+	if err != nil {
+		return TodoTree{}, err
+	}
+	return buildTree(root, descendants), nil
+}
+
+// PlanTodos returns every open Todo item ordered so that each comes after all of its open prerequisites, otherwise
+// keeping the order they were created in
+func (service *SqliteTodoService) PlanTodos(ctx context.Context) ([]models.Todo, error) {
+	todos, err := service.ReturnAllTodos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return planTodos(todos), nil
+}
+
+// PreviewOccurrences returns when up to count further occurrences of the Todo item with an id matching the id passed as
+// a parameter will be due. If no such Todo item exists a NotFoundError is returned
+func (service *SqliteTodoService) PreviewOccurrences(ctx context.Context, id string, count int) ([]time.Time, error) {
+	todo, err := service.ReturnSingleTodo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return previewOccurrences(todo, count)
+}
+
+// ReturnTrash returns every Todo item within the trash, in the order they were deleted
+func (service *SqliteTodoService) ReturnTrash(ctx context.Context) ([]models.Todo, error) {
+	return selectTrash(ctx, service.db, "SELECT todo FROM trash ORDER BY seq")
+}
+
+// RestoreTodo moves the Todo item with an id matching the id passed as a parameter out of the trash, returning it with a
+// new version. Its references to any list or Todo items which no longer exist are dropped. If no such Todo item is within
+// the trash a NotFoundError is returned
+func (service *SqliteTodoService) RestoreTodo(ctx context.Context, id string) (models.Todo, error) {
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	defer tx.Rollback()
+
+	trashed, err := selectTrash(ctx, tx, "SELECT todo FROM trash WHERE id = ?", id)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	if len(trashed) == 0 {
+		return models.Todo{}, &NotFoundError{Resource: "trashed todo", Id: id}
+	}
+	var listExists bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM lists WHERE id = ?)", trashed[0].ListId).Scan(&listExists)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	now := service.options.now()
+	restored, err := restoreTodo(trashed[0], listExists, findTodo(ctx, tx), now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM trash WHERE id = ?", id)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = insertTodo(ctx, tx, restored)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = recordRevision(ctx, tx, models.ActionRestore, &trashed[0], &restored, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return restored, service.commitTx(ctx, tx)
+}
+
+// PurgeTodo permanently removes the Todo item with an id matching the id passed as a parameter from the trash. If no
+// such Todo item is within the trash a NotFoundError is returned
+func (service *SqliteTodoService) PurgeTodo(ctx context.Context, id string) error {
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	trashed, err := selectTrash(ctx, tx, "SELECT todo FROM trash WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if len(trashed) == 0 {
+		return &NotFoundError{Resource: "trashed todo", Id: id}
+	}
+	err = purgeTodo(ctx, tx, trashed[0], service.options.now())
+	if err != nil {
+		return err
+	}
+	return service.commitTx(ctx, tx)
+}
+
+// PurgeTrash permanently removes every Todo item which has been within the trash for at least olderThan, returning the
+// number removed
+func (service *SqliteTodoService) PurgeTrash(ctx context.Context, olderThan time.Duration) (int, error) {
+	tx, err := service.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Timestamps are stored as text which does not sort chronologically, so expired Todo items are found after they
+	// have been read rather than by the query
+	trashed, err := selectTrash(ctx, tx, "SELECT todo FROM trash ORDER BY seq")
+	if err != nil {
+		return 0, err
+	}
+	now := service.options.now()
+	cutoff := now.Add(-olderThan)
+	purged := 0
+	for _, todo := range trashed {
+		if !expired(todo, cutoff) {
+			continue
+		}
+		err = purgeTodo(ctx, tx, todo, now)
+		if err != nil {
+			return 0, err
+		}
+		purged++
+	}
+	return purged, service.commitTx(ctx, tx)
+}
+
+// ReturnHistory returns every Revision of the Todo item with an id matching the id passed as a parameter, in the order
+// they were recorded. If no such Todo item exists, and none ever did, a NotFoundError is returned
+func (service *SqliteTodoService) ReturnHistory(ctx context.Context, id string) ([]models.Revision, error) {
+	tx, err := service.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	revisions, err := selectRevisions(ctx, tx, "SELECT "+revisionColumns+" FROM revisions WHERE todo_id = ? ORDER BY seq", id)
+	if err != nil || len(revisions) > 0 {
+		return revisions, err
+	}
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM todos WHERE id = ?) OR EXISTS(SELECT 1 FROM trash WHERE id = ?)",
+		id, id).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &NotFoundError{Resource: "todo", Id: id}
+	}
+	return revisions, nil
+}
+
+// QueryAudit returns a single page of the revisions of every Todo item which match the filters of the query, in the
+// order they were recorded. If the query is invalid a ValidationError is returned
+func (service *SqliteTodoService) QueryAudit(ctx context.Context, query AuditQuery) (AuditPage, error) {
+	// As with the trash, timestamps do not sort chronologically as text, so revisions are filtered once they have been
+	// read. Only the revisions after a valid cursor are read, an invalid cursor is reported by applyAuditQuery
+	after, _ := strconv.ParseInt(query.Cursor, 10, 64)
+	revisions, err := selectRevisions(ctx, service.db, "SELECT "+revisionColumns+" FROM revisions WHERE seq > ? ORDER BY seq", after)
+	if err != nil {
+		return AuditPage{}, err
+	}
+	return applyAuditQuery(revisions, query)
+}
+
+// RevertTodo returns the Todo item with an id matching the id passed as a parameter to how it was after the revision
+// with an id of revision, saving it as a new version. The revert is itself recorded as an update. If the revision does
+// not exist, or belongs to a different Todo item, a NotFoundError is returned
+func (service *SqliteTodoService) RevertTodo(ctx context.Context, id string, revision int64, expectedVersion *int64) (models.Todo, error) {
+	// Revisions never change once recorded, so reading the revision outside of the transaction made by UpdateTodo is
+	// safe
+	revisions, err := selectRevisions(ctx, service.db, "SELECT "+revisionColumns+" FROM revisions WHERE seq = ?", revision)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	if len(revisions) == 0 {
+		return models.Todo{}, &NotFoundError{Resource: "revision", Id: strconv.FormatInt(revision, 10)}
+	}
+	reverted, err := revertedTodo(revisions[0], id)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return service.UpdateTodo(ctx, reverted, expectedVersion)
+}
+
+// spawnOccurrence creates the Todo item for the next occurrence of todo within tx, if todo, which is replacing existing,
+// has just been completed, returning todo linked to it
+func (service *SqliteTodoService) spawnOccurrence(ctx context.Context, tx *sql.Tx, existing models.Todo, todo models.Todo, now time.Time) (models.Todo, error) {
+	next, ok, err := nextOccurrence(existing, todo, service.options.generateId, now)
+	if err != nil || !ok {
+		return todo, err
+	}
+	err = insertTodo(ctx, tx, next)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	err = recordRevision(ctx, tx, models.ActionCreate, nil, &next, now)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	todo.NextId = next.Id
+	return todo, nil
+}
+
+// completeSubtasks applies the CompletionRule of the service within tx when todo, which is replacing existing, has just
+// been completed, saving any subtasks completed alongside it
+func (service *SqliteTodoService) completeSubtasks(ctx context.Context, tx *sql.Tx, existing models.Todo, todo models.Todo, now time.Time) error {
+	if !completing(existing, todo) || service.options.CompletionRule == CompletionIndependent {
+		return nil
+	}
+	descendants, err := selectDescendants(ctx, tx, todo.Id)
+	if err != nil {
+		return err
+	}
+	changed, err := applyCompletionRule(service.options.CompletionRule, descendants, now)
+	if err != nil {
+		return err
+	}
+	for _, subtask := range changed {
+		err = updateTodo(ctx, tx, subtask)
+		if err != nil {
+			return err
+		}
+		before := descendants[slices.IndexFunc(descendants, func(descendant models.Todo) bool {
+			return descendant.Id == subtask.Id
+		})]
+		err = recordRevision(ctx, tx, models.ActionUpdate, &before, &subtask, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectDescendants reads every Todo item which is a subtask of the Todo item with a matching id within tx, at any
+// depth, in the order they were created
+func selectDescendants(ctx context.Context, tx *sql.Tx, id string) ([]models.Todo, error) {
+	// UNION discards rows which have already been found, so the query ends even if the subtasks contain a cycle
+	return selectTodos(ctx, tx, `WITH RECURSIVE subtasks(id) AS (
+			SELECT id FROM todos WHERE parent_id = ?
+			UNION SELECT todos.id FROM todos JOIN subtasks ON todos.parent_id = subtasks.id
+		)
+		SELECT `+todoColumns+" FROM todos WHERE id IN (SELECT id FROM subtasks) AND id != ? ORDER BY seq", id, id)
+}
+
+// findTodo returns a function which looks up Todo items within tx, as needed by checkParent
+func findTodo(ctx context.Context, tx *sql.Tx) func(id string) (models.Todo, bool, error) {
+	return func(id string) (models.Todo, bool, error) {
+		todo, err := selectTodo(ctx, tx, id)
+		if errors.Is(err, ErrNotFound) {
+			return models.Todo{}, false, nil
+		}
+		return todo, err == nil, err
+	}
+}
+
+// A sqlQueryer is able to run queries returning rows, either a *sql.DB or a *sql.Tx
+type sqlQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// selectTodos reads every Todo item returned by a query selecting the todoColumns
+func selectTodos(ctx context.Context, db sqlQueryer, query string, args ...any) ([]models.Todo, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []models.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
+
+// selectTrash reads every Todo item within the trash returned by a query selecting the todo column of the trash table
+func selectTrash(ctx context.Context, db sqlQueryer, query string, args ...any) ([]models.Todo, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []models.Todo{}
+	for rows.Next() {
+		var document string
+		err = rows.Scan(&document)
+		if err != nil {
+			return nil, err
+		}
+		var todo models.Todo
+		err = json.Unmarshal([]byte(document), &todo)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
+
+// moveToTrash moves existing from the todos table into the trash within tx, marking it as deleted at now. Any Todo item
+// with the same id already within the trash is replaced
+func moveToTrash(ctx context.Context, tx *sql.Tx, existing models.Todo, now time.Time) error {
+	todo := trashTodo(existing, now)
+	document, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT OR REPLACE INTO trash (id, deleted_at, todo) VALUES (?, ?, ?)",
+		todo.Id, formatTime(todo.DeletedAt), string(document))
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM todos WHERE id = ?", todo.Id)
+	if err != nil {
+		return err
+	}
+	return recordRevision(ctx, tx, models.ActionDelete, &existing, &todo, now)
+}
+
+// purgeTodo permanently removes todo from the trash within tx
+func purgeTodo(ctx context.Context, tx *sql.Tx, todo models.Todo, now time.Time) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM trash WHERE id = ?", todo.Id)
+	if err != nil {
+		return err
+	}
+	return recordRevision(ctx, tx, models.ActionPurge, &todo, nil, now)
+}
+
+// recordRevision adds a row for the Revision recording action, which changed before into after, within tx
+func recordRevision(ctx context.Context, tx *sql.Tx, action models.RevisionAction, before *models.Todo, after *models.Todo, now time.Time) error {
+	revision := newRevision(ctx, action, before, after, now)
+	changes, err := json.Marshal(revision.Changes)
+	if err != nil {
+		return err
+	}
+	document, err := json.Marshal(revision.Todo)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO revisions (todo_id, version, action, actor, at, changes, todo) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		revision.TodoId, revision.Version, revision.Action, revision.Actor, formatTime(&revision.At), string(changes),
+		string(document))
+	return err
+}
+
+// selectRevisions reads every Revision returned by a query selecting the revisionColumns
+func selectRevisions(ctx context.Context, db sqlQueryer, query string, args ...any) ([]models.Revision, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.Revision{}
+	for rows.Next() {
+		var revision models.Revision
+		var at, changes, document string
+		err = rows.Scan(&revision.Id, &revision.TodoId, &revision.Version, &revision.Action, &revision.Actor, &at,
+			&changes, &document)
+		if err != nil {
+			return nil, err
+		}
+		revision.At, err = parseTime(at)
+		if err == nil {
+			err = json.Unmarshal([]byte(changes), &revision.Changes)
+		}
+		if err == nil {
+			err = json.Unmarshal([]byte(document), &revision.Todo)
+		}
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// selectTagCounts reads the number of Todo items each tag is attached to, sorted by tag
+func selectTagCounts(ctx context.Context, db sqlQueryer) ([]TagCount, error) {
+	rows, err := db.QueryContext(ctx, `SELECT tag.value, COUNT(*) FROM todos, json_each(todos.tags) AS tag
+		GROUP BY tag.value ORDER BY tag.value`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []TagCount{}
+	for rows.Next() {
+		var count TagCount
+		err = rows.Scan(&count.Name, &count.Count)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// selectTodo reads the Todo item with a matching id within tx, returning a NotFoundError if it does not exist
+func selectTodo(ctx context.Context, tx *sql.Tx, id string) (models.Todo, error) {
+	todo, err := scanTodo(tx.QueryRowContext(ctx, "SELECT "+todoColumns+" FROM todos WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Todo{}, &NotFoundError{Resource: "todo", Id: id}
+	}
+	return todo, err
+}
+
+// insertTodo adds a row for todo within tx
+func insertTodo(ctx context.Context, tx *sql.Tx, todo models.Todo) error {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", strings.Count(todoColumns, ",")+1), ", ")
+	_, err := tx.ExecContext(ctx, "INSERT INTO todos ("+todoColumns+") VALUES ("+placeholders+")",
+		todo.Id, todo.Title, todo.Desc, todo.Completed, todo.Version, formatTime(&todo.CreatedAt),
+		formatTime(&todo.UpdatedAt), formatTime(todo.CompletedAt), formatTime(todo.DueAt), todo.Priority,
+		formatStrings(todo.Tags), todo.ListId, todo.ParentId, formatStrings(todo.DependsOn), todo.Recurrence,
+		todo.Occurrence, todo.NextId)
+	return err
+}
+
+// updateTodo overwrites every column of the existing row for todo within tx
+func updateTodo(ctx context.Context, tx *sql.Tx, todo models.Todo) error {
+	_, err := tx.ExecContext(ctx, `UPDATE todos SET title = ?, description = ?, completed = ?, version = ?, created_at = ?,
+		updated_at = ?, completed_at = ?, due_at = ?, priority = ?, tags = ?, list_id = ?, parent_id = ?, depends_on = ?,
+		recurrence = ?, occurrence = ?, next_id = ? WHERE id = ?`,
+		todo.Title, todo.Desc, todo.Completed, todo.Version, formatTime(&todo.CreatedAt), formatTime(&todo.UpdatedAt),
+		formatTime(todo.CompletedAt), formatTime(todo.DueAt), todo.Priority, formatStrings(todo.Tags), todo.ListId,
+		todo.ParentId, formatStrings(todo.DependsOn), todo.Recurrence, todo.Occurrence, todo.NextId, todo.Id)
+	return err
+}
+
+// scanTodo reads a single row containing the todoColumns into a models.Todo. Any columns selected after them are read
+// into extra
+func scanTodo(row interface{ Scan(dest ...any) error }, extra ...any) (models.Todo, error) {
+	var todo models.Todo
+	var createdAt, updatedAt, tags, dependsOn string
+	var completedAt, dueAt sql.NullString
+	dest := []any{&todo.Id, &todo.Title, &todo.Desc, &todo.Completed, &todo.Version, &createdAt, &updatedAt,
+		&completedAt, &dueAt, &todo.Priority, &tags, &todo.ListId, &todo.ParentId, &dependsOn,
+		&todo.Recurrence, &todo.Occurrence, &todo.NextId}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return models.Todo{}, err
+	}
+	todo.Tags, err = parseStrings(tags)
+	if err == nil {
+		todo.DependsOn, err = parseStrings(dependsOn)
+	}
+	if err == nil {
+		todo.CreatedAt, err = parseTime(createdAt)
+	}
+	if err == nil {
+		todo.UpdatedAt, err = parseTime(updatedAt)
+	}
+	if err == nil {
+		todo.CompletedAt, err = parseOptionalTime(completedAt)
+	}
+	if err == nil {
+		todo.DueAt, err = parseOptionalTime(dueAt)
+	}
+	return todo, err
+}
+
+// formatStrings converts a list of strings held by a Todo item, such as its tags, into the JSON array they are stored as
+func formatStrings(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// parseStrings converts the JSON array a list of strings held by a Todo item is stored as back into the list, returning
+// nil when it is empty
+func parseStrings(value string) ([]string, error) {
+	var values []string
+	err := json.Unmarshal([]byte(value), &values)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	return values, nil
+}
+
+// anySlice converts values into a slice which can be passed as the arguments of a statement
+func anySlice(values []string) []any {
+	result := make([]any, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+	}
+	return result
+}
+
+// formatTime converts a timestamp into the RFC 3339 text it is stored as, keeping its timezone. Nil timestamps are
+// stored as NULL
+func formatTime(timestamp *time.Time) any {
+	if timestamp == nil {
+		return nil
+	}
+	return timestamp.Format(time.RFC3339Nano)
+}
+
+// parseTime converts the RFC 3339 text a timestamp is stored as back into a timestamp. Rows created before timestamps
+// were recorded store an empty string, which is returned as the zero time
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// parseOptionalTime converts the RFC 3339 text an optional timestamp is stored as back into a timestamp, returning nil
+// for NULL
+func parseOptionalTime(value sql.NullString) (*time.Time, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	timestamp, err := parseTime(value.String)
+	if err != nil {
+		return nil, err
+	}
+	return &timestamp, nil
+}
+
+// Functions registered with the SQLite driver so that queries can compute the same sort keys as sortKeys, allowing the
+// position recorded within a cursor to be compared against rows by the database
+const (
+	// sqliteLower converts text to lower case as strings.ToLower does, unlike the built-in lower function which only
+	// handles ASCII
+	sqliteLower = "unicode_lower"
+	// sqliteSortableTime converts the RFC 3339 text a timestamp is stored as into the key given to it by sortKeys
+	sqliteSortableTime = "sortable_time"
+)
+
+func init() {
+	err := sqlite.RegisterDeterministicScalarFunction(sqliteLower, 1,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			value, ok := args[0].(string)
+			if !ok {
+				return args[0], nil
+			}
+			return strings.ToLower(value), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+	err = sqlite.RegisterDeterministicScalarFunction(sqliteSortableTime, 1,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			value, ok := args[0].(string)
+			if !ok {
+				return nil, nil
+			}
+			timestamp, err := parseTime(value)
+			if err != nil {
+				return nil, err
+			}
+			return timestamp.UTC().Format(sortableTime), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// sqliteSortKeys contains, for each field Todo items can be sorted by, an expression producing the same key for a row
+// of the todos table as sortKeys produces for the Todo item it holds
+var sqliteSortKeys = map[string]string{
+	SortById:        "''",
+	SortByTitle:     sqliteLower + "(title)",
+	SortByCompleted: "CASE WHEN completed THEN '1' ELSE '0' END",
+	SortByCreated:   sqliteSortableTime + "(created_at)",
+	SortByUpdated:   sqliteSortableTime + "(updated_at)",
+	SortByDue:       "COALESCE(" + sqliteSortableTime + "(due_at), '" + noDueDate + "')",
+	SortByPriority:  sqlitePriorityKey(),
+}
+
+// sqliteBlocked is an expression which is true for rows of the todos table holding a Todo item which depends on an open
+// Todo item, matching blocked
+const sqliteBlocked = `EXISTS(SELECT 1 FROM json_each(todos.depends_on) AS prerequisite
+	JOIN todos AS prerequisites ON prerequisites.id = prerequisite.value WHERE NOT prerequisites.completed)`
+
+// sqlitePriorityKey returns an expression producing the position of the priority of a row within models.Priorities,
+// which is the key sortKeys gives it
+func sqlitePriorityKey() string {
+	var key strings.Builder
+	key.WriteString("CASE priority")
+	for i, priority := range models.Priorities {
+		fmt.Fprintf(&key, " WHEN '%s' THEN '%d'", strings.ReplaceAll(string(priority), "'", "''"), i)
+	}
+	key.WriteString(" ELSE '-1' END")
+	return key.String()
+}
+
+// todoQueryStatement builds a statement selecting the todoColumns, followed by whether each Todo item is Blocked, of the
+// rows matching the normalised query at the time now. Only the rows following after are selected, sorted as query
+// requests, and one more row than the limit of query is selected so that it is known whether a further page follows
+func todoQueryStatement(query TodoQuery, after *pageCursor, now time.Time) (string, []any) {
+	var conditions []string
+	var args []any
+	where := func(condition string, values ...any) {
+		conditions = append(conditions, condition)
+		args = append(args, values...)
+	}
+
+	if query.Completed != nil {
+		where("completed = ?", *query.Completed)
+	}
+	if query.ListId != nil {
+		where("list_id = ?", *query.ListId)
+	}
+	if query.Priority != nil {
+		where("priority = ?", *query.Priority)
+	}
+	if len(query.Tags) > 0 && query.AnyTag {
+		where("EXISTS(SELECT 1 FROM json_each(todos.tags) WHERE value IN (?"+strings.Repeat(", ?", len(query.Tags)-1)+"))",
+			anySlice(query.Tags)...)
+	}
+	if len(query.Tags) > 0 && !query.AnyTag {
+		for _, tag := range query.Tags {
+			where("EXISTS(SELECT 1 FROM json_each(todos.tags) WHERE value = ?)", tag)
+		}
+	}
+	if query.DueBefore != nil {
+		where(sqliteSortableTime+"(due_at) < ?", query.DueBefore.UTC().Format(sortableTime))
+	}
+	if query.DueAfter != nil {
+		where(sqliteSortableTime+"(due_at) > ?", query.DueAfter.UTC().Format(sortableTime))
+	}
+	if query.Overdue != nil {
+		overdue := "(NOT completed AND " + sqliteSortableTime + "(due_at) IS NOT NULL AND " + sqliteSortableTime + "(due_at) < ?)"
+		if !*query.Overdue {
+			overdue = "NOT " + overdue
+		}
+		where(overdue, now.UTC().Format(sortableTime))
+	}
+	if query.Ready != nil {
+		ready := "(NOT completed AND NOT " + sqliteBlocked + ")"
+		if !*query.Ready {
+			ready = "NOT " + ready
+		}
+		where(ready)
+	}
+	if query.Search != "" {
+		search := strings.ToLower(query.Search)
+		where("(instr("+sqliteLower+"(title), ?) > 0 OR instr("+sqliteLower+"(description), ?) > 0)", search, search)
+	}
+
+	sortKey := sqliteSortKeys[query.SortBy]
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if after != nil {
+		where(fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", sortKey, comparison), after.Key, after.Key, after.Id)
+	}
+
+	statement := "SELECT " + todoColumns + ", " + sqliteBlocked + " FROM todos"
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s LIMIT ?", sortKey, direction)
+	return statement, append(args, query.Limit+1)
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"path/filepath"
+	"testing"
+)
+
+func setupSqliteTest(t *testing.T, prerequisite []models.Todo) *SqliteTodoService {
+	db, err := OpenSqliteDB(filepath.Join(t.TempDir(), "todos.db"))
+	if err != nil {
+		t.Fatalf("Failed to open sqlite database: [%v]", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	service, err := NewSqliteTodoService(db, Options{AllowClientIds: true, Clock: testClock})
+	if err != nil {
+		t.Fatalf("Failed to create sqlite todo service: [%v]", err)
+	}
+	for _, todo := range prerequisite {
+		_, err = service.CreateNewTodo(context.Background(), todo)
+		if err != nil {
+			t.Fatalf("Failed to persist prerequisite todo: [%v]", err)
+		}
+	}
+	return service
+}
+
+func TestSqliteReturnAllTodos(t *testing.T) {
+	tests := map[string]struct {
+		prerequisite []models.Todo
+		expected     []models.Todo
+	}{
+		"Return No Todos": {
+			prerequisite: []models.Todo{},
+			expected:     []models.Todo{},
+		},
+		"Return Multiple Todos In Creation Order": {
+			prerequisite: []models.Todo{
+				{Id: "2", Title: "Example Title 2", Desc: "Example Description", Completed: true},
+				{Id: "1", Title: "Example Title", Desc: "Example Description", Completed: false},
+			},
+			expected: []models.Todo{
+				stamped(models.Todo{Id: "2", Title: "Example Title 2", Desc: "Example Description", Completed: true, Version: 1}),
+				stamped(models.Todo{Id: "1", Title: "Example Title", Desc: "Example Description", Completed: false, Version: 1}),
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			service := setupSqliteTest(t, tt.prerequisite)
+			actual, err := service.ReturnAllTodos(context.Background())
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff(tt.expected, actual)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestSqliteReturnSingleTodo(t *testing.T) {
+	tests := map[string]struct {
+		prerequisite         []models.Todo
+		input                string
+		expected             models.Todo
+		errorExpected        bool
+		expectedErrorMessage string
+	}{
+		"No Todo Found Wrong Id": {
+			prerequisite:         []models.Todo{{Id: "1", Title: "Example Title", Desc: "Example Description"}},
+			input:                "2",
+			expected:             models.Todo{},
+			errorExpected:        true,
+			expectedErrorMessage: "could not find todo with id [2]",
+		},
+		"Todo With Matching Id Found": {
+			prerequisite:  []models.Todo{{Id: "1", Title: "Example Title", Desc: "Example Description"}},
+			input:         "1",
+			expected:      stamped(models.Todo{Id: "1", Title: "Example Title", Desc: "Example Description", Version: 1}),
+			errorExpected: false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			service := setupSqliteTest(t, tt.prerequisite)
+			actual, err := service.ReturnSingleTodo(context.Background(), tt.input)
+			diff := cmp.Diff(tt.expected, actual)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			if tt.errorExpected {
+				if err == nil {
+					t.Fatalf("Error expected but none occured")
+				} else if err.Error() != tt.expectedErrorMessage {
+					t.Fatalf("Error message not as expected, expected [%v] but was [%v]", tt.expectedErrorMessage, err.Error())
+				}
+			} else if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+		})
+	}
+}
+
+func TestSqliteCreateNewTodo(t *testing.T) {
+	tests := map[string]struct {
+		prerequisite         []models.Todo
+		input                models.Todo
+		expected             models.Todo
+		errorExpected        bool
+		expectedErrorMessage string
+	}{
+		"Duplicate Id Error": {
+			prerequisite:         []models.Todo{{Id: "1", Title: "Example Title", Desc: "Example Description"}},
+			input:                models.Todo{Id: "1", Title: "Example Title", Desc: "Example Description"},
+			expected:             models.Todo{},
+			errorExpected:        true,
+			expectedErrorMessage: "todo with id [1] already exists",
+		},
+		"Create Todo Successfully": {
+			prerequisite:  []models.Todo{},
+			input:         models.Todo{Id: "1", Title: "Example Title", Desc: "Example Description", Completed: true},
+			expected:      stamped(models.Todo{Id: "1", Title: "Example Title", Desc: "Example Description", Completed: true, Version: 1}),
+			errorExpected: false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			service := setupSqliteTest(t, tt.prerequisite)
+			actual, err := service.CreateNewTodo(context.Background(), tt.input)
+			diff := cmp.Diff(tt.expected, actual)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			if tt.errorExpected {
+				if err == nil {
+					t.Fatalf("Error expected but none occured")
+				} else if err.Error() != tt.expectedErrorMessage {
+					t.Fatalf("Error message not as expected, expected [%v] but was [%v]", tt.expectedErrorMessage, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			persisted, err := service.ReturnSingleTodo(context.Background(), tt.input.Id)
+			if err != nil {
+				t.Fatalf("Created todo was not persisted: [%v]", err)
+			}
+			diff = cmp.Diff(tt.expected, persisted)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestSqliteDeleteTodo(t *testing.T) {
+	tests := map[string]struct {
+		prerequisite  []models.Todo
+		input         string
+		expected      []models.Todo
+		errorExpected bool
+	}{
+		"Successful deletion": {
+			prerequisite: []models.Todo{{Id: "1", Title: "Example Title"}, {Id: "2", Title: "Example Title"}},
+			input:        "1",
+			expected:     []models.Todo{stamped(models.Todo{Id: "2", Title: "Example Title", Version: 1})},
+		},
+		"Non-Matching Id Does Not Delete Anything": {
+			prerequisite: []models.Todo{{Id: "1", Title: "Example Title"}, {Id: "2", Title: "Example Title"}},
+			input:        "3",
+			expected: []models.Todo{
+				stamped(models.Todo{Id: "1", Title: "Example Title", Version: 1}),
+				stamped(models.Todo{Id: "2", Title: "Example Title", Version: 1}),
+			},
+			errorExpected: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			service := setupSqliteTest(t, tt.prerequisite)
+			err := service.DeleteTodo(context.Background(), tt.input, nil)
+			if tt.errorExpected && !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			} else if !tt.errorExpected && err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			actual, err := service.ReturnAllTodos(context.Background())
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff(tt.expected, actual)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestSqliteUpdateTodo(t *testing.T) {
+	tests := map[string]struct {
+		prerequisite         []models.Todo
+		input                models.Todo
+		expected             models.Todo
+		errorExpected        bool
+		expectedErrorMessage string
+	}{
+		"Validation Error": {
+			prerequisite:         []models.Todo{{Id: "1", Title: "Example Title"}},
+			input:                models.Todo{Title: "Updated Example Title", Completed: true},
+			expected:             models.Todo{},
+			errorExpected:        true,
+			expectedErrorMessage: "todo Id cannot be null",
+		},
+		"No Todo With Id Found": {
+			prerequisite:         []models.Todo{},
+			input:                models.Todo{Id: "1", Title: "Updated Example Title", Completed: true},
+			expected:             models.Todo{},
+			errorExpected:        true,
+			expectedErrorMessage: "could not find todo with id [1]",
+		},
+		"Update Todo Successfully": {
+			prerequisite:  []models.Todo{{Id: "1", Title: "Example Title"}},
+			input:         models.Todo{Id: "1", Title: "Updated Example Title", Desc: "Updated", Completed: true},
+			expected:      stamped(models.Todo{Id: "1", Title: "Updated Example Title", Desc: "Updated", Completed: true, Version: 2}),
+			errorExpected: false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			service := setupSqliteTest(t, tt.prerequisite)
+			actual, err := service.UpdateTodo(context.Background(), tt.input, nil)
+			diff := cmp.Diff(tt.expected, actual)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			if tt.errorExpected {
+				if err == nil {
+					t.Fatalf("Error expected but none occured")
+				} else if err.Error() != tt.expectedErrorMessage {
+					t.Fatalf("Error message not as expected, expected [%v] but was [%v]", tt.expectedErrorMessage, err.Error())
+				}
+			} else if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+		})
+	}
+}
+
+func TestSqliteTodosSurviveReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.db")
+	db, err := OpenSqliteDB(path)
+	if err != nil {
+		t.Fatalf("Failed to open sqlite database: [%v]", err)
+	}
+	service, err := NewSqliteTodoService(db, Options{AllowClientIds: true, Clock: testClock})
+	if err != nil {
+		t.Fatalf("Failed to create sqlite todo service: [%v]", err)
+	}
+	_, err = service.CreateNewTodo(context.Background(), models.Todo{Id: "1", Title: "Example Title"})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	db.Close()
+
+	db, err = OpenSqliteDB(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen sqlite database: [%v]", err)
+	}
+	defer db.Close()
+	service, err = NewSqliteTodoService(db, Options{AllowClientIds: true, Clock: testClock})
+	if err != nil {
+		t.Fatalf("Failed to recreate sqlite todo service: [%v]", err)
+	}
+	actual, err := service.ReturnAllTodos(context.Background())
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	diff := cmp.Diff([]models.Todo{stamped(models.Todo{Id: "1", Title: "Example Title", Version: 1})}, actual)
+	if diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestSqliteCreateNewTodoGeneratesId(t *testing.T) {
+	service := setupSqliteTest(t, []models.Todo{})
+	created, err := service.CreateNewTodo(context.Background(), models.Todo{Title: "Example Title"})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if created.Id == "" {
+		t.Fatalf("Expected an id to be generated")
+	}
+	persisted, err := service.ReturnSingleTodo(context.Background(), created.Id)
+	if err != nil {
+		t.Fatalf("Todo with generated id was not persisted: [%v]", err)
+	}
+	diff := cmp.Diff(created, persisted)
+	if diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestSqliteCheckHealth(t *testing.T) {
+	healthy := []ComponentHealth{
+		{Name: "sqlite.connection", Status: HealthUp},
+		{Name: "sqlite.migrations", Status: HealthUp},
+		{Name: "sqlite.writable", Status: HealthUp},
+	}
+	tests := map[string]struct {
+		setup    func(t *testing.T, path string) *SqliteTodoService
+		expected []ComponentHealth
+	}{
+		"Healthy": {
+			setup: func(t *testing.T, path string) *SqliteTodoService {
+				return openSqliteService(t, path)
+			},
+			expected: healthy,
+		},
+		"Migrations Missing": {
+			setup: func(t *testing.T, path string) *SqliteTodoService {
+				service := openSqliteService(t, path)
+				_, err := service.db.Exec("PRAGMA user_version = 1")
+				if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+				return service
+			},
+			expected: []ComponentHealth{
+				{Name: "sqlite.connection", Status: HealthUp},
+				{Name: "sqlite.migrations", Status: HealthDown, Detail: fmt.Sprintf("schema is at version [1] not the expected version [%d]", len(sqliteMigrations))},
+				{Name: "sqlite.writable", Status: HealthUp},
+			},
+		},
+		"Read Only": {
+			setup: func(t *testing.T, path string) *SqliteTodoService {
+				openSqliteService(t, path).db.Close()
+				return openSqliteService(t, "file:"+path+"?mode=ro")
+			},
+			expected: []ComponentHealth{
+				{Name: "sqlite.connection", Status: HealthUp},
+				{Name: "sqlite.migrations", Status: HealthUp},
+				{Name: "sqlite.writable", Status: HealthDown},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			service := tt.setup(t, filepath.Join(t.TempDir(), "todos.db"))
+			actual := service.CheckHealth(context.Background())
+			// The detail of a failed write depends on the SQLite driver, so only its presence is checked
+			for i := range actual {
+				if actual[i].Status == HealthDown && actual[i].Detail == "" {
+					t.Errorf("Detail missing from unhealthy component [%v]", actual[i].Name)
+				}
+				if actual[i].Name == "sqlite.writable" {
+					actual[i].Detail = ""
+				}
+			}
+			diff := cmp.Diff(tt.expected, actual)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func openSqliteService(t *testing.T, path string) *SqliteTodoService {
+	db, err := OpenSqliteDB(path)
+	if err != nil {
+		t.Fatalf("Failed to open sqlite database: [%v]", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	service, err := NewSqliteTodoService(db, Options{})
+	if err != nil {
+		t.Fatalf("Failed to create sqlite todo service: [%v]", err)
+	}
+	return service
+}
@@ -2,14 +2,64 @@ package services
 
 import (
 	"TodoApp/src/main/models"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/google/go-cmp/cmp"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 )
 
 var todoService *TodoServiceImpl
 
-func setupTest() {
-	todoService = NewTodoServiceImpl([]models.Todo{})
+// testTime is the current time according to the clock of the services created by the tests
+var testTime = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func testClock() time.Time {
+	return testTime
+}
+
+// stamped returns todo with the timestamps the services set when saving it at testTime
+func stamped(todo models.Todo) models.Todo {
+	todo.CreatedAt = testTime
+	todo.UpdatedAt = testTime
+	if todo.Completed {
+		completedAt := testTime
+		todo.CompletedAt = &completedAt
+	}
+	return todo
+}
+
+func setupTest(prerequisite []models.Todo) {
+	todoService = NewTodoServiceImpl(prerequisite, Options{AllowClientIds: true, Clock: testClock})
+}
+
+// setupBackends creates an instance of every TodoService backend, each containing the prerequisite Todo items, for tests
+// which confirm the backends behave identically
+func setupBackends(t *testing.T, prerequisite []models.Todo) map[string]Store {
+	memory := NewTodoServiceImpl([]models.Todo{}, Options{AllowClientIds: true, Clock: testClock})
+	for _, todo := range prerequisite {
+		_, err := memory.CreateNewTodo(context.Background(), todo)
+		if err != nil {
+			t.Fatalf("Failed to persist prerequisite todo: [%v]", err)
+		}
+	}
+	return map[string]Store{
+		"Memory": memory,
+		"Sqlite": setupSqliteTest(t, prerequisite),
+		"File":   setupFileTest(t, filepath.Join(t.TempDir(), "todos.jsonl"), 3, prerequisite),
+		"Events": setupEventTest(t, prerequisite),
+	}
+}
+
+func countTodos(t *testing.T) int {
+	todos, err := todoService.ReturnAllTodos(context.Background())
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	return len(todos)
 }
 
 func TestReturnAllTodos(t *testing.T) {
@@ -47,12 +97,14 @@ func TestReturnAllTodos(t *testing.T) {
 
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			setupTest()
-			todoService.Todos = append(todoService.Todos, tt.prerequisite...)
-			actual := todoService.ReturnAllTodos()
+			setupTest(tt.prerequisite)
+			actual, err := todoService.ReturnAllTodos(context.Background())
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
 			diff := cmp.Diff(tt.expected, actual)
 			if diff != "" {
-				t.Fatalf(diff)
+				t.Fatal(diff)
 			}
 		})
 	}
@@ -108,12 +160,11 @@ func TestReturnSingleTodo(t *testing.T) {
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			setupTest()
-			todoService.Todos = append(todoService.Todos, tt.prerequisite...)
-			actual, err := todoService.ReturnSingleTodo(tt.input)
+			setupTest(tt.prerequisite)
+			actual, err := todoService.ReturnSingleTodo(context.Background(), tt.input)
 			diff := cmp.Diff(tt.expected, actual)
 			if diff != "" {
-				t.Fatalf(diff)
+				t.Fatal(diff)
 			}
 			if tt.errorExpected {
 				if err == nil {
@@ -137,17 +188,6 @@ func TestCreateNewTodo(t *testing.T) {
 		errorExpected        bool
 		expectedErrorMessage string
 	}{
-		"Validation Error": {
-			prerequisite: []models.Todo{},
-			input: models.Todo{
-				Title:     "Example Title",
-				Desc:      "Example Description",
-				Completed: false,
-			},
-			expected:             models.Todo{},
-			errorExpected:        true,
-			expectedErrorMessage: "todo Id cannot be null",
-		},
 		"Duplicate Id Error": {
 			prerequisite: []models.Todo{
 				{
@@ -175,28 +215,28 @@ func TestCreateNewTodo(t *testing.T) {
 				Desc:      "Example Description",
 				Completed: false,
 			},
-			expected: models.Todo{
+			expected: stamped(models.Todo{
 				Id:        "1",
 				Title:     "Example Title",
 				Desc:      "Example Description",
 				Completed: false,
-			},
+				Version:   1,
+			}),
 			errorExpected: false,
 		},
 	}
 
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			setupTest()
-			todoService.Todos = append(todoService.Todos, tt.prerequisite...)
-			var numOfTodosAfterPreReq = len(todoService.Todos)
-			actual, err := todoService.CreateNewTodo(tt.input)
+			setupTest(tt.prerequisite)
+			var numOfTodosAfterPreReq = len(tt.prerequisite)
+			actual, err := todoService.CreateNewTodo(context.Background(), tt.input)
 			diff := cmp.Diff(tt.expected, actual)
 			if diff != "" {
-				t.Fatalf(diff)
+				t.Fatal(diff)
 			}
 			if tt.errorExpected {
-				if len(todoService.Todos) != numOfTodosAfterPreReq {
+				if countTodos(t) != numOfTodosAfterPreReq {
 					t.Fatalf("Number of persisted Todos has changed unexpectedly")
 				}
 				if err == nil {
@@ -208,18 +248,72 @@ func TestCreateNewTodo(t *testing.T) {
 			} else if !tt.errorExpected && err != nil {
 				t.Fatalf("Error occured when none expected: [%v]", err)
 			}
-			if !tt.errorExpected && len(todoService.Todos) <= numOfTodosAfterPreReq {
+			if !tt.errorExpected && countTodos(t) <= numOfTodosAfterPreReq {
 				t.Fatalf("Number of has not increased as expected")
 			}
 		})
 	}
 }
 
+func TestCreateNewTodoIdGeneration(t *testing.T) {
+	tests := map[string]struct {
+		options              Options
+		input                models.Todo
+		errorExpected        bool
+		expectedErrorMessage string
+	}{
+		"Id Generated When None Supplied": {
+			options: Options{AllowClientIds: false},
+			input:   models.Todo{Title: "Example Title"},
+		},
+		"Id Generated When None Supplied And Client Ids Allowed": {
+			options: Options{AllowClientIds: true},
+			input:   models.Todo{Title: "Example Title"},
+		},
+		"Client Id Rejected When Not Allowed": {
+			options:              Options{AllowClientIds: false},
+			input:                models.Todo{Id: "1", Title: "Example Title"},
+			errorExpected:        true,
+			expectedErrorMessage: "todo Id cannot be set by the client",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			todoService = NewTodoServiceImpl([]models.Todo{}, tt.options)
+			first, err := todoService.CreateNewTodo(context.Background(), tt.input)
+			if tt.errorExpected {
+				if !errors.Is(err, ErrValidation) || err.Error() != tt.expectedErrorMessage {
+					t.Fatalf("Error message not as expected, expected [%v] but was [%v]", tt.expectedErrorMessage, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			second, err := todoService.CreateNewTodo(context.Background(), tt.input)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if first.Id == "" || second.Id == "" || first.Id == second.Id {
+				t.Fatalf("Expected unique ids to be generated but were [%v] and [%v]", first.Id, second.Id)
+			}
+			if first.Id > second.Id {
+				t.Fatalf("Expected generated ids to sort in creation order but [%v] sorts after [%v]", first.Id, second.Id)
+			}
+			if _, err = todoService.ReturnSingleTodo(context.Background(), first.Id); err != nil {
+				t.Fatalf("Todo with generated id was not persisted: [%v]", err)
+			}
+		})
+	}
+}
+
 func TestDeleteTodo(t *testing.T) {
 	tests := map[string]struct {
-		prerequisite []models.Todo
-		input        string
-		expected     []models.Todo
+		prerequisite  []models.Todo
+		input         string
+		expected      []models.Todo
+		errorExpected bool
 	}{
 
 		"Successful deletion": {
@@ -263,7 +357,8 @@ func TestDeleteTodo(t *testing.T) {
 					Completed: false,
 				},
 			},
-			input: "3",
+			input:         "3",
+			errorExpected: true,
 			expected: []models.Todo{
 				{
 					Id:        "1",
@@ -282,12 +377,20 @@ func TestDeleteTodo(t *testing.T) {
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			setupTest()
-			todoService.Todos = append(todoService.Todos, tt.prerequisite...)
-			todoService.DeleteTodo(tt.input)
-			diff := cmp.Diff(tt.expected, todoService.Todos)
+			setupTest(tt.prerequisite)
+			err := todoService.DeleteTodo(context.Background(), tt.input, nil)
+			if tt.errorExpected && !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			} else if !tt.errorExpected && err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			actual, err := todoService.ReturnAllTodos(context.Background())
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff(tt.expected, actual)
 			if diff != "" {
-				t.Fatalf(diff)
+				t.Fatal(diff)
 			}
 		})
 	}
@@ -342,10 +445,13 @@ func TestUpdateTodo(t *testing.T) {
 				},
 			},
 			expected: models.Todo{
-				Id:        "1",
-				Title:     "Updated Example Title",
-				Desc:      "Updated Example Description",
-				Completed: true,
+				Id:          "1",
+				Title:       "Updated Example Title",
+				Desc:        "Updated Example Description",
+				Completed:   true,
+				Version:     1,
+				UpdatedAt:   testTime,
+				CompletedAt: &testTime,
 			},
 			input: models.Todo{
 				Id:        "1",
@@ -358,12 +464,11 @@ func TestUpdateTodo(t *testing.T) {
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			setupTest()
-			todoService.Todos = append(todoService.Todos, tt.prerequisite...)
-			actual, err := todoService.UpdateTodo(tt.input)
+			setupTest(tt.prerequisite)
+			actual, err := todoService.UpdateTodo(context.Background(), tt.input, nil)
 			diff := cmp.Diff(tt.expected, actual)
 			if diff != "" {
-				t.Fatalf(diff)
+				t.Fatal(diff)
 			}
 			if tt.errorExpected {
 				if err == nil {
@@ -378,3 +483,192 @@ func TestUpdateTodo(t *testing.T) {
 		})
 	}
 }
+
+func TestReturnAllTodosPreservesInsertionOrder(t *testing.T) {
+	setupTest([]models.Todo{{Id: "3"}, {Id: "1"}, {Id: "2"}})
+	ctx := context.Background()
+	if err := todoService.DeleteTodo(ctx, "1", nil); err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if err := todoService.PurgeTodo(ctx, "1"); err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if _, err := todoService.UpdateTodo(ctx, models.Todo{Id: "3", Title: "Updated"}, nil); err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if _, err := todoService.CreateNewTodo(ctx, models.Todo{Id: "1"}); err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	actual, err := todoService.ReturnAllTodos(ctx)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	diff := cmp.Diff([]models.Todo{{Id: "3", Title: "Updated", Version: 1, UpdatedAt: testTime}, {Id: "2"}, stamped(models.Todo{Id: "1", Version: 1})}, actual)
+	if diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+// TestConcurrentAccess hammers every operation from many goroutines at once, it is intended to be run with the race
+// detector enabled (go test -race)
+func TestConcurrentAccess(t *testing.T) {
+	setupTest([]models.Todo{})
+	ctx := context.Background()
+	const workers = 16
+	const iterations = 200
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				id := fmt.Sprintf("%d-%d", worker, i%10)
+				_, _ = todoService.CreateNewTodo(ctx, models.Todo{Id: id, Title: "Example Title"})
+				_, _ = todoService.ReturnSingleTodo(ctx, id)
+				_, _ = todoService.UpdateTodo(ctx, models.Todo{Id: id, Title: "Updated Example Title", Completed: true}, nil)
+				_, _ = todoService.ReturnAllTodos(ctx)
+				if i%3 == 0 {
+					_ = todoService.DeleteTodo(ctx, id, nil)
+					_, _ = todoService.ReturnTrash(ctx)
+					if i%2 == 0 {
+						_, _ = todoService.RestoreTodo(ctx, id)
+					} else {
+						_ = todoService.PurgeTodo(ctx, id)
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	todos, err := todoService.ReturnAllTodos(ctx)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	seen := make(map[string]bool, len(todos))
+	for _, todo := range todos {
+		if seen[todo.Id] {
+			t.Fatalf("Todo with id [%s] persisted more than once", todo.Id)
+		}
+		seen[todo.Id] = true
+		if _, err = todoService.ReturnSingleTodo(ctx, todo.Id); err != nil {
+			t.Fatalf("Todo with id [%s] returned by ReturnAllTodos but not by ReturnSingleTodo", todo.Id)
+		}
+	}
+}
+
+func TestVersionPreconditionsAcrossBackends(t *testing.T) {
+	for name, service := range setupBackends(t, []models.Todo{{Id: "1", Title: "Example Title"}}) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			stale := int64(0)
+			current := int64(1)
+
+			_, err := service.UpdateTodo(ctx, models.Todo{Id: "1", Title: "Updated"}, &stale)
+			if !errors.Is(err, ErrPreconditionFailed) {
+				t.Fatalf("Precondition failed error expected but was [%v]", err)
+			}
+			updated, err := service.UpdateTodo(ctx, models.Todo{Id: "1", Title: "Updated", Version: 99}, &current)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if updated.Version != 2 {
+				t.Fatalf("Expected version to be incremented to 2 but was [%v]", updated.Version)
+			}
+
+			_, err = service.PatchTodo(ctx, "1", TodoPatch{Type: MergePatchType, Document: []byte(`{"Completed": true}`)}, &current)
+			if !errors.Is(err, ErrPreconditionFailed) {
+				t.Fatalf("Precondition failed error expected but was [%v]", err)
+			}
+			current = 2
+			patched, err := service.PatchTodo(ctx, "1", TodoPatch{Type: MergePatchType, Document: []byte(`{"Version": 50}`)}, &current)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if patched.Version != 3 {
+				t.Fatalf("Expected version to be incremented to 3 but was [%v]", patched.Version)
+			}
+
+			err = service.DeleteTodo(ctx, "1", &current)
+			if !errors.Is(err, ErrPreconditionFailed) {
+				t.Fatalf("Precondition failed error expected but was [%v]", err)
+			}
+			current = 3
+			err = service.DeleteTodo(ctx, "1", &current)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+		})
+	}
+}
+
+func TestCountTodosAcrossBackends(t *testing.T) {
+	tests := map[string]struct {
+		prerequisite []models.Todo
+		expected     TodoCounts
+	}{
+		"No Todos": {
+			prerequisite: []models.Todo{},
+			expected:     TodoCounts{},
+		},
+		"Completed And Open Todos": {
+			prerequisite: []models.Todo{
+				{Id: "1", Title: "Example Title", Completed: true},
+				{Id: "2", Title: "Example Title 2"},
+				{Id: "3", Title: "Example Title 3"},
+			},
+			expected: TodoCounts{Total: 3, Completed: 1},
+		},
+	}
+
+	for name, tt := range tests {
+		for backend, service := range setupBackends(t, tt.prerequisite) {
+			t.Run(name+" "+backend, func(t *testing.T) {
+				actual, err := service.CountTodos(context.Background())
+				if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+				diff := cmp.Diff(tt.expected, actual)
+				if diff != "" {
+					t.Fatal(diff)
+				}
+			})
+		}
+	}
+}
+
+func TestTimestampsAcrossBackends(t *testing.T) {
+	dueAt := time.Date(2024, time.March, 2, 9, 30, 0, 0, time.FixedZone("IST", 5*60*60+30*60))
+	for name, service := range setupBackends(t, []models.Todo{{Id: "1", Title: "Example Title", DueAt: &dueAt, Priority: models.PriorityHigh}}) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			created, err := service.ReturnSingleTodo(ctx, "1")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if !created.DueAt.Equal(dueAt) || created.DueAt.Format(time.RFC3339) != dueAt.Format(time.RFC3339) {
+				t.Fatalf("Expected due date [%v] to keep its timezone but was [%v]", dueAt, created.DueAt)
+			}
+			if created.Priority != models.PriorityHigh || created.CompletedAt != nil {
+				t.Fatalf("Unexpected todo after creation [%+v]", created)
+			}
+
+			completed, err := service.PatchTodo(ctx, "1", TodoPatch{Type: MergePatchType, Document: []byte(`{"Completed": true, "CreatedAt": "2000-01-01T00:00:00Z"}`)}, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if completed.CompletedAt == nil || !completed.CompletedAt.Equal(testTime) || !completed.CreatedAt.Equal(testTime) {
+				t.Fatalf("Unexpected timestamps after completion [%+v]", completed)
+			}
+
+			reopened, err := service.UpdateTodo(ctx, models.Todo{Id: "1", Title: "Example Title"}, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if reopened.CompletedAt != nil || reopened.DueAt != nil || reopened.Priority != models.PriorityNone {
+				t.Fatalf("Unexpected todo after reopening [%+v]", reopened)
+			}
+		})
+	}
+}
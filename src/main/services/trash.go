@@ -0,0 +1,101 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// trashTodo returns todo as it is kept within the trash, marked as deleted at now
+func trashTodo(todo models.Todo, now time.Time) models.Todo {
+	todo.Blocked = false
+	todo.DeletedAt = &now
+	return todo
+}
+
+// restoreTodo returns todo, which is being restored from the trash, with a new version. References to a list or Todo
+// items which no longer exist are dropped, as they may have been deleted while todo was in the trash. listExists reports
+// whether the list todo belonged to still exists, and find looks up a Todo item by id, returning false if it does not
+// exist
+func restoreTodo(todo models.Todo, listExists bool, find func(id string) (models.Todo, bool, error), now time.Time) (models.Todo, error) {
+	restored := todo
+	if !listExists {
+		restored.ListId = ""
+	}
+	if todo.ParentId != "" {
+		_, ok, err := find(todo.ParentId)
+		if err != nil {
+			return models.Todo{}, err
+		}
+		if !ok {
+			restored.ParentId = ""
+		}
+	}
+	restored.DependsOn = nil
+	for _, id := range todo.DependsOn {
+		_, ok, err := find(id)
+		if err != nil {
+			return models.Todo{}, err
+		}
+		if ok {
+			restored.DependsOn = append(restored.DependsOn, id)
+		}
+	}
+	return stampRevised(todo, restored, now), nil
+}
+
+// expired reports whether todo was moved to the trash at or before cutoff
+func expired(todo models.Todo, cutoff time.Time) bool {
+	return todo.DeletedAt != nil && !todo.DeletedAt.After(cutoff)
+}
+
+// TrashPurgerActor is the actor recorded against the Todo items removed from the trash by a TrashPurger
+const TrashPurgerActor = "trash-purger"
+
+// A TrashPurger permanently removes Todo items which have been in the trash for longer than a retention period, checking
+// for them at a regular interval
+type TrashPurger struct {
+	service   TodoService
+	retention time.Duration
+	interval  time.Duration
+	logger    *slog.Logger
+}
+
+// NewTrashPurger creates a new TrashPurger removing the Todo items held in the trash of service for longer than
+// retention, checking every interval. A retention of zero keeps Todo items in the trash until they are removed by hand.
+// This is used by Wire when starting the API to perform the necessary dependency injection
+func NewTrashPurger(service TodoService, retention time.Duration, interval time.Duration, logger *slog.Logger) *TrashPurger {
+	return &TrashPurger{service, retention, interval, logger}
+}
+
+// Run purges the trash straight away and then once every interval, until ctx is cancelled. It returns immediately if
+// the retention period is zero
+func (purger *TrashPurger) Run(ctx context.Context) {
+	if purger.retention <= 0 || purger.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(purger.interval)
+	defer ticker.Stop()
+	for {
+		purger.purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge removes the expired Todo items from the trash, logging how many were removed. The revisions recording their
+// removal name the purger as their actor
+func (purger *TrashPurger) purge(ctx context.Context) {
+	purged, err := purger.service.PurgeTrash(WithActor(ctx, TrashPurgerActor), purger.retention)
+	if err != nil {
+		purger.logger.ErrorContext(ctx, "Failed to purge trash", "error", err)
+		return
+	}
+	if purged > 0 {
+		purger.logger.InfoContext(ctx, "Purged trash", "todos", purged, "retention", purger.retention)
+	}
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"TodoApp/src/main/models"
+	"context"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func trashPrerequisite() []models.Todo {
+	return []models.Todo{
+		{Id: "parent", Title: "Parent"},
+		{Id: "child", Title: "Child", ParentId: "parent"},
+		{Id: "design", Title: "Design"},
+		{Id: "build", Title: "Build", DependsOn: []string{"design"}},
+		{Id: "other", Title: "Other"},
+	}
+}
+
+func TestTrashAcrossBackends(t *testing.T) {
+	for name, service := range setupBackends(t, trashPrerequisite()) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, id := range []string{"child", "parent", "build", "design"} {
+				err := service.DeleteTodo(ctx, id, nil)
+				if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+			}
+			_, err := service.ReturnSingleTodo(ctx, "child")
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+			counts, err := service.CountTodos(ctx)
+			if err != nil || counts.Total != 1 {
+				t.Fatalf("Expected only [other] to be counted but found %+v with error [%v]", counts, err)
+			}
+
+			trash, err := service.ReturnTrash(ctx)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff([]string{"child", "parent", "build", "design"}, ids(trash))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			deletedAt := testTime
+			diff = cmp.Diff(stamped(models.Todo{Id: "child", Title: "Child", ParentId: "parent", Version: 1, DeletedAt: &deletedAt}), trash[0])
+			if diff != "" {
+				t.Fatal(diff)
+			}
+
+			_, err = service.CreateNewTodo(ctx, models.Todo{Id: "child", Title: "Replacement"})
+			if !errors.Is(err, ErrConflict) {
+				t.Fatalf("Conflict error expected but was [%v]", err)
+			}
+
+			// The parent and prerequisite were deleted after the todo items referencing them, so are dropped on restore
+			child, err := service.RestoreTodo(ctx, "child")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff(stamped(models.Todo{Id: "child", Title: "Child", Version: 2}), child)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			_, err = service.RestoreTodo(ctx, "design")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			build, err := service.RestoreTodo(ctx, "build")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff = cmp.Diff([]string{"design"}, build.DependsOn)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			_, err = service.ReturnSingleTodo(ctx, "child")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+
+			_, err = service.RestoreTodo(ctx, "child")
+			if !errors.Is(err, ErrNotFound) || err.Error() != "could not find trashed todo with id [child]" {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+			err = service.PurgeTodo(ctx, "parent")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = service.PurgeTodo(ctx, "parent")
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Not found error expected but was [%v]", err)
+			}
+			trash, err = service.ReturnTrash(ctx)
+			if err != nil || len(trash) != 0 {
+				t.Fatalf("Expected the trash to be empty but found %v with error [%v]", trash, err)
+			}
+		})
+	}
+}
+
+func TestPurgeTrashAcrossBackends(t *testing.T) {
+	for name, service := range setupBackends(t, trashPrerequisite()) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, id := range []string{"child", "other"} {
+				err := service.DeleteTodo(ctx, id, nil)
+				if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+			}
+			purged, err := service.PurgeTrash(ctx, time.Hour)
+			if err != nil || purged != 0 {
+				t.Fatalf("Expected nothing to be purged but purged [%d] with error [%v]", purged, err)
+			}
+			purged, err = service.PurgeTrash(ctx, 0)
+			if err != nil || purged != 2 {
+				t.Fatalf("Expected [2] todos to be purged but purged [%d] with error [%v]", purged, err)
+			}
+			trash, err := service.ReturnTrash(ctx)
+			if err != nil || len(trash) != 0 {
+				t.Fatalf("Expected the trash to be empty but found %v with error [%v]", trash, err)
+			}
+		})
+	}
+}
+
+func TestDeleteListMovesTodosToTrashAcrossBackends(t *testing.T) {
+	for name, service := range setupBackends(t, []models.Todo{}) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := service.CreateNewList(ctx, models.List{Id: "sprint", Name: "Sprint"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			_, err = service.CreateNewTodo(ctx, models.Todo{Id: "inside", Title: "Inside", ListId: "sprint"})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = service.DeleteList(ctx, "sprint", ListDeleteCascade, nil)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			trash, err := service.ReturnTrash(ctx)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			diff := cmp.Diff([]string{"inside"}, ids(trash))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			restored, err := service.RestoreTodo(ctx, "inside")
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if restored.ListId != "" || restored.DeletedAt != nil || restored.Version != 2 {
+				t.Fatalf("Expected [inside] to be restored outside of any list but was [%+v]", restored)
+			}
+		})
+	}
+}
+
+func TestTrashPurgerRun(t *testing.T) {
+	now := testTime
+	service := NewTodoServiceImpl([]models.Todo{}, Options{AllowClientIds: true, Clock: func() time.Time { return now }})
+	ctx := context.Background()
+	for _, id := range []string{"old", "new"} {
+		_, err := service.CreateNewTodo(ctx, models.Todo{Id: id, Title: id})
+		if err != nil {
+			t.Fatalf("Error occured when none expected: [%v]", err)
+		}
+		err = service.DeleteTodo(ctx, id, nil)
+		if err != nil {
+			t.Fatalf("Error occured when none expected: [%v]", err)
+		}
+		now = now.Add(2 * time.Hour)
+	}
+
+	// A cancelled context stops the purger after its first purge
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	NewTrashPurger(service, 3*time.Hour, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil))).Run(cancelled)
+	trash, err := service.ReturnTrash(ctx)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	diff := cmp.Diff([]string{"new"}, ids(trash))
+	if diff != "" {
+		t.Fatal(diff)
+	}
+
+	// A retention of zero keeps everything
+	now = now.Add(24 * time.Hour)
+	NewTrashPurger(service, 0, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil))).Run(ctx)
+	trash, err = service.ReturnTrash(ctx)
+	if err != nil || len(trash) != 1 {
+		t.Fatalf("Expected the trash to be kept but found %v with error [%v]", trash, err)
+	}
+}
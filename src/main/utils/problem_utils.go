@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemContentType is the media type used for error responses, as defined by RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// A Problem represents the body of an error response, following the problem details format defined by RFC 7807.
+// Composed of the following fields:
+//
+// Type: A URI identifying the type of problem, "about:blank" when the problem has no meaning beyond the HTTP status
+//
+// Title: A short, human-readable summary of the type of problem
+//
+// Status: The HTTP status code of the response
+//
+// Detail: A human-readable explanation specific to this occurrence of the problem
+//
+// Instance: The URI of the request which caused the problem
+//
+// Errors: The individual fields which failed validation, only present for validation problems
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Errors   []ProblemError `json:"errors,omitempty"`
+}
+
+// A ProblemError describes why a single field within the request failed validation
+type ProblemError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// NewProblem creates a new Problem object for the HTTP status code and detail passed as parameters, using the status
+// text as the title and the path of the request as the instance
+func NewProblem(request *http.Request, httpCode int, detail string, errors ...ProblemError) Problem {
+	return Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(httpCode),
+		Status:   httpCode,
+		Detail:   detail,
+		Instance: request.URL.Path,
+		Errors:   errors,
+	}
+}
+
+// ReturnProblemResponse sends an error response back to the client in the problem details format.
+//
+// ReturnProblemResponse receives a writer, used to return the response to the client, the request which caused the
+// problem, a HTTP code to be returned as part of the response header, a detail message explaining the problem, and
+// optionally the individual fields which failed validation. Like ReturnJsonResponse, a failure to serialize the problem
+// is returned for the caller to log
+func ReturnProblemResponse(writer http.ResponseWriter, request *http.Request, httpCode int, detail string, errors ...ProblemError) error {
+	writer.Header().Set("Content-Type", ProblemContentType)
+	writer.WriteHeader(httpCode)
+	return json.NewEncoder(writer).Encode(NewProblem(request, httpCode, detail, errors...))
+}
@@ -8,12 +8,11 @@ import (
 // ReturnJsonResponse sends a HTTP response back to the client, if a body is provided it will be serialized into JSON format.
 //
 // ReturnJsonResponse receives a writer, used to return the response to the client, a HTTP code to be returned as part of
-// the response header, and an optional response body to be included in the response
-func ReturnJsonResponse(writer http.ResponseWriter, httpCode int, responseBody any) {
+// the response header, and an optional response body to be included in the response. As the response header has already
+// been sent by the time the body is serialized, a failure to serialize the body cannot be reported to the client and is
+// returned for the caller to log instead
+func ReturnJsonResponse(writer http.ResponseWriter, httpCode int, responseBody any) error {
 	writer.Header().Set("Content-Type", "application/json")
 	writer.WriteHeader(httpCode)
-	err := json.NewEncoder(writer).Encode(responseBody)
-	if err != nil {
-		panic(err)
-	}
+	return json.NewEncoder(writer).Encode(responseBody)
 }
@@ -0,0 +1,272 @@
+package webhooks
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// The headers sent with every delivery
+const (
+	// SignatureHeader holds the signature of the body of the delivery, see Sign
+	SignatureHeader = "X-Webhook-Signature"
+	// EventHeader holds the event being delivered
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader holds the id of the delivery, which differs for every redelivery of the same event
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+// maxBackoff is the longest a failed delivery waits before it is attempted again
+const maxBackoff = 6 * time.Hour
+
+// maxResponseBytes is how much of the body of a response from a webhook is read before the connection is discarded
+const maxResponseBytes = 64 << 10
+
+// Sign returns the signature sent within the SignatureHeader of a delivery, "sha256=" followed by the hex encoded
+// HMAC-SHA256 of payload keyed with secret. Receivers compute the same over the body they received and compare the
+// two, using a constant time comparison, to check the delivery came from the API and was not altered
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// A Dispatcher queues a delivery of every event recorded by the revisions of a TodoService within a Registry, then sends
+// them as they fall due. A delivery is attempted until the webhook responds with a 2xx, waiting twice as long after each
+// failed attempt, and fails once it has been attempted maxAttempts times. Redirects are not followed and count as a
+// failed attempt. The deliveries to each webhook are sent in turn, while different webhooks are sent to concurrently,
+// so a slow webhook only holds up its own deliveries
+type Dispatcher struct {
+	registry    *Registry
+	todoService services.TodoService
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	logger      *slog.Logger
+	mu          sync.Mutex
+	// sending holds the ids of the webhooks whose deliveries are being sent
+	sending map[string]bool
+	senders sync.WaitGroup
+}
+
+// NewDispatcher creates a new Dispatcher queuing the events recorded by todoService within registry, resuming from the
+// last revision queued before the API restarted, then sending them, giving up on each attempt after timeout and waiting
+// backoff after the first failed attempt. This is used by Wire when starting the API to perform the necessary
+// dependency injection
+func NewDispatcher(ctx context.Context, registry *Registry, todoService services.TodoService, timeout time.Duration,
+	maxAttempts int, backoff time.Duration, logger *slog.Logger) (*Dispatcher, error) {
+	latest, err := services.LatestRevision(ctx, todoService)
+	if err != nil {
+		return nil, err
+	}
+	registry.resume(latest)
+	client := &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &Dispatcher{registry: registry, todoService: todoService, client: client, maxAttempts: maxAttempts,
+		backoff: backoff, logger: logger, sending: map[string]bool{}}, nil
+}
+
+// Run queues deliveries of the events recorded by each change and sends them as they fall due, until ctx is cancelled.
+// It returns once every delivery being sent has finished. A delivery interrupted by ctx being cancelled is not counted
+// as an attempt, so it is sent again once the API restarts
+func (dispatcher *Dispatcher) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	defer dispatcher.senders.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-dispatcher.registry.wake:
+		case <-timer.C:
+		}
+		dispatcher.enqueueRecorded(ctx)
+		next := dispatcher.deliverDue(ctx)
+		wait := maxBackoff
+		if !next.IsZero() {
+			wait = max(time.Until(next), 0)
+		}
+		timer.Reset(wait)
+	}
+}
+
+// Wake wakes the Dispatcher to queue the deliveries of the events recorded since it last woke, without waiting for them
+// to be queued. It is registered as an observer of the Store, see services.Store
+func (dispatcher *Dispatcher) Wake(_ context.Context) {
+	dispatcher.registry.signal()
+}
+
+// enqueueRecorded queues the deliveries of the events recorded by every revision made since the last one queued. Only
+// the revisions after the cursor of the registry are read, in pages, each of which is queued at once. Revisions which
+// fail to be queued are queued again the next time the Dispatcher wakes
+func (dispatcher *Dispatcher) enqueueRecorded(ctx context.Context) {
+	_, err := services.ReadRevisions(ctx, dispatcher.todoService, dispatcher.registry.cursor(),
+		func(revisions []models.Revision) error {
+			var payloads []models.WebhookPayload
+			for _, revision := range revisions {
+				payloads = append(payloads, revisionPayloads(revision)...)
+			}
+			return dispatcher.registry.enqueue(revisions[len(revisions)-1].Id, payloads...)
+		})
+	if err != nil && ctx.Err() == nil {
+		dispatcher.logger.ErrorContext(ctx, "Failed to queue webhook deliveries", "error", err)
+	}
+}
+
+// deliverDue starts sending the deliveries which are due to each webhook whose deliveries are not already being sent,
+// returning when the next of the remaining deliveries to those webhooks is due, which is zero when there are none. The
+// registry wakes the Dispatcher once the deliveries to a webhook have been sent, so that those which fell due in the
+// meantime are sent
+func (dispatcher *Dispatcher) deliverDue(ctx context.Context) time.Time {
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+	due, next := dispatcher.registry.due(func(webhookId string) bool {
+		return dispatcher.sending[webhookId]
+	})
+	batches := map[string][]dueDelivery{}
+	var webhookIds []string
+	for _, pending := range due {
+		webhookId := pending.delivery.WebhookId
+		if batches[webhookId] == nil {
+			webhookIds = append(webhookIds, webhookId)
+		}
+		batches[webhookId] = append(batches[webhookId], pending)
+	}
+	for _, webhookId := range webhookIds {
+		dispatcher.sending[webhookId] = true
+		dispatcher.senders.Add(1)
+		go func() {
+			defer dispatcher.senders.Done()
+			for _, pending := range batches[webhookId] {
+				if ctx.Err() != nil {
+					break
+				}
+				dispatcher.deliver(ctx, pending)
+			}
+			dispatcher.mu.Lock()
+			delete(dispatcher.sending, webhookId)
+			dispatcher.mu.Unlock()
+			dispatcher.registry.signal()
+		}()
+	}
+	return next
+}
+
+// deliver attempts to send pending, recording the outcome and when it is next due if the attempt failed
+func (dispatcher *Dispatcher) deliver(ctx context.Context, pending dueDelivery) {
+	delivery := pending.delivery
+	status, err := dispatcher.send(ctx, pending)
+	if ctx.Err() != nil {
+		return
+	}
+	now := dispatcher.registry.now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = status
+	delivery.Error = ""
+	delivery.NextAttemptAt = nil
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+	case delivery.Attempts >= dispatcher.maxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = err.Error()
+		dispatcher.logger.WarnContext(ctx, "Webhook delivery failed", "webhook", delivery.WebhookId,
+			"delivery", delivery.Id, "attempts", delivery.Attempts, "error", err)
+	default:
+		next := now.Add(dispatcher.backoffAfter(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		delivery.Error = err.Error()
+	}
+	err = dispatcher.registry.record(delivery)
+	if err != nil {
+		dispatcher.logger.ErrorContext(ctx, "Failed to record webhook delivery", "delivery", delivery.Id, "error", err)
+	}
+}
+
+// send POSTs the payload of pending to its webhook, returning the status code the webhook responded with and an error
+// if it did not respond with a 2xx
+func (dispatcher *Dispatcher) send(ctx context.Context, pending dueDelivery) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, pending.url, bytes.NewReader(pending.delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, string(pending.delivery.Event))
+	request.Header.Set(DeliveryHeader, pending.delivery.Id)
+	request.Header.Set(SignatureHeader, Sign(pending.secret, pending.delivery.Payload))
+	response, err := dispatcher.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxResponseBytes))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("webhook responded with status [%d]", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+// backoffAfter returns how long to wait after the attempt numbered attempts failed, doubling with every attempt up to
+// maxBackoff
+func (dispatcher *Dispatcher) backoffAfter(attempts int) time.Duration {
+	wait := dispatcher.backoff
+	for range attempts - 1 {
+		if wait >= maxBackoff/2 {
+			return maxBackoff
+		}
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+// revisionPayloads returns the payloads of the events recorded by revision, in the order they are delivered. Revisions
+// are notified as the same action as they are to the feed, see services.NotifiedAction
+func revisionPayloads(revision models.Revision) []models.WebhookPayload {
+	action, ok := services.NotifiedAction(revision)
+	if !ok {
+		return nil
+	}
+	var events []models.WebhookEvent
+	switch action {
+	case models.ActionCreate:
+		events = []models.WebhookEvent{models.WebhookTodoCreated}
+	case models.ActionUpdate:
+		events = []models.WebhookEvent{models.WebhookTodoUpdated}
+		if completes(revision) {
+			events = append(events, models.WebhookTodoCompleted)
+		}
+	case models.ActionDelete:
+		events = []models.WebhookEvent{models.WebhookTodoDeleted}
+	}
+	payloads := make([]models.WebhookPayload, 0, len(events))
+	for _, event := range events {
+		payloads = append(payloads, models.WebhookPayload{Event: event, Actor: revision.Actor, At: revision.At,
+			Todo: revision.Todo})
+	}
+	return payloads
+}
+
+// completes reports whether revision records a todo item being completed
+func completes(revision models.Revision) bool {
+	for _, change := range revision.Changes {
+		if change.Field == "Completed" && string(change.New) == "true" {
+			return true
+		}
+	}
+	return false
+}
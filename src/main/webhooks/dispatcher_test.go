@@ -0,0 +1,417 @@
+package webhooks
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"github.com/google/go-cmp/cmp"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// receiver records each delivery it receives, responding with the status codes of responses in turn and with the last
+// of them once they run out
+type receiver struct {
+	t         *testing.T
+	secret    string
+	responses []int
+	received  atomic.Int32
+}
+
+func (receiver *receiver) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		receiver.t.Errorf("Error occured when none expected: [%v]", err)
+	}
+	signature := request.Header.Get(SignatureHeader)
+	if !hmac.Equal([]byte(signature), []byte(Sign(receiver.secret, body))) {
+		receiver.t.Errorf("unexpected signature [%v] of the body [%s]", signature, body)
+	}
+	if request.Header.Get(EventHeader) == "" || request.Header.Get(DeliveryHeader) == "" {
+		receiver.t.Errorf("unexpected headers [%v]", request.Header)
+	}
+	received := int(receiver.received.Add(1))
+	writer.WriteHeader(receiver.responses[min(received, len(receiver.responses))-1])
+}
+
+func setupDispatcherTest(t *testing.T, responses ...int) (*Dispatcher, *receiver, string) {
+	target := &receiver{t: t, secret: "shh", responses: responses}
+	server := httptest.NewServer(target)
+	t.Cleanup(server.Close)
+	registry, created := setupRegistryTest(t, "",
+		models.Webhook{Url: server.URL, Events: []models.WebhookEvent{models.WebhookTodoCreated}, Secret: "shh"})
+	store := services.NewTodoServiceImpl([]models.Todo{}, services.Options{})
+	dispatcher, err := NewDispatcher(context.Background(), registry, store, time.Second, 3, time.Minute,
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	err = registry.enqueue(0, models.WebhookPayload{Event: models.WebhookTodoCreated, Todo: models.Todo{Id: "1"}})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	return dispatcher, target, created[0].Id
+}
+
+// deliverAll starts sending every delivery which is due, waiting until they have been sent, then returns when the next
+// delivery is due
+func deliverAll(ctx context.Context, dispatcher *Dispatcher) time.Time {
+	dispatcher.deliverDue(ctx)
+	dispatcher.senders.Wait()
+	_, next := dispatcher.registry.due(func(string) bool { return false })
+	return next
+}
+
+func TestSign(t *testing.T) {
+	expected := "sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"
+	actual := Sign("key", []byte("The quick brown fox jumps over the lazy dog"))
+	if actual != expected {
+		t.Errorf("unexpected signature, expected [%v] but recieved [%v]", expected, actual)
+	}
+}
+
+func TestDispatcherRun(t *testing.T) {
+	dispatcher, target, id := setupDispatcherTest(t, http.StatusNoContent)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, err := dispatcher.registry.ReturnDeliveries(ctx, id)
+		if err != nil {
+			t.Fatalf("Error occured when none expected: [%v]", err)
+		}
+		if deliveries[0].Status == models.DeliverySucceeded {
+			if deliveries[0].Attempts != 1 || deliveries[0].ResponseStatus != http.StatusNoContent {
+				t.Errorf("unexpected delivery [%+v]", deliveries[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected delivery, expected it to succeed but recieved [%+v]", deliveries[0])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if target.received.Load() != 1 {
+		t.Errorf("unexpected number of requests, expected [1] but recieved [%v]", target.received.Load())
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	tests := map[string]struct {
+		responses        []int
+		expectedStatus   models.DeliveryStatus
+		expectedAttempts int
+		expectedError    string
+	}{
+		"Succeeds After Retrying": {
+			responses:        []int{http.StatusInternalServerError, http.StatusOK},
+			expectedStatus:   models.DeliverySucceeded,
+			expectedAttempts: 2,
+		},
+		"Fails After Max Attempts": {
+			responses:        []int{http.StatusServiceUnavailable},
+			expectedStatus:   models.DeliveryFailed,
+			expectedAttempts: 3,
+			expectedError:    "webhook responded with status [503]",
+		},
+		"Redirect Is Not Followed": {
+			responses:        []int{http.StatusFound},
+			expectedStatus:   models.DeliveryFailed,
+			expectedAttempts: 3,
+			expectedError:    "webhook responded with status [302]",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			dispatcher, target, id := setupDispatcherTest(t, tt.responses...)
+			now := dispatcher.registry.now()
+			dispatcher.registry.clock = fixedClock(&now)
+
+			var waits []time.Duration
+			for {
+				next := deliverAll(ctx, dispatcher)
+				if next.IsZero() {
+					break
+				}
+				waits = append(waits, next.Sub(now))
+				now = next
+			}
+			deliveries, err := dispatcher.registry.ReturnDeliveries(ctx, id)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			delivery := deliveries[0]
+			if delivery.Status != tt.expectedStatus || delivery.Attempts != tt.expectedAttempts ||
+				delivery.Error != tt.expectedError || delivery.NextAttemptAt != nil {
+				t.Errorf("unexpected delivery [%+v]", delivery)
+			}
+			if int(target.received.Load()) != tt.expectedAttempts {
+				t.Errorf("unexpected number of requests, expected [%v] but recieved [%v]", tt.expectedAttempts,
+					target.received.Load())
+			}
+			// Every failed attempt but the last is retried, waiting twice as long each time
+			expected := []time.Duration{time.Minute, 2 * time.Minute}[:min(tt.expectedAttempts-1, 2)]
+			diff := cmp.Diff(expected, waits)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestDispatcherSendsToWebhooksConcurrently(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	var slowReceived atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		slowReceived.Add(1)
+		<-release
+	}))
+	t.Cleanup(slow.Close)
+	dispatcher, target, id := setupDispatcherTest(t, http.StatusOK)
+	_, err := dispatcher.registry.CreateNewWebhook(ctx, models.Webhook{Url: slow.URL,
+		Events: []models.WebhookEvent{models.WebhookTodoCreated}})
+	if err != nil {
+		t.Fatalf("Failed to persist prerequisite webhook: [%v]", err)
+	}
+	defer close(release)
+
+	// Both webhooks receive the first event, then the second is queued while the slow webhook is still responding
+	for i := range 2 {
+		err = dispatcher.registry.enqueue(0, models.WebhookPayload{Event: models.WebhookTodoCreated, Todo: models.Todo{Id: "1"}})
+		if err != nil {
+			t.Fatalf("Error occured when none expected: [%v]", err)
+		}
+		dispatcher.deliverDue(ctx)
+		deadline := time.Now().Add(5 * time.Second)
+		for int(target.received.Load()) < 2+i {
+			if time.Now().After(deadline) {
+				t.Fatal("Delivery held up by another webhook when sent concurrently expected")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	deliveries, err := dispatcher.registry.ReturnDeliveries(ctx, id)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if len(deliveries) != 3 {
+		t.Errorf("unexpected deliveries [%+v]", deliveries)
+	}
+	// The deliveries to the slow webhook are sent in turn, so the next is not sent until the first has been answered
+	if slowReceived.Load() != 1 {
+		t.Errorf("unexpected number of requests, expected [1] but recieved [%v]", slowReceived.Load())
+	}
+}
+
+func TestDispatcherQueuesRecordedChanges(t *testing.T) {
+	due := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		events   []models.WebhookEvent
+		change   func(ctx context.Context, store services.Store) error
+		expected []string
+		// unchanged is set when the change fails, so nothing is committed to wake the Dispatcher
+		unchanged bool
+	}{
+		"Create": {
+			events: allEvents,
+			change: func(ctx context.Context, store services.Store) error {
+				_, err := store.CreateNewTodo(ctx, models.Todo{Id: "4", Title: "Buy eggs"})
+				return err
+			},
+			expected: []string{"todo.created 4 Buy eggs"},
+		},
+		"Update": {
+			events: allEvents,
+			change: func(ctx context.Context, store services.Store) error {
+				_, err := store.UpdateTodo(ctx, models.Todo{Id: "1", Title: "Bake bread"}, nil)
+				return err
+			},
+			expected: []string{"todo.updated 1 Bake bread"},
+		},
+		"Update Completing Todo Cascades To Subtasks": {
+			events: allEvents,
+			change: func(ctx context.Context, store services.Store) error {
+				_, err := store.UpdateTodo(ctx, models.Todo{Id: "1", Title: "Bake cake", Completed: true}, nil)
+				return err
+			},
+			expected: []string{"todo.updated 2 Buy flour", "todo.completed 2 Buy flour", "todo.updated 1 Bake cake",
+				"todo.completed 1 Bake cake"},
+		},
+		"Patch Completing Todo Subscribed To Completion": {
+			events: []models.WebhookEvent{models.WebhookTodoCompleted},
+			change: func(ctx context.Context, store services.Store) error {
+				_, err := store.PatchTodo(ctx, "2", services.TodoPatch{Type: services.MergePatchType,
+					Document: []byte(`{"Completed": true}`)}, nil)
+				return err
+			},
+			expected: []string{"todo.completed 2 Buy flour"},
+		},
+		"Completing Recurring Todo Creates Next Occurrence": {
+			events: []models.WebhookEvent{models.WebhookTodoCreated, models.WebhookTodoCompleted},
+			change: func(ctx context.Context, store services.Store) error {
+				_, err := store.UpdateTodo(ctx, models.Todo{Id: "3", Title: "Standup", DueAt: &due,
+					Recurrence: "FREQ=DAILY", Completed: true}, nil)
+				return err
+			},
+			expected: []string{"todo.created  Standup", "todo.completed 3 Standup"},
+		},
+		"Delete, Restore And Purge": {
+			events: allEvents,
+			change: func(ctx context.Context, store services.Store) error {
+				err := store.DeleteTodo(ctx, "2", nil)
+				if err != nil {
+					return err
+				}
+				_, err = store.RestoreTodo(ctx, "2")
+				if err != nil {
+					return err
+				}
+				err = store.DeleteTodo(ctx, "2", nil)
+				if err != nil {
+					return err
+				}
+				return store.PurgeTodo(ctx, "2")
+			},
+			expected: []string{"todo.deleted 2 Buy flour", "todo.created 2 Buy flour", "todo.deleted 2 Buy flour"},
+		},
+		"Revert": {
+			events: allEvents,
+			change: func(ctx context.Context, store services.Store) error {
+				_, err := store.UpdateTodo(ctx, models.Todo{Id: "1", Title: "Bake bread"}, nil)
+				if err != nil {
+					return err
+				}
+				_, err = store.RevertTodo(ctx, "1", 1, nil)
+				return err
+			},
+			expected: []string{"todo.updated 1 Bake bread", "todo.updated 1 Bake cake"},
+		},
+		"Delete List With Its Todos": {
+			events: []models.WebhookEvent{models.WebhookTodoDeleted},
+			change: func(ctx context.Context, store services.Store) error {
+				_, err := store.CreateNewList(ctx, models.List{Id: "chores", Name: "Chores"})
+				if err != nil {
+					return err
+				}
+				_, err = store.MoveTodo(ctx, "1", "chores", nil)
+				if err != nil {
+					return err
+				}
+				return store.DeleteList(ctx, "chores", services.ListDeleteCascade, nil)
+			},
+			expected: []string{"todo.deleted 1 Bake cake"},
+		},
+		"Failed Change": {
+			events: allEvents,
+			change: func(ctx context.Context, store services.Store) error {
+				_ = store.DeleteTodo(ctx, "9", nil)
+				return nil
+			},
+			unchanged: true,
+		},
+		"Not Subscribed": {
+			events: []models.WebhookEvent{models.WebhookTodoDeleted},
+			change: func(ctx context.Context, store services.Store) error {
+				_, err := store.CreateNewTodo(ctx, models.Todo{Id: "4", Title: "Buy eggs"})
+				return err
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := services.WithActor(context.Background(), "alice")
+			store := services.NewTodoServiceImpl([]models.Todo{}, services.Options{AllowClientIds: true,
+				CompletionRule: services.CompletionCascade})
+			for _, todo := range []models.Todo{{Id: "1", Title: "Bake cake"}, {Id: "2", Title: "Buy flour", ParentId: "1"},
+				{Id: "3", Title: "Standup", DueAt: &due, Recurrence: "FREQ=DAILY"}} {
+				_, err := store.CreateNewTodo(ctx, todo)
+				if err != nil {
+					t.Fatalf("Failed to persist prerequisite todo: [%v]", err)
+				}
+			}
+			registry, created := setupRegistryTest(t, "", models.Webhook{Url: "https://example.com/hook", Events: tt.events})
+			dispatcher, err := NewDispatcher(ctx, registry, store, time.Second, 3, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+
+			store.Observe(dispatcher.Wake)
+
+			err = tt.change(ctx, store)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			// The change only wakes the Dispatcher, which queues the deliveries of every revision made since it last woke
+			woken := len(registry.wake) == 1
+			if woken == tt.unchanged {
+				t.Fatalf("unexpected wake, expected [%v] but recieved [%v]", !tt.unchanged, woken)
+			}
+			dispatcher.enqueueRecorded(ctx)
+			deliveries, err := registry.ReturnDeliveries(ctx, created[0].Id)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			var actual []string
+			// Deliveries are returned newest first
+			for i := len(deliveries) - 1; i >= 0; i-- {
+				var payload models.WebhookPayload
+				err = json.Unmarshal(deliveries[i].Payload, &payload)
+				if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+				if payload.Actor != "alice" || payload.Event != deliveries[i].Event {
+					t.Errorf("unexpected payload [%+v] of the delivery of [%v]", payload, deliveries[i].Event)
+				}
+				id := payload.Todo.Id
+				if payload.Todo.Occurrence > 1 {
+					// The id of the next occurrence is generated
+					id = ""
+				}
+				actual = append(actual, string(payload.Event)+" "+id+" "+payload.Todo.Title)
+			}
+			diff := cmp.Diff(tt.expected, actual)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestBackoffAfter(t *testing.T) {
+	dispatcher := &Dispatcher{backoff: 10 * time.Second}
+	tests := map[int]time.Duration{
+		1:  10 * time.Second,
+		2:  20 * time.Second,
+		4:  80 * time.Second,
+		12: 20480 * time.Second,
+		13: maxBackoff,
+		19: maxBackoff,
+	}
+
+	for attempts, expected := range tests {
+		actual := dispatcher.backoffAfter(attempts)
+		if actual != expected {
+			t.Errorf("unexpected backoff after [%v] attempts, expected [%v] but recieved [%v]", attempts, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,706 @@
+// Package webhooks delivers changes made to todo items to other systems over HTTP. Each webhook subscribes to the events
+// it is interested in, and every event is queued as a Delivery which is signed and retried until the webhook accepts it
+package webhooks
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// deliveryLogSize is the number of finished deliveries kept for each webhook, older ones are discarded. Pending
+// deliveries are always kept
+const deliveryLogSize = 100
+
+// secretBytes is the number of random bytes within a generated secret
+const secretBytes = 32
+
+// deliveriesSuffix is appended to the path of the file of a Registry to name the log its deliveries are kept in
+const deliveriesSuffix = ".deliveries"
+
+// deliveryCompactAfter is the number of records the delivery log holds before it is compacted
+const deliveryCompactAfter = 1000
+
+// A Service is responsible for functionality relating to webhooks and their deliveries
+type Service interface {
+	ReturnAllWebhooks(ctx context.Context) ([]models.Webhook, error)
+	ReturnSingleWebhook(ctx context.Context, id string) (models.Webhook, error)
+	CreateNewWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook models.Webhook, expectedVersion *int64) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string, expectedVersion *int64) error
+	ReturnDeliveries(ctx context.Context, id string) ([]models.Delivery, error)
+	Redeliver(ctx context.Context, id string, deliveryId string) (models.Delivery, error)
+}
+
+// registryState holds every webhook and delivery of a Registry, alongside Cursor, the id of the last revision whose
+// events have been queued. The cursor is saved alongside the deliveries queued by the revisions up to it, or on its own
+// when they queued none, so the revisions after a saved cursor were never queued and are queued once the API restarts
+type registryState struct {
+	Webhooks   []models.Webhook
+	Deliveries []models.Delivery
+	Cursor     int64
+}
+
+// webhooksFile is the document the webhooks of a Registry are saved as
+type webhooksFile struct {
+	Webhooks []models.Webhook `json:"Webhooks"`
+}
+
+// deliveryRecord is a single line of the delivery log of a Registry. Composed of the following fields:
+//
+// Deliveries: The deliveries queued or attempted, as they were left, each replacing any earlier record of the same
+// delivery
+//
+// Cursor: When not zero, the id of the last revision whose events have been queued
+type deliveryRecord struct {
+	Deliveries []models.Delivery `json:"Deliveries,omitempty"`
+	Cursor     int64             `json:"Cursor,omitempty"`
+}
+
+// A Registry represents a Service class holding every webhook alongside the queue of deliveries to them, oldest first.
+// Webhooks are saved to its file, which is only rewritten when a webhook changes, replacing the previous file
+// atomically. Deliveries are kept apart from them, within an append-only JSON lines log alongside the file, so that
+// queuing or attempting a delivery only appends a record of it. The log is compacted once it holds
+// deliveryCompactAfter records. Without a file the registry is only held in-memory
+type Registry struct {
+	path  string
+	clock func() time.Time
+	// writeMu serialises changes, so the state each is decided against cannot change before it has been saved. It is held
+	// while writing to disk, while mu is only held to read or replace the state, so reads never wait on the disk
+	writeMu sync.Mutex
+	// records is the number of records within the delivery log, guarded by writeMu
+	records int
+	mu      sync.Mutex
+	state   registryState
+	// wake is signalled whenever a delivery is queued, waking the Dispatcher
+	wake chan struct{}
+}
+
+// NewRegistry creates a new Registry saved to the file at path, loading the webhooks already saved within it and the
+// deliveries saved within the log alongside it. An empty path holds the registry in-memory. This is used by Wire when
+// starting the API to perform the necessary dependency injection
+func NewRegistry(path string) (*Registry, error) {
+	registry := &Registry{
+		path:  path,
+		clock: time.Now,
+		state: registryState{Webhooks: []models.Webhook{}, Deliveries: []models.Delivery{}},
+		wake:  make(chan struct{}, 1),
+	}
+	if path == "" {
+		return registry, nil
+	}
+	err := registry.load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhooks from [%s]: %w", path, err)
+	}
+	return registry, nil
+}
+
+// load restores the webhooks saved within the file of the registry, then replays the delivery log on top of them. A
+// missing file or log is treated as empty. A final record of the log which was only partly written when the API
+// stopped is discarded, as the change it describes was never applied
+func (registry *Registry) load() error {
+	data, err := os.ReadFile(registry.path)
+	if err == nil {
+		var file webhooksFile
+		err = json.Unmarshal(data, &file)
+		if file.Webhooks != nil {
+			registry.state.Webhooks = file.Webhooks
+		}
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	logPath := registry.path + deliveriesSuffix
+	data, err = os.ReadFile(logPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	size, err := services.ReadLog(data, func(line []byte) error {
+		var record deliveryRecord
+		err := json.Unmarshal(line, &record)
+		if err != nil {
+			return err
+		}
+		applyRecord(&registry.state, record)
+		registry.records++
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("delivery log: %w", err)
+	}
+	return os.Truncate(logPath, size)
+}
+
+// ReturnAllWebhooks returns every webhook, in the order they were created, without their secrets
+func (registry *Registry) ReturnAllWebhooks(_ context.Context) ([]models.Webhook, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	webhooks := make([]models.Webhook, 0, len(registry.state.Webhooks))
+	for _, webhook := range registry.state.Webhooks {
+		webhooks = append(webhooks, redact(webhook))
+	}
+	return webhooks, nil
+}
+
+// ReturnSingleWebhook returns the webhook with an id matching the id passed as a parameter, without its secret. If no
+// such webhook exists a NotFoundError is returned
+func (registry *Registry) ReturnSingleWebhook(_ context.Context, id string) (models.Webhook, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	index, err := findWebhook(registry.state, id)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	return redact(registry.state.Webhooks[index]), nil
+}
+
+// CreateNewWebhook persists a new webhook, generating its id and, when none was supplied, its secret. The webhook is
+// returned alongside its secret, which is not returned again. If the webhook is invalid a ValidationError is returned
+func (registry *Registry) CreateNewWebhook(_ context.Context, webhook models.Webhook) (models.Webhook, error) {
+	if webhook.Id != "" {
+		return models.Webhook{}, &services.ValidationError{Resource: "webhook",
+			Fields: []services.FieldError{{Field: "Id", Message: "cannot be set by the client"}}}
+	}
+	err := validateWebhook(webhook)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	if webhook.Secret == "" {
+		webhook.Secret, err = generateSecret()
+		if err != nil {
+			return models.Webhook{}, err
+		}
+	}
+	now := registry.now()
+	webhook.Id = id.String()
+	webhook.Version = 1
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	err = registry.updateWebhooks(func(state *registryState) error {
+		state.Webhooks = append(state.Webhooks, webhook)
+		return nil
+	})
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook replaces the webhook with an id matching that of the webhook passed as a parameter, keeping its secret
+// when none was supplied. The webhook is returned without its secret. If no such webhook exists a NotFoundError is
+// returned, and if expectedVersion is not nil and the webhook is at another version a VersionMismatchError
+func (registry *Registry) UpdateWebhook(_ context.Context, webhook models.Webhook, expectedVersion *int64) (models.Webhook, error) {
+	err := validateWebhook(webhook)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	err = registry.updateWebhooks(func(state *registryState) error {
+		index, err := findWebhook(*state, webhook.Id)
+		if err != nil {
+			return err
+		}
+		existing := state.Webhooks[index]
+		err = checkVersion(existing, expectedVersion)
+		if err != nil {
+			return err
+		}
+		if webhook.Secret == "" {
+			webhook.Secret = existing.Secret
+		}
+		webhook.Version = existing.Version + 1
+		webhook.CreatedAt = existing.CreatedAt
+		webhook.UpdatedAt = registry.now()
+		state.Webhooks[index] = webhook
+		return nil
+	})
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	return redact(webhook), nil
+}
+
+// DeleteWebhook removes the webhook with an id matching the id passed as a parameter alongside its deliveries, so any
+// still pending are never sent. If no such webhook exists a NotFoundError is returned, and if expectedVersion is not
+// nil and the webhook is at another version a VersionMismatchError
+func (registry *Registry) DeleteWebhook(_ context.Context, id string, expectedVersion *int64) error {
+	return registry.updateWebhooks(func(state *registryState) error {
+		index, err := findWebhook(*state, id)
+		if err != nil {
+			return err
+		}
+		err = checkVersion(state.Webhooks[index], expectedVersion)
+		if err != nil {
+			return err
+		}
+		state.Webhooks = slices.Delete(state.Webhooks, index, index+1)
+		state.Deliveries = slices.DeleteFunc(state.Deliveries, func(delivery models.Delivery) bool {
+			return delivery.WebhookId == id
+		})
+		return nil
+	})
+}
+
+// ReturnDeliveries returns the deliveries to the webhook with an id matching the id passed as a parameter, newest first.
+// Only the most recent finished deliveries are kept. If no such webhook exists a NotFoundError is returned
+func (registry *Registry) ReturnDeliveries(_ context.Context, id string) ([]models.Delivery, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	_, err := findWebhook(registry.state, id)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := []models.Delivery{}
+	for _, delivery := range slices.Backward(registry.state.Deliveries) {
+		if delivery.WebhookId == id {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues the payload of an earlier delivery to the webhook with an id matching the id passed as a parameter
+// to be sent again, as a new delivery which is returned. If no such webhook or delivery exists a NotFoundError is
+// returned
+func (registry *Registry) Redeliver(_ context.Context, id string, deliveryId string) (models.Delivery, error) {
+	var redelivery models.Delivery
+	err := registry.appendDeliveries(func(state registryState) (deliveryRecord, error) {
+		_, err := findWebhook(state, id)
+		if err != nil {
+			return deliveryRecord{}, err
+		}
+		index := slices.IndexFunc(state.Deliveries, func(delivery models.Delivery) bool {
+			return delivery.Id == deliveryId && delivery.WebhookId == id
+		})
+		if index < 0 {
+			return deliveryRecord{}, &services.NotFoundError{Resource: "delivery", Id: deliveryId}
+		}
+		original := state.Deliveries[index]
+		redelivery, err = registry.newDelivery(id, original.Event, original.Payload)
+		if err != nil {
+			return deliveryRecord{}, err
+		}
+		return deliveryRecord{Deliveries: []models.Delivery{redelivery}}, nil
+	})
+	if err != nil {
+		return models.Delivery{}, err
+	}
+	registry.signal()
+	return redelivery, nil
+}
+
+// enqueue queues a delivery of each of payloads to every webhook subscribed to its event, giving each payload a new id
+// shared by its deliveries, and advances the cursor to the revision the payloads were read up to. The subscriptions are
+// checked and the cursor advanced within a single change, so a single record is appended to the delivery log for all
+// of the payloads, holding only the cursor when nothing is subscribed to them
+func (registry *Registry) enqueue(cursor int64, payloads ...models.WebhookPayload) error {
+	queued := false
+	err := registry.appendDeliveries(func(state registryState) (deliveryRecord, error) {
+		record := deliveryRecord{Cursor: cursor}
+		for _, payload := range payloads {
+			var data json.RawMessage
+			for _, webhook := range state.Webhooks {
+				if !slices.Contains(webhook.Events, payload.Event) {
+					continue
+				}
+				if data == nil {
+					id, err := uuid.NewV7()
+					if err != nil {
+						return deliveryRecord{}, err
+					}
+					payload.Id = id.String()
+					data, err = json.Marshal(payload)
+					if err != nil {
+						return deliveryRecord{}, err
+					}
+				}
+				delivery, err := registry.newDelivery(webhook.Id, payload.Event, data)
+				if err != nil {
+					return deliveryRecord{}, err
+				}
+				record.Deliveries = append(record.Deliveries, delivery)
+			}
+		}
+		queued = len(record.Deliveries) > 0
+		return record, nil
+	})
+	if err != nil {
+		return err
+	}
+	if queued {
+		registry.signal()
+	}
+	return nil
+}
+
+// cursor returns the id of the last revision whose events have been queued
+func (registry *Registry) cursor() int64 {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	return registry.state.Cursor
+}
+
+// resume moves the cursor to latest, the id of the last revision recorded, unless the cursor was saved before it. A
+// cursor beyond latest was saved against revisions which no longer exist, such as those of an in-memory store
+func (registry *Registry) resume(latest int64) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.state.Cursor == 0 || registry.state.Cursor > latest {
+		registry.state.Cursor = latest
+	}
+}
+
+// A dueDelivery is a pending delivery whose next attempt is due, alongside where it is sent and the key it is signed with
+type dueDelivery struct {
+	delivery models.Delivery
+	url      string
+	secret   string
+}
+
+// due returns the pending deliveries whose next attempt is due, in the order they are due, and when the next of the
+// remaining pending deliveries is due, which is zero when there are none. The deliveries to webhooks for which skip
+// returns true are left out of both
+func (registry *Registry) due(skip func(webhookId string) bool) ([]dueDelivery, time.Time) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	now := registry.now()
+	var due []dueDelivery
+	var next time.Time
+	for _, delivery := range registry.state.Deliveries {
+		if delivery.Status != models.DeliveryPending || delivery.NextAttemptAt == nil || skip(delivery.WebhookId) {
+			continue
+		}
+		if delivery.NextAttemptAt.After(now) {
+			if next.IsZero() || delivery.NextAttemptAt.Before(next) {
+				next = *delivery.NextAttemptAt
+			}
+			continue
+		}
+		// The webhook always exists, as its deliveries are removed alongside it
+		webhook := registry.state.Webhooks[slices.IndexFunc(registry.state.Webhooks, func(webhook models.Webhook) bool {
+			return webhook.Id == delivery.WebhookId
+		})]
+		due = append(due, dueDelivery{delivery, webhook.Url, webhook.Secret})
+	}
+	slices.SortStableFunc(due, func(a, b dueDelivery) int {
+		return a.delivery.NextAttemptAt.Compare(*b.delivery.NextAttemptAt)
+	})
+	return due, next
+}
+
+// record saves the outcome of an attempt to send delivery. Deliveries whose webhook has since been removed are ignored
+func (registry *Registry) record(delivery models.Delivery) error {
+	return registry.appendDeliveries(func(state registryState) (deliveryRecord, error) {
+		if !slices.ContainsFunc(state.Deliveries, func(existing models.Delivery) bool {
+			return existing.Id == delivery.Id
+		}) {
+			return deliveryRecord{}, nil
+		}
+		return deliveryRecord{Deliveries: []models.Delivery{delivery}}, nil
+	})
+}
+
+// updateWebhooks makes a change to the webhooks by calling change against a copy of the state of the registry, which
+// replaces the state once the webhooks have been saved. Nothing is changed if either change or saving fails. The
+// deliveries removed alongside a webhook are only removed in-memory, those left within the delivery log are ignored
+// when it is next loaded as their webhook no longer exists
+func (registry *Registry) updateWebhooks(change func(state *registryState) error) error {
+	registry.writeMu.Lock()
+	defer registry.writeMu.Unlock()
+	state := registryState{
+		Webhooks:   slices.Clone(registry.state.Webhooks),
+		Deliveries: slices.Clone(registry.state.Deliveries),
+		Cursor:     registry.state.Cursor,
+	}
+	err := change(&state)
+	if err != nil {
+		return err
+	}
+	if registry.path != "" {
+		data, err := json.Marshal(webhooksFile{Webhooks: state.Webhooks})
+		if err == nil {
+			err = writeFile(registry.path, data)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save webhooks: %w", err)
+		}
+	}
+	registry.mu.Lock()
+	registry.state = state
+	registry.mu.Unlock()
+	return nil
+}
+
+// appendDeliveries makes a change to the deliveries by calling decide with the state of the registry, which cannot
+// change until the change has been made. The record it returns is appended to the delivery log and synced to disk, and
+// only then applied to the state, while an empty record changes nothing. The log is compacted once it is due
+func (registry *Registry) appendDeliveries(decide func(state registryState) (deliveryRecord, error)) error {
+	registry.writeMu.Lock()
+	defer registry.writeMu.Unlock()
+	// Only changes replace the state, and they hold writeMu, so it can be read without holding mu
+	record, err := decide(registry.state)
+	if err != nil {
+		return err
+	}
+	if len(record.Deliveries) == 0 && record.Cursor == 0 {
+		return nil
+	}
+	err = registry.appendRecord(record)
+	if err != nil {
+		return fmt.Errorf("failed to save webhook deliveries: %w", err)
+	}
+	registry.mu.Lock()
+	applyRecord(&registry.state, record)
+	registry.mu.Unlock()
+	if registry.path == "" || registry.records < deliveryCompactAfter {
+		return nil
+	}
+	err = registry.compact()
+	if err != nil {
+		return fmt.Errorf("failed to compact webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// appendRecord writes record to the end of the delivery log and syncs it to disk. If it cannot be written the log is
+// truncated back to the end of the previous record. Nothing is written when the registry is held in-memory. The log is
+// only readable by its owner, as it holds the payload of every delivery. The caller must hold writeMu
+func (registry *Registry) appendRecord(record deliveryRecord) error {
+	if registry.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	logPath := registry.path + deliveriesSuffix
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Join(err, file.Close())
+	}
+	_, err = file.Write(append(data, '\n'))
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		err = errors.Join(err, file.Truncate(info.Size()))
+	}
+	err = errors.Join(err, file.Close())
+	if err == nil && registry.records == 0 {
+		// The log may have just been created, so the directory holding it is synced for it to survive a crash
+		err = syncDir(logPath)
+	}
+	if err != nil {
+		return err
+	}
+	registry.records++
+	return nil
+}
+
+// compact rewrites the delivery log as a single record holding every delivery kept and the cursor, dropping the records
+// of deliveries which have since been pruned or removed. The caller must hold writeMu
+func (registry *Registry) compact() error {
+	data, err := json.Marshal(deliveryRecord{Deliveries: registry.state.Deliveries, Cursor: registry.state.Cursor})
+	if err != nil {
+		return err
+	}
+	err = writeFile(registry.path+deliveriesSuffix, append(data, '\n'))
+	if err != nil {
+		return err
+	}
+	registry.records = 1
+	return nil
+}
+
+// applyRecord applies record to state, each delivery within it replacing the delivery with the same id or otherwise
+// being queued, then advances the cursor. Deliveries to webhooks which no longer exist are ignored, as their deliveries
+// were removed alongside them
+func applyRecord(state *registryState, record deliveryRecord) {
+	for _, delivery := range record.Deliveries {
+		index := slices.IndexFunc(state.Deliveries, func(existing models.Delivery) bool {
+			return existing.Id == delivery.Id
+		})
+		if index >= 0 {
+			state.Deliveries[index] = delivery
+		} else if slices.ContainsFunc(state.Webhooks, func(webhook models.Webhook) bool {
+			return webhook.Id == delivery.WebhookId
+		}) {
+			state.Deliveries = append(state.Deliveries, delivery)
+		}
+	}
+	if record.Cursor != 0 {
+		state.Cursor = record.Cursor
+	}
+	state.Deliveries = pruneDeliveries(state.Deliveries)
+}
+
+// writeFile writes data to the file at path, first writing it alongside the file before renaming it over the file so
+// that a crash while writing leaves the previous file intact. The file is only readable by its owner, as it holds the
+// secret of every webhook or the payload of every delivery
+func writeFile(path string, data []byte) error {
+	temp := path + ".tmp"
+	file, err := os.OpenFile(temp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	err = errors.Join(err, file.Close())
+	if err != nil {
+		return err
+	}
+	err = os.Rename(temp, path)
+	if err != nil {
+		return err
+	}
+	return syncDir(path)
+}
+
+// syncDir syncs the directory containing path to disk, so that a file created or renamed within it survives a crash
+func syncDir(path string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	return errors.Join(dir.Sync(), dir.Close())
+}
+
+// newDelivery returns a new pending delivery of payload to the webhook with an id of webhookId, due straight away
+func (registry *Registry) newDelivery(webhookId string, event models.WebhookEvent, payload json.RawMessage) (models.Delivery, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return models.Delivery{}, err
+	}
+	now := registry.now()
+	return models.Delivery{
+		Id:            id.String(),
+		WebhookId:     webhookId,
+		Event:         event,
+		Payload:       payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// signal wakes the Dispatcher, if it is not already due to wake
+func (registry *Registry) signal() {
+	select {
+	case registry.wake <- struct{}{}:
+	default:
+	}
+}
+
+// now returns the current time according to the clock of the registry, in UTC
+func (registry *Registry) now() time.Time {
+	return registry.clock().UTC()
+}
+
+// pruneDeliveries discards the oldest finished deliveries of each webhook beyond the deliveryLogSize most recent
+func pruneDeliveries(deliveries []models.Delivery) []models.Delivery {
+	finished := map[string]int{}
+	keep := make([]bool, len(deliveries))
+	for i, delivery := range slices.Backward(deliveries) {
+		if delivery.Status != models.DeliveryPending {
+			finished[delivery.WebhookId]++
+		}
+		keep[i] = delivery.Status == models.DeliveryPending || finished[delivery.WebhookId] <= deliveryLogSize
+	}
+	pruned := deliveries[:0]
+	for i, delivery := range deliveries {
+		if keep[i] {
+			pruned = append(pruned, delivery)
+		}
+	}
+	return pruned
+}
+
+// findWebhook returns the index of the webhook with an id of id within state, or a NotFoundError if there is none
+func findWebhook(state registryState, id string) (int, error) {
+	index := slices.IndexFunc(state.Webhooks, func(webhook models.Webhook) bool {
+		return webhook.Id == id
+	})
+	if index < 0 {
+		return 0, &services.NotFoundError{Resource: "webhook", Id: id}
+	}
+	return index, nil
+}
+
+// checkVersion returns a VersionMismatchError if expectedVersion is not nil and webhook is at another version
+func checkVersion(webhook models.Webhook, expectedVersion *int64) error {
+	if expectedVersion != nil && *expectedVersion != webhook.Version {
+		return &services.VersionMismatchError{Resource: "webhook", Id: webhook.Id, Expected: *expectedVersion, Actual: webhook.Version}
+	}
+	return nil
+}
+
+// validateWebhook returns a ValidationError listing every field of webhook which is invalid
+func validateWebhook(webhook models.Webhook) error {
+	var fields []services.FieldError
+	target, err := url.Parse(webhook.Url)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		fields = append(fields, services.FieldError{Field: "Url", Message: "must be an absolute http or https URL"})
+	}
+	if len(webhook.Events) == 0 {
+		fields = append(fields, services.FieldError{Field: "Events", Message: "cannot be empty"})
+	}
+	for _, event := range webhook.Events {
+		if !slices.Contains(models.WebhookEvents, event) {
+			names := make([]string, 0, len(models.WebhookEvents))
+			for _, valid := range models.WebhookEvents {
+				names = append(names, string(valid))
+			}
+			fields = append(fields, services.FieldError{Field: "Events",
+				Message: "must only contain " + strings.Join(names, ", ")})
+			break
+		}
+	}
+	if len(fields) > 0 {
+		return &services.ValidationError{Resource: "webhook", Fields: fields}
+	}
+	return nil
+}
+
+// generateSecret returns a new random secret, hex encoded
+func generateSecret() (string, error) {
+	secret := make([]byte, secretBytes)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// redact returns webhook without its secret
+func redact(webhook models.Webhook) models.Webhook {
+	webhook.Secret = ""
+	return webhook
+}
@@ -0,0 +1,407 @@
+package webhooks
+
+import (
+	"TodoApp/src/main/models"
+	"TodoApp/src/main/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var allEvents = []models.WebhookEvent{models.WebhookTodoCreated, models.WebhookTodoUpdated, models.WebhookTodoCompleted,
+	models.WebhookTodoDeleted}
+
+func setupRegistryTest(t *testing.T, path string, prerequisite ...models.Webhook) (*Registry, []models.Webhook) {
+	registry, err := NewRegistry(path)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	var created []models.Webhook
+	for _, webhook := range prerequisite {
+		webhook, err = registry.CreateNewWebhook(context.Background(), webhook)
+		if err != nil {
+			t.Fatalf("Failed to persist prerequisite webhook: [%v]", err)
+		}
+		created = append(created, webhook)
+	}
+	return registry, created
+}
+
+func TestRegistryCreateNewWebhook(t *testing.T) {
+	tests := map[string]struct {
+		webhook        models.Webhook
+		expectedSecret string
+		expectedErr    error
+	}{
+		"Generated Secret": {
+			webhook: models.Webhook{Url: "https://example.com/hook", Events: allEvents},
+		},
+		"Supplied Secret": {
+			webhook:        models.Webhook{Url: "http://localhost:8080", Events: allEvents, Secret: "shh"},
+			expectedSecret: "shh",
+		},
+		"Client Id": {
+			webhook: models.Webhook{Id: "hook", Url: "https://example.com/hook", Events: allEvents},
+			expectedErr: &services.ValidationError{Resource: "webhook",
+				Fields: []services.FieldError{{Field: "Id", Message: "cannot be set by the client"}}},
+		},
+		"Invalid Url And No Events": {
+			webhook: models.Webhook{Url: "/hook"},
+			expectedErr: &services.ValidationError{Resource: "webhook", Fields: []services.FieldError{
+				{Field: "Url", Message: "must be an absolute http or https URL"},
+				{Field: "Events", Message: "cannot be empty"},
+			}},
+		},
+		"Unknown Event": {
+			webhook: models.Webhook{Url: "https://example.com/hook", Events: []models.WebhookEvent{"todo.renamed"}},
+			expectedErr: &services.ValidationError{Resource: "webhook", Fields: []services.FieldError{{Field: "Events",
+				Message: "must only contain todo.created, todo.updated, todo.completed, todo.deleted"}}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			registry, _ := setupRegistryTest(t, "")
+			created, err := registry.CreateNewWebhook(context.Background(), tt.webhook)
+			diff := cmp.Diff(tt.expectedErr, err, cmp.Comparer(func(a, b error) bool { return a.Error() == b.Error() }))
+			if diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				return
+			}
+			if created.Id == "" || created.Version != 1 {
+				t.Errorf("unexpected id [%v] or version [%v] of the created webhook", created.Id, created.Version)
+			}
+			if tt.expectedSecret != "" && created.Secret != tt.expectedSecret {
+				t.Errorf("unexpected secret, expected [%v] but recieved [%v]", tt.expectedSecret, created.Secret)
+			}
+			if len(created.Secret) == 0 {
+				t.Error("unexpected empty secret returned for the created webhook")
+			}
+			stored, err := registry.ReturnSingleWebhook(context.Background(), created.Id)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			created.Secret = ""
+			diff = cmp.Diff(created, stored)
+			if diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestRegistryUpdateAndDeleteWebhook(t *testing.T) {
+	ctx := context.Background()
+	registry, created := setupRegistryTest(t, "",
+		models.Webhook{Url: "https://example.com/hook", Events: allEvents, Secret: "shh"})
+	id := created[0].Id
+	stale := int64(5)
+
+	_, err := registry.UpdateWebhook(ctx, models.Webhook{Id: id, Url: "https://example.com/other", Events: allEvents}, &stale)
+	if !errors.Is(err, services.ErrPreconditionFailed) {
+		t.Fatalf("unexpected error, expected a version mismatch but recieved [%v]", err)
+	}
+	current := int64(1)
+	updated, err := registry.UpdateWebhook(ctx, models.Webhook{Id: id, Url: "https://example.com/other",
+		Events: []models.WebhookEvent{models.WebhookTodoDeleted}}, &current)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if updated.Version != 2 || updated.Secret != "" || updated.Url != "https://example.com/other" {
+		t.Errorf("unexpected updated webhook [%+v]", updated)
+	}
+	if registry.state.Webhooks[0].Secret != "shh" {
+		t.Errorf("unexpected secret, expected [shh] but recieved [%v]", registry.state.Webhooks[0].Secret)
+	}
+
+	err = registry.enqueue(1, models.WebhookPayload{Event: models.WebhookTodoDeleted, Todo: models.Todo{Id: "1"}})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	err = registry.DeleteWebhook(ctx, id, nil)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if len(registry.state.Webhooks) != 0 || len(registry.state.Deliveries) != 0 {
+		t.Errorf("unexpected webhooks [%v] or deliveries [%v] left after deleting", registry.state.Webhooks,
+			registry.state.Deliveries)
+	}
+	_, err = registry.ReturnDeliveries(ctx, id)
+	if err == nil || err.Error() != fmt.Sprintf("could not find webhook with id [%s]", id) {
+		t.Errorf("unexpected error, expected the webhook to be missing but recieved [%v]", err)
+	}
+}
+
+func TestRegistryEnqueueAndRedeliver(t *testing.T) {
+	ctx := context.Background()
+	registry, created := setupRegistryTest(t, "",
+		models.Webhook{Url: "https://example.com/created", Events: []models.WebhookEvent{models.WebhookTodoCreated}},
+		models.Webhook{Url: "https://example.com/all", Events: allEvents})
+
+	err := registry.enqueue(2, models.WebhookPayload{Event: models.WebhookTodoCreated, Actor: "alice", Todo: models.Todo{Id: "1"}},
+		models.WebhookPayload{Event: models.WebhookTodoDeleted, Actor: "bob", Todo: models.Todo{Id: "1"}})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	createdOnly, err := registry.ReturnDeliveries(ctx, created[0].Id)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	all, err := registry.ReturnDeliveries(ctx, created[1].Id)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if len(createdOnly) != 1 || len(all) != 2 || all[0].Event != models.WebhookTodoDeleted {
+		t.Fatalf("unexpected deliveries [%v] and [%v]", createdOnly, all)
+	}
+	var first, second models.WebhookPayload
+	_ = json.Unmarshal(createdOnly[0].Payload, &first)
+	_ = json.Unmarshal(all[1].Payload, &second)
+	if first.Id == "" || first.Id != second.Id || first.Actor != "alice" || registry.cursor() != 2 {
+		t.Errorf("unexpected payloads [%+v] and [%+v] of the same event", first, second)
+	}
+
+	redelivery, err := registry.Redeliver(ctx, created[0].Id, createdOnly[0].Id)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if redelivery.Id == createdOnly[0].Id || string(redelivery.Payload) != string(createdOnly[0].Payload) ||
+		redelivery.Status != models.DeliveryPending {
+		t.Errorf("unexpected redelivery [%+v] of [%+v]", redelivery, createdOnly[0])
+	}
+	// A delivery can only be redelivered to the webhook it was made to
+	_, err = registry.Redeliver(ctx, created[0].Id, all[0].Id)
+	if err == nil || err.Error() != fmt.Sprintf("could not find delivery with id [%s]", all[0].Id) {
+		t.Errorf("unexpected error, expected the delivery to be missing but recieved [%v]", err)
+	}
+}
+
+func TestRegistryPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	registry, created := setupRegistryTest(t, path,
+		models.Webhook{Url: "https://example.com/hook", Events: allEvents, Secret: "shh"})
+	err := registry.enqueue(1, models.WebhookPayload{Event: models.WebhookTodoCreated, Todo: models.Todo{Id: "1"}})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	for _, file := range []string{path, path + deliveriesSuffix} {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatalf("Error occured when none expected: [%v]", err)
+		}
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("unexpected mode of [%s], expected [%v] but recieved [%v]", file, os.FileMode(0o600), info.Mode().Perm())
+		}
+	}
+	// Attempting a delivery only appends a record of it to the delivery log, leaving the webhooks as they were saved
+	webhooks, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	attempted := registry.state.Deliveries[0]
+	attempted.Attempts = 1
+	err = registry.record(attempted)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	if string(after) != string(webhooks) {
+		t.Errorf("unexpected webhooks file, expected [%s] but recieved [%s]", webhooks, after)
+	}
+
+	reloaded, _ := setupRegistryTest(t, path)
+	diff := cmp.Diff(registry.state, reloaded.state)
+	if diff != "" {
+		t.Fatal(diff)
+	}
+	due, _ := reloaded.due(func(string) bool { return false })
+	if len(due) != 1 || due[0].url != created[0].Url || due[0].secret != "shh" || due[0].delivery.Attempts != 1 {
+		t.Errorf("unexpected deliveries due after reloading [%+v]", due)
+	}
+
+	err = os.WriteFile(path, []byte("{"), 0o600)
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	_, err = NewRegistry(path)
+	if err == nil {
+		t.Fatal("Error expected but none occured")
+	}
+}
+
+func TestRegistryDeliveryLog(t *testing.T) {
+	tests := map[string]struct {
+		damage        func(log string) string
+		expected      int
+		expectedError string
+	}{
+		"Torn Final Record": {
+			damage: func(log string) string {
+				return log[:len(log)-10]
+			},
+			expected: 1,
+		},
+		"Corrupt Earlier Record": {
+			damage: func(log string) string {
+				return "not json\n" + log
+			},
+			expectedError: "record on line 1 is corrupt",
+		},
+		"Removed Webhook": {
+			damage: func(log string) string {
+				return log + `{"Deliveries":[{"Id":"orphan","WebhookId":"removed","Status":"pending"}]}` + "\n"
+			},
+			expected: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "webhooks.json")
+			registry, _ := setupRegistryTest(t, path, models.Webhook{Url: "https://example.com/hook", Events: allEvents})
+			for i := range 2 {
+				err := registry.enqueue(int64(i+1), models.WebhookPayload{Event: models.WebhookTodoCreated,
+					Todo: models.Todo{Id: "1"}})
+				if err != nil {
+					t.Fatalf("Error occured when none expected: [%v]", err)
+				}
+			}
+			log, err := os.ReadFile(path + deliveriesSuffix)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			err = os.WriteFile(path+deliveriesSuffix, []byte(tt.damage(string(log))), 0o600)
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+
+			reloaded, err := NewRegistry(path)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("Error [%s] expected but was [%v]", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			// Deliveries to webhooks which no longer exist are ignored, as they are removed alongside their webhook
+			if len(reloaded.state.Deliveries) != tt.expected || reloaded.cursor() != int64(tt.expected) {
+				t.Fatalf("unexpected deliveries [%v] or cursor [%v] after reloading", reloaded.state.Deliveries,
+					reloaded.cursor())
+			}
+		})
+	}
+}
+
+func TestRegistryCompactsDeliveryLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	registry, _ := setupRegistryTest(t, path, models.Webhook{Url: "https://example.com/hook", Events: allEvents})
+	err := registry.enqueue(1, models.WebhookPayload{Event: models.WebhookTodoCreated, Todo: models.Todo{Id: "1"}})
+	if err != nil {
+		t.Fatalf("Error occured when none expected: [%v]", err)
+	}
+	delivery := registry.state.Deliveries[0]
+	for range deliveryCompactAfter - 1 {
+		delivery.Attempts++
+		err = registry.record(delivery)
+		if err != nil {
+			t.Fatalf("Error occured when none expected: [%v]", err)
+		}
+	}
+	if registry.records != 1 {
+		t.Fatalf("unexpected records, expected the log to be compacted into [1] but recieved [%v]", registry.records)
+	}
+
+	reloaded, _ := setupRegistryTest(t, path)
+	diff := cmp.Diff(registry.state, reloaded.state)
+	if diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestRegistryCursor(t *testing.T) {
+	tests := map[string]struct {
+		latest   int64
+		expected int64
+	}{
+		"Resumes From Saved Cursor": {
+			latest:   5,
+			expected: 2,
+		},
+		"Cursor Beyond Latest Revision": {
+			expected: 0,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "webhooks.json")
+			registry, _ := setupRegistryTest(t, path,
+				models.Webhook{Url: "https://example.com/hook", Events: []models.WebhookEvent{models.WebhookTodoCreated}})
+			err := registry.enqueue(1, models.WebhookPayload{Event: models.WebhookTodoCreated, Todo: models.Todo{Id: "1"}})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			// Nothing is subscribed to the event, so only the cursor is saved
+			err = registry.enqueue(2, models.WebhookPayload{Event: models.WebhookTodoDeleted, Todo: models.Todo{Id: "1"}})
+			if err != nil {
+				t.Fatalf("Error occured when none expected: [%v]", err)
+			}
+			if registry.cursor() != 2 || len(registry.state.Deliveries) != 1 {
+				t.Fatalf("unexpected cursor [%v] or deliveries [%v]", registry.cursor(), registry.state.Deliveries)
+			}
+
+			reloaded, _ := setupRegistryTest(t, path)
+			reloaded.resume(tt.latest)
+			if reloaded.cursor() != tt.expected {
+				t.Errorf("unexpected cursor, expected [%v] but recieved [%v]", tt.expected, reloaded.cursor())
+			}
+		})
+	}
+}
+
+func TestPruneDeliveries(t *testing.T) {
+	var deliveries []models.Delivery
+	for i := range deliveryLogSize + 2 {
+		status := models.DeliverySucceeded
+		if i == 0 {
+			status = models.DeliveryPending
+		}
+		deliveries = append(deliveries,
+			models.Delivery{Id: fmt.Sprintf("a%d", i), WebhookId: "a", Status: status},
+			models.Delivery{Id: fmt.Sprintf("b%d", i), WebhookId: "b", Status: models.DeliveryFailed})
+	}
+	pruned := pruneDeliveries(deliveries)
+
+	counts := map[string]int{}
+	for _, delivery := range pruned {
+		counts[delivery.WebhookId]++
+	}
+	// The oldest delivery to "a" is kept as it is still pending, alongside the most recent finished deliveries
+	expected := map[string]int{"a": deliveryLogSize + 1, "b": deliveryLogSize}
+	diff := cmp.Diff(expected, counts)
+	if diff != "" {
+		t.Fatal(diff)
+	}
+	if pruned[0].Id != "a0" || pruned[len(pruned)-1].Id != fmt.Sprintf("b%d", deliveryLogSize+1) {
+		t.Errorf("unexpected first [%v] or last [%v] delivery kept", pruned[0].Id, pruned[len(pruned)-1].Id)
+	}
+}
+
+// fixedClock returns a clock which reports *now, which tests advance as they need
+func fixedClock(now *time.Time) func() time.Time {
+	return func() time.Time { return *now }
+}
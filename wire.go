@@ -1,24 +1,236 @@
 package main
 
 import (
+	"TodoApp/src/main/config"
 	"TodoApp/src/main/controllers"
+	"TodoApp/src/main/feed"
+	"TodoApp/src/main/logging"
+	"TodoApp/src/main/metrics"
 	"TodoApp/src/main/models"
+	"TodoApp/src/main/server"
 	"TodoApp/src/main/services"
+	"TodoApp/src/main/webhooks"
+	"context"
+	"fmt"
 	"github.com/google/wire"
+	"log/slog"
+	"os"
+	"time"
 )
 
-func InitializeTodoController() controllers.TodoController {
-	todoServiceImpl := provideTodoServiceImpl()
-	todoController := controllers.NewTodoController(todoServiceImpl)
-	return todoController
+func InitializeServer(cfg config.Config) (*server.Server, func(), error) {
+	options := provideServiceOptions(cfg)
+	logger := provideLogger(cfg)
+	store, cleanup, err := provideStore(cfg, options, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	changeFeed, err := provideFeed(cfg, store, logger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	registry, err := provideWebhookRegistry(cfg)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	dispatcher, err := provideWebhookDispatcher(cfg, registry, store, logger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	todoService := provideTodoService(store)
+	todoController := controllers.NewTodoController(todoService, logger)
+	tagController := controllers.NewTagController(todoService, logger)
+	listService := provideListService(store)
+	listController := controllers.NewListController(listService, todoService, logger)
+	trashController := controllers.NewTrashController(todoService, logger)
+	auditController := controllers.NewAuditController(todoService, logger)
+	eventStream := provideEventStream(store)
+	eventController := controllers.NewEventController(eventStream, logger)
+	feedController := provideFeedController(cfg, changeFeed, logger)
+	socketController := provideSocketController(cfg, todoService, changeFeed, logger)
+	webhookService := provideWebhookService(registry)
+	webhookController := controllers.NewWebhookController(webhookService, logger)
+	healthController := controllers.NewHealthController(todoService, logger)
+	metricsMetrics := metrics.New(todoService)
+	handler := server.NewRouter(todoController, tagController, listController, trashController, auditController, eventController, feedController, socketController, webhookController, healthController, logger, metricsMetrics)
+	serverConfig := provideServerConfig(cfg)
+	trashPurger := provideTrashPurger(cfg, todoService, logger)
+	serverServer := server.New(serverConfig, handler, logger, trashPurger, changeFeed, dispatcher)
+	return serverServer, func() {
+		cleanup()
+	}, nil
 }
 
 // wire.go:
 
-func provideTodoServiceImpl() *services.TodoServiceImpl {
-	var todos []models.Todo
-	return services.NewTodoServiceImpl(todos)
+func provideServiceOptions(cfg config.Config) services.Options {
+	return services.Options{
+		AllowClientIds: cfg.AllowClientIds,
+		Clock:          time.Now,
+		CompletionRule: services.CompletionRule(cfg.CompletionRule),
+	}
+}
+
+// provideLogger creates the logger shared by every component of the API, writing JSON lines to stdout
+func provideLogger(cfg config.Config) *slog.Logger {
+	return logging.New(os.Stdout, cfg.LogLevel)
+}
+
+func provideServerConfig(cfg config.Config) config.ServerConfig {
+	return cfg.Server
+}
+
+func provideTodoServiceImpl(options services.Options) *services.TodoServiceImpl {
+	todos := []models.Todo{}
+	return services.NewTodoServiceImpl(todos, options)
+}
+
+// provideSqliteTodoService opens the SQLite database named by the config, returning a cleanup function which closes it
+// once the API has stopped serving requests
+func provideSqliteTodoService(cfg config.Config, options services.Options, logger *slog.Logger) (*services.SqliteTodoService, func(), error) {
+	db, err := services.OpenSqliteDB(cfg.SqlitePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	service, err := services.NewSqliteTodoService(db, options)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return service, func() {
+		err := service.Close()
+		if err != nil {
+			logger.Error("Failed to close sqlite database", "error", err)
+		}
+	}, nil
+}
+
+// provideFileTodoService replays the log file named by the config, returning a cleanup function which closes it once
+// the API has stopped serving requests
+func provideFileTodoService(cfg config.Config, options services.Options, logger *slog.Logger) (*services.FileTodoService, func(), error) {
+	service, err := services.NewFileTodoService(cfg.FilePath, options, cfg.FileCompactAfter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return service, func() {
+		err := service.Close()
+		if err != nil {
+			logger.Error("Failed to close todo log file", "error", err)
+		}
+	}, nil
+}
+
+// provideEventTodoService replays the event log named by the config, returning a cleanup function which closes it once
+// the API has stopped serving requests
+func provideEventTodoService(cfg config.Config, options services.Options, logger *slog.Logger) (*services.EventTodoService, func(), error) {
+	service, err := services.NewEventTodoService(cfg.EventsPath, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	return service, func() {
+		err := service.Close()
+		if err != nil {
+			logger.Error("Failed to close event log file", "error", err)
+		}
+	}, nil
+}
+
+// provideStore selects the backend named by the Store field of the config, alongside a cleanup function which flushes it
+func provideStore(cfg config.Config, options services.Options, logger *slog.Logger) (services.Store, func(), error) {
+	switch cfg.Store {
+	case config.StoreMemory:
+		return provideTodoServiceImpl(options), func() {}, nil
+	case config.StoreSqlite:
+		return provideSqliteTodoService(cfg, options, logger)
+	case config.StoreFile:
+		return provideFileTodoService(cfg, options, logger)
+	case config.StoreEvents:
+		return provideEventTodoService(cfg, options, logger)
+	default:
+		return nil, nil, fmt.Errorf("unknown todo store [%s]", cfg.Store)
+	}
+}
+
+// provideTrashPurger creates the background job which removes Todo items kept in the trash for longer than the retention
+// period of the config
+func provideTrashPurger(cfg config.Config, todoService services.TodoService, logger *slog.Logger) server.Job {
+	return services.NewTrashPurger(todoService, cfg.TrashRetention, cfg.TrashPurgeInterval, logger)
+}
+
+// provideFeed creates the feed publishing every change made to a Todo item, buffering as many notifications as the
+// config allows for clients resuming their stream. The feed observes the store, so each change is published once it has
+// been committed
+func provideFeed(cfg config.Config, store services.Store, logger *slog.Logger) (*feed.Feed, error) {
+	changeFeed, err := feed.New(context.Background(), store, cfg.FeedBufferSize, logger)
+	if err != nil {
+		return nil, err
+	}
+	store.Observe(changeFeed.Sync)
+	return changeFeed, nil
+}
+
+// provideFeedController creates the controller streaming the feed, sending heartbeats at the interval of the config
+func provideFeedController(cfg config.Config, changeFeed *feed.Feed, logger *slog.Logger) controllers.FeedController {
+	return controllers.NewFeedController(changeFeed, cfg.FeedHeartbeatInterval, logger)
+}
+
+// provideSocketController creates the controller serving the WebSocket, pinging clients and buffering messages to them
+// as the config allows. Messages from clients share the size limit of request bodies
+func provideSocketController(cfg config.Config, todoService services.TodoService, changeFeed *feed.Feed,
+	logger *slog.Logger) controllers.SocketController {
+	return controllers.NewSocketController(todoService, changeFeed, cfg.SocketPingInterval, cfg.SocketSendBuffer,
+		cfg.Server.MaxBodyBytes, logger)
+}
+
+// provideWebhookRegistry loads the webhooks and their queued deliveries from the file named by the config, holding them
+// in-memory when no file is named
+func provideWebhookRegistry(cfg config.Config) (*webhooks.Registry, error) {
+	return webhooks.NewRegistry(cfg.WebhookPath)
+}
+
+// provideWebhookService exposes the webhooks held by the registry
+func provideWebhookService(registry *webhooks.Registry) webhooks.Service {
+	return registry
+}
+
+// provideWebhookDispatcher creates the background job which queues the changes recorded by the store within the
+// registry, then sends the deliveries, retrying them as the config allows. The dispatcher observes the store, so it
+// wakes once each change has been committed
+func provideWebhookDispatcher(cfg config.Config, registry *webhooks.Registry, store services.Store, logger *slog.Logger) (server.Job, error) {
+	dispatcher, err := webhooks.NewDispatcher(context.Background(), registry, store, cfg.WebhookTimeout,
+		cfg.WebhookMaxAttempts, cfg.WebhookBackoff, logger)
+	if err != nil {
+		return nil, err
+	}
+	store.Observe(dispatcher.Wake)
+	return dispatcher, nil
+}
+
+// provideTodoService exposes the Todo items persisted by the store
+func provideTodoService(store services.Store) services.TodoService {
+	return store
+}
+
+// provideEventStream exposes the event stream recorded by the store, which is nil unless the store is event-sourced
+func provideEventStream(store services.Store) services.EventStream {
+	eventStream, ok := store.(services.EventStream)
+	if !ok {
+		return nil
+	}
+	return eventStream
+}
+
+// provideListService exposes the lists persisted by the store
+func provideListService(store services.Store) services.ListService {
+	return store
 }
 
 var Set = wire.NewSet(
-	provideTodoServiceImpl, wire.Bind(new(services.TodoService), new(*services.TodoServiceImpl)))
+	provideServiceOptions, provideLogger, provideServerConfig, provideStore, provideFeed, provideFeedController,
+	provideSocketController, provideTodoService, provideListService, provideEventStream, provideTrashPurger,
+	provideWebhookRegistry, provideWebhookService, provideWebhookDispatcher,
+	controllers.NewTodoController, controllers.NewTagController, controllers.NewListController, controllers.NewTrashController, controllers.NewAuditController,
+	controllers.NewEventController, controllers.NewWebhookController, controllers.NewHealthController, metrics.New, server.NewRouter, server.New)